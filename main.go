@@ -5,11 +5,18 @@
 package main
 
 import (
+	"os"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 	"github.com/vmware/terraform-provider-vcfa/vcfa"
 )
 
 func main() {
+	// The provider binary can also be used as a kubectl exec credential plugin
+	if len(os.Args) > 1 && os.Args[1] == vcfa.CredentialHelperCommand {
+		os.Exit(vcfa.RunCredentialHelper(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: vcfa.Provider})
 }
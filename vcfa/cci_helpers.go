@@ -0,0 +1,341 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/vmware/go-vcloud-director/v3/ccitypes"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"github.com/vmware/go-vcloud-director/v3/util"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateCciEntity performs a PUT request to the given CCI entity URL, sending 'payload' and
+// unmarshalling the response into 'outType'. The go-vcloud-director SDK only offers POST, GET and DELETE
+// for CCI entities, so this function complements them
+func updateCciEntity(tmClient *VCDClient, urlRef *url.URL, payload, outType interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON data for PUT request: %s", err)
+	}
+	return performCciRequest(tmClient, http.MethodPut, urlRef, "application/json", body, outType)
+}
+
+// patchCciEntity performs a JSON merge patch request to the given CCI entity URL, sending 'payload' and
+// unmarshalling the response into 'outType'. Only the fields present in 'payload' are modified, so it is
+// safe to use with objects that have more fields than the ones modelled by the provider
+func patchCciEntity(tmClient *VCDClient, urlRef *url.URL, payload, outType interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON data for PATCH request: %s", err)
+	}
+	return performCciRequest(tmClient, http.MethodPatch, urlRef, "application/merge-patch+json", body, outType)
+}
+
+// createCciEntity performs a POST request to the given CCI collection URL, sending 'payload' and
+// unmarshalling the response into 'outType'. Unlike the go-vcloud-director SDK, it can target URLs
+// outside of the CCI API base path, like the Kubernetes endpoints of Supervisor Namespaces
+func createCciEntity(tmClient *VCDClient, urlRef *url.URL, payload, outType interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON data for POST request: %s", err)
+	}
+	return performCciRequest(tmClient, http.MethodPost, urlRef, "application/json", body, outType)
+}
+
+// performCciRequest sends a request with the given method and body to a CCI entity URL, using the
+// same authentication headers as the go-vcloud-director SDK. If 'outType' is not nil, the response
+// body is unmarshalled into it. A 404 response returns an error that contains govcd.ErrorEntityNotFound,
+// so it can be checked with govcd.ContainsNotFound
+func performCciRequest(tmClient *VCDClient, method string, urlRef *url.URL, contentType string, body []byte, outType interface{}) error {
+	client := tmClient.Client
+	req, err := http.NewRequest(method, urlRef.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error building %s request for %s: %s", method, urlRef.String(), err)
+	}
+
+	if client.VCDAuthHeader != "" && client.VCDToken != "" {
+		req.Header.Add(client.VCDAuthHeader, client.VCDToken)
+		// Bearer tokens are longer than the deprecated 32 characters authorization token
+		if len(client.VCDToken) > 32 {
+			req.Header.Add("Authorization", "bearer "+client.VCDToken)
+			req.Header.Add("X-Vmware-Vcloud-Token-Type", "Bearer")
+		}
+	}
+	req.Header.Add("Content-Type", contentType)
+	req.Header.Add("Accept", "application/json")
+	if client.UserAgent != "" {
+		req.Header.Set("User-Agent", client.UserAgent)
+	}
+
+	util.Logger.Printf("[TRACE] %s CCI entity at endpoint %s", method, urlRef.String())
+	resp, err := client.Http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error performing %s request to %s: %s", method, urlRef.String(), err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body of %s request to %s: %s", method, urlRef.String(), err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := ccitypes.ApiError{}
+		if jsonErr := json.Unmarshal(respBody, &apiErr); jsonErr != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(respBody))
+			apiErr.Code = int32(resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s: %s", govcd.ErrorEntityNotFound, apiErr)
+		}
+		return fmt.Errorf("error in HTTP %s request: %s", method, apiErr)
+	}
+
+	if outType != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, outType); err != nil {
+			return fmt.Errorf("error decoding JSON response after %s: %s", method, err)
+		}
+	}
+
+	return nil
+}
+
+// isCciConditionTrue returns true when the condition of type 'conditionType' is present in the
+// given conditions and its status is "True"
+func isCciConditionTrue(conditions []cciStatusCondition, conditionType string) bool {
+	for _, condition := range conditions {
+		if strings.EqualFold(condition.Type, conditionType) {
+			return strings.EqualFold(condition.Status, "true")
+		}
+	}
+	return false
+}
+
+// getCciConditionMessage returns the reason and message of the condition of type 'conditionType',
+// so they can be shown to the user when something fails
+func getCciConditionMessage(conditions []cciStatusCondition, conditionType string) string {
+	for _, condition := range conditions {
+		if strings.EqualFold(condition.Type, conditionType) {
+			return fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+		}
+	}
+	return ""
+}
+
+// discoverKubernetesApiResource uses the Kubernetes discovery API of the given endpoint to find the
+// resource (plural name and scope) that serves objects of the given API version and kind
+func discoverKubernetesApiResource(tmClient *VCDClient, endpoint, apiVersion, kind string) (*v1.APIResource, error) {
+	discoveryPath := "/apis/" + apiVersion
+	if !strings.Contains(apiVersion, "/") {
+		// Core group objects, like Secrets or ConfigMaps, are served under '/api'
+		discoveryPath = "/api/" + apiVersion
+	}
+	discoveryURL, err := url.ParseRequestURI(strings.TrimSuffix(endpoint, "/") + discoveryPath)
+	if err != nil {
+		return nil, fmt.Errorf("error building discovery URL for API version %s: %s", apiVersion, err)
+	}
+
+	var resourceList v1.APIResourceList
+	if err := performCciRequest(tmClient, http.MethodGet, discoveryURL, "application/json", nil, &resourceList); err != nil {
+		return nil, fmt.Errorf("error discovering resources of API version %s: %s", apiVersion, err)
+	}
+
+	for _, apiResource := range resourceList.APIResources {
+		// Subresources like 'status' or 'scale' share the Kind with their parent resource
+		if apiResource.Kind == kind && !strings.Contains(apiResource.Name, "/") {
+			return &apiResource, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: kind %s is not served by API version %s", govcd.ErrorEntityNotFound, kind, apiVersion)
+}
+
+// buildKubernetesObjectURL builds the URL of a Kubernetes object served by the given endpoint
+func buildKubernetesObjectURL(endpoint, apiVersion string, apiResource *v1.APIResource, namespace, name string) (*url.URL, error) {
+	objectPath := "/apis/" + apiVersion
+	if !strings.Contains(apiVersion, "/") {
+		objectPath = "/api/" + apiVersion
+	}
+	if apiResource.Namespaced {
+		objectPath = objectPath + "/namespaces/" + url.PathEscape(namespace)
+	}
+	objectPath = objectPath + "/" + apiResource.Name + "/" + url.PathEscape(name)
+
+	return url.ParseRequestURI(strings.TrimSuffix(endpoint, "/") + objectPath)
+}
+
+// applyKubernetesObject performs a server-side apply of the given object, using 'fieldManager' as owner
+// of the fields present in it. When 'force' is true, conflicts with other field managers are overridden
+func applyKubernetesObject(tmClient *VCDClient, objectURL *url.URL, fieldManager string, force bool, object map[string]interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling object for server-side apply: %s", err)
+	}
+
+	applyURL := *objectURL
+	queryParams := url.Values{}
+	queryParams.Set("fieldManager", fieldManager)
+	queryParams.Set("force", fmt.Sprintf("%t", force))
+	applyURL.RawQuery = queryParams.Encode()
+
+	result := map[string]interface{}{}
+	// JSON is valid YAML, so it can be sent as an apply patch
+	if err := performCciRequest(tmClient, http.MethodPatch, &applyURL, "application/apply-patch+yaml", body, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readKubernetesObject retrieves the Kubernetes object that lives in the given URL
+func readKubernetesObject(tmClient *VCDClient, objectURL *url.URL) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if err := performCciRequest(tmClient, http.MethodGet, objectURL, "application/json", nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteKubernetesObject deletes the Kubernetes object that lives in the given URL. The deletion is
+// propagated in the foreground, so that dependent objects are removed before the object itself disappears
+func deleteKubernetesObject(tmClient *VCDClient, objectURL *url.URL) error {
+	body, err := json.Marshal(v1.DeleteOptions{
+		TypeMeta:          v1.TypeMeta{Kind: "DeleteOptions", APIVersion: "v1"},
+		PropagationPolicy: addrOf(v1.DeletePropagationForeground),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling delete options: %s", err)
+	}
+	return performCciRequest(tmClient, http.MethodDelete, objectURL, "application/json", body, nil)
+}
+
+// getKubernetesObjectField returns the value of a field of a Kubernetes object, given its dot separated
+// path (i.e. 'status.phase'). Numeric path elements are used as indexes when the field is a list
+// (i.e. 'status.conditions.0.type'). The second returned value is false if the field is not found
+func getKubernetesObjectField(object map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = object
+	for _, element := range strings.Split(path, ".") {
+		switch typedCurrent := current.(type) {
+		case map[string]interface{}:
+			value, ok := typedCurrent[element]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(element)
+			if err != nil || index < 0 || index >= len(typedCurrent) {
+				return nil, false
+			}
+			current = typedCurrent[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// kubernetesObjectCondition represents a condition that a Kubernetes object must meet
+type kubernetesObjectCondition struct {
+	conditionType string
+	status        string
+}
+
+// waitForKubernetesObject polls the Kubernetes object at the given URL until all the given conditions are
+// present in 'status.conditions' with the expected status, and all the given fields have the expected values.
+// A field with expected value "*" only needs to exist and be non-empty
+func waitForKubernetesObject(ctx context.Context, tmClient *VCDClient, objectURL *url.URL, conditions []kubernetesObjectCondition, fields map[string]string, timeout time.Duration) (map[string]interface{}, error) {
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"WAITING"},
+		Target:  []string{"DONE"},
+		Refresh: func() (any, string, error) {
+			object, err := readKubernetesObject(tmClient, objectURL)
+			if err != nil {
+				return nil, "", err
+			}
+
+			objectConditions := []cciStatusCondition{}
+			if rawConditions, ok := getKubernetesObjectField(object, "status.conditions"); ok {
+				// Conditions are converted to the common type, to reuse the condition helpers
+				conditionsJson, err := json.Marshal(rawConditions)
+				if err != nil {
+					return nil, "", err
+				}
+				if err := json.Unmarshal(conditionsJson, &objectConditions); err != nil {
+					return nil, "", fmt.Errorf("error reading status conditions: %s", err)
+				}
+			}
+			for _, condition := range conditions {
+				found := false
+				for _, objectCondition := range objectConditions {
+					if strings.EqualFold(objectCondition.Type, condition.conditionType) {
+						found = strings.EqualFold(objectCondition.Status, condition.status)
+						break
+					}
+				}
+				if !found {
+					log.Printf("[DEBUG] waiting for condition %s to be %s in %s", condition.conditionType, condition.status, objectURL.Path)
+					return object, "WAITING", nil
+				}
+			}
+
+			for path, expectedValue := range fields {
+				value, ok := getKubernetesObjectField(object, path)
+				if !ok || value == nil || fmt.Sprintf("%v", value) == "" {
+					log.Printf("[DEBUG] waiting for field %s to be present in %s", path, objectURL.Path)
+					return object, "WAITING", nil
+				}
+				if expectedValue != "*" && fmt.Sprintf("%v", value) != expectedValue {
+					log.Printf("[DEBUG] waiting for field %s to be %s in %s, current value is %v", path, expectedValue, objectURL.Path, value)
+					return object, "WAITING", nil
+				}
+			}
+
+			return object, "DONE", nil
+		},
+		Timeout:    timeout,
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	result, err := stateChangeFunc.WaitForStateContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+// waitForKubernetesObjectDeletion polls the Kubernetes object at the given URL until it is not found
+func waitForKubernetesObjectDeletion(ctx context.Context, tmClient *VCDClient, objectURL *url.URL, timeout time.Duration) error {
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"DELETING"},
+		Target:  []string{"DELETED"},
+		Refresh: func() (any, string, error) {
+			object, err := readKubernetesObject(tmClient, objectURL)
+			if err != nil {
+				if govcd.ContainsNotFound(err) {
+					return "", "DELETED", nil
+				}
+				return nil, "", err
+			}
+			return object, "DELETING", nil
+		},
+		Timeout:    timeout,
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	_, err := stateChangeFunc.WaitForStateContext(ctx)
+	return err
+}
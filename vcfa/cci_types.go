@@ -0,0 +1,247 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"github.com/vmware/go-vcloud-director/v3/ccitypes"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// This file contains the definitions of the CCI (Kubernetes-like) API objects that are consumed by the provider
+// but are not (yet) available in the go-vcloud-director SDK 'ccitypes' package.
+
+const (
+	cciVpcKind    = "VPC"
+	cciVpcAPI     = "vpc.nsx.vmware.com"
+	cciVpcVersion = "v1alpha1"
+	cciVpcsURL    = "/apis/" + cciVpcAPI + "/" + cciVpcVersion + "/namespaces/%s/vpcs"
+)
+
+// cciStatusCondition is the common structure of the conditions reported in the status of CCI objects
+type cciStatusCondition struct {
+	Message  string `json:"message,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+// cciVpc defines a VPC that belongs to a Project
+type cciVpc struct {
+	v1.TypeMeta   `json:",inline"`
+	v1.ObjectMeta `json:"metadata,omitempty"`
+	Spec          cciVpcSpec    `json:"spec,omitempty"`
+	Status        *cciVpcStatus `json:"status,omitempty"`
+}
+
+type cciVpcSpec struct {
+	Description                string           `json:"description,omitempty"`
+	RegionName                 string           `json:"regionName,omitempty"`
+	PrivateIPs                 []string         `json:"privateIPs,omitempty"`
+	VpcConnectivityProfileName string           `json:"vpcConnectivityProfileName,omitempty"`
+	IsDefault                  bool             `json:"isDefault,omitempty"`
+	QosConfig                  *cciVpcQosConfig `json:"qosConfig,omitempty"`
+}
+
+// cciVpcQosConfig contains the QoS settings of a VPC. When they are not set, the VPC inherits
+// the QoS settings of the Org Regional Networking VPC Connectivity Profile
+type cciVpcQosConfig struct {
+	IngressProfile *cciVpcQosProfile `json:"ingressProfile,omitempty"`
+	EgressProfile  *cciVpcQosProfile `json:"egressProfile,omitempty"`
+}
+
+type cciVpcQosProfile struct {
+	CommittedBandwidthMbps int `json:"committedBandwidthMbps"`
+	BurstSizeBytes         int `json:"burstSizeBytes"`
+}
+
+type cciVpcStatus struct {
+	Conditions                 []cciStatusCondition `json:"conditions,omitempty"`
+	Phase                      string               `json:"phase,omitempty"`
+	PrivateIPs                 []string             `json:"privateIPs,omitempty"`
+	VpcConnectivityProfileName string               `json:"vpcConnectivityProfileName,omitempty"`
+	IsDefault                  *bool                `json:"isDefault,omitempty"`
+	QosConfig                  *cciVpcQosConfig     `json:"qosConfig,omitempty"`
+}
+
+const (
+	cciVirtualMachineKind    = "VirtualMachine"
+	cciVirtualMachineAPI     = "vmoperator.vmware.com"
+	cciVirtualMachineVersion = "v1alpha3"
+	// cciVirtualMachinesPath is relative to the Kubernetes endpoint of a Supervisor Namespace
+	cciVirtualMachinesPath = "/apis/" + cciVirtualMachineAPI + "/" + cciVirtualMachineVersion + "/namespaces/%s/virtualmachines"
+)
+
+// cciVirtualMachine defines a VM Service Virtual Machine that lives in a Supervisor Namespace
+type cciVirtualMachine struct {
+	v1.TypeMeta   `json:",inline"`
+	v1.ObjectMeta `json:"metadata,omitempty"`
+	Spec          cciVirtualMachineSpec    `json:"spec,omitempty"`
+	Status        *cciVirtualMachineStatus `json:"status,omitempty"`
+}
+
+type cciVirtualMachineSpec struct {
+	ImageName    string                          `json:"imageName,omitempty"`
+	ClassName    string                          `json:"className,omitempty"`
+	StorageClass string                          `json:"storageClass,omitempty"`
+	PowerState   string                          `json:"powerState,omitempty"`
+	Network      *cciVirtualMachineNetworkSpec   `json:"network,omitempty"`
+	Bootstrap    *cciVirtualMachineBootstrapSpec `json:"bootstrap,omitempty"`
+}
+
+type cciVirtualMachineNetworkSpec struct {
+	Interfaces []cciVirtualMachineNetworkInterfaceSpec `json:"interfaces,omitempty"`
+}
+
+type cciVirtualMachineNetworkInterfaceSpec struct {
+	Name    string                       `json:"name"`
+	Network *cciVirtualMachineNetworkRef `json:"network,omitempty"`
+}
+
+type cciVirtualMachineNetworkRef struct {
+	Name string `json:"name,omitempty"`
+}
+
+type cciVirtualMachineBootstrapSpec struct {
+	CloudInit *cciVirtualMachineCloudInitSpec `json:"cloudInit,omitempty"`
+}
+
+// cciVirtualMachineCloudInitSpec references the Secret key that contains the cloud-init user data
+type cciVirtualMachineCloudInitSpec struct {
+	RawCloudConfig *cciSecretKeySelector `json:"rawCloudConfig,omitempty"`
+}
+
+type cciSecretKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+type cciVirtualMachineStatus struct {
+	Conditions []cciStatusCondition            `json:"conditions,omitempty"`
+	PowerState string                          `json:"powerState,omitempty"`
+	Network    *cciVirtualMachineNetworkStatus `json:"network,omitempty"`
+	UniqueID   string                          `json:"uniqueID,omitempty"`
+	Zone       string                          `json:"zone,omitempty"`
+}
+
+type cciVirtualMachineNetworkStatus struct {
+	PrimaryIP4 string `json:"primaryIP4,omitempty"`
+	PrimaryIP6 string `json:"primaryIP6,omitempty"`
+}
+
+const (
+	cciClusterKind    = "Cluster"
+	cciClusterAPI     = "cluster.x-k8s.io"
+	cciClusterVersion = "v1beta1"
+	// cciClusterResource is the plural name of the Cluster API resource that serves Kubernetes Clusters
+	cciClusterResource = "clusters"
+)
+
+// cciSupervisorNamespaceList is the list of Supervisor Namespaces of a Project
+type cciSupervisorNamespaceList struct {
+	v1.TypeMeta `json:",inline"`
+	v1.ListMeta `json:"metadata,omitempty"`
+	Items       []ccitypes.SupervisorNamespace `json:"items"`
+}
+
+const (
+	// cciResourceQuotasPath is relative to the Kubernetes endpoint of a Supervisor Namespace
+	cciResourceQuotasPath = "/api/v1/namespaces/%s/resourcequotas"
+	// cciZoneLabel is the well-known label that identifies the zone of a Kubernetes object
+	cciZoneLabel = "topology.kubernetes.io/zone"
+	// cciStorageClassQuotaSuffix is appended to a Storage Class name to build the key of its storage quota
+	cciStorageClassQuotaSuffix = ".storageclass.storage.k8s.io/requests.storage"
+)
+
+// cciResourceQuotaList is the list of Kubernetes ResourceQuotas of a Supervisor Namespace. Only the fields
+// that report the limits and consumption of the Supervisor Namespace are defined
+type cciResourceQuotaList struct {
+	v1.TypeMeta `json:",inline"`
+	v1.ListMeta `json:"metadata,omitempty"`
+	Items       []cciResourceQuota `json:"items"`
+}
+
+type cciResourceQuota struct {
+	v1.TypeMeta   `json:",inline"`
+	v1.ObjectMeta `json:"metadata,omitempty"`
+	Status        cciResourceQuotaStatus `json:"status,omitempty"`
+}
+
+type cciResourceQuotaStatus struct {
+	Hard map[string]resource.Quantity `json:"hard,omitempty"`
+	Used map[string]resource.Quantity `json:"used,omitempty"`
+}
+
+const (
+	// cciVirtualMachineImagesPath is relative to the Kubernetes endpoint of a Supervisor Namespace
+	cciVirtualMachineImagesPath = "/apis/" + cciVirtualMachineAPI + "/" + cciVirtualMachineVersion + "/namespaces/%s/virtualmachineimages"
+)
+
+// cciVirtualMachineImageList is the list of VM Service images that are available in a Supervisor Namespace
+type cciVirtualMachineImageList struct {
+	v1.TypeMeta `json:",inline"`
+	v1.ListMeta `json:"metadata,omitempty"`
+	Items       []cciVirtualMachineImage `json:"items"`
+}
+
+// cciVirtualMachineImage is generated by the Supervisor for each Content Library item that is available in a
+// Supervisor Namespace. Its name is the Virtual Machine Identifier (VMI) of the Content Library item
+type cciVirtualMachineImage struct {
+	v1.TypeMeta   `json:",inline"`
+	v1.ObjectMeta `json:"metadata,omitempty"`
+	Status        cciVirtualMachineImageStatus `json:"status,omitempty"`
+}
+
+type cciVirtualMachineImageStatus struct {
+	Conditions             []cciStatusCondition              `json:"conditions,omitempty"`
+	Name                   string                            `json:"name,omitempty"`
+	OSInfo                 cciVirtualMachineImageOSInfo      `json:"osInfo,omitempty"`
+	ProductInfo            cciVirtualMachineImageProductInfo `json:"productInfo,omitempty"`
+	ProviderItemID         string                            `json:"providerItemID,omitempty"`
+	ProviderContentVersion string                            `json:"providerContentVersion,omitempty"`
+	Type                   string                            `json:"type,omitempty"`
+}
+
+type cciVirtualMachineImageOSInfo struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type cciVirtualMachineImageProductInfo struct {
+	Product     string `json:"product,omitempty"`
+	Vendor      string `json:"vendor,omitempty"`
+	Version     string `json:"version,omitempty"`
+	FullVersion string `json:"fullVersion,omitempty"`
+}
+
+const (
+	cciContentLibraryKind    = "ContentLibrary"
+	cciContentLibraryAPI     = "imageregistry.vmware.com"
+	cciContentLibraryVersion = "v1alpha1"
+	// cciContentLibrariesPath is relative to the Kubernetes endpoint of a Supervisor Namespace
+	cciContentLibrariesPath = "/apis/" + cciContentLibraryAPI + "/" + cciContentLibraryVersion + "/namespaces/%s/contentlibraries"
+)
+
+// cciContentLibrary attaches a vCenter Content Library to a Supervisor Namespace, so its items are available
+// as Virtual Machine Images
+type cciContentLibrary struct {
+	v1.TypeMeta   `json:",inline"`
+	v1.ObjectMeta `json:"metadata,omitempty"`
+	Spec          cciContentLibrarySpec    `json:"spec,omitempty"`
+	Status        *cciContentLibraryStatus `json:"status,omitempty"`
+}
+
+type cciContentLibrarySpec struct {
+	UUID     string `json:"uuid"`
+	Writable bool   `json:"writable,omitempty"`
+}
+
+type cciContentLibraryStatus struct {
+	Conditions []cciStatusCondition `json:"conditions,omitempty"`
+	Name       string               `json:"name,omitempty"`
+	Type       string               `json:"type,omitempty"`
+}
@@ -0,0 +1,175 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"crypto/sha1" // #nosec G505 -- SHA-1 is only used to show the fingerprint that other tools display
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// certificateMetadataSchema contains the computed attributes that are parsed from the PEM of a Certificate. They are
+// shared by the resource and the data source
+var certificateMetadataSchema = map[string]*schema.Schema{
+	"subject": {
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Distinguished name of the subject of the Certificate, like 'CN=example.com,O=Example'",
+	},
+	"issuer": {
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Distinguished name of the issuer of the Certificate",
+	},
+	"subject_alternative_names": {
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "DNS names, IP addresses, email addresses and URIs of the Subject Alternative Name extension of the Certificate",
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	},
+	"serial_number": {
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Serial number of the Certificate, as colon-separated hexadecimal bytes",
+	},
+	"not_before": {
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The ISO-8601 timestamp representing when the Certificate starts being valid",
+	},
+	"not_after": {
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The ISO-8601 timestamp representing when the Certificate expires",
+	},
+	"sha1_fingerprint": {
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "SHA-1 fingerprint of the Certificate, as colon-separated hexadecimal bytes",
+	},
+	"sha256_fingerprint": {
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "SHA-256 fingerprint of the Certificate, as colon-separated hexadecimal bytes",
+	},
+}
+
+// certificateExpiryWarningDaysSchema defines how many days before the expiry of a Certificate a warning is shown
+var certificateExpiryWarningDaysSchema = &schema.Schema{
+	Type:         schema.TypeInt,
+	Optional:     true,
+	Default:      30,
+	Description:  "A warning is shown when the Certificate expires in less than this number of days. 0 disables the warning",
+	ValidateFunc: validation.IntAtLeast(0),
+}
+
+// parseCertificatePem returns the first certificate of the given PEM, which is the leaf certificate in a chain
+func parseCertificatePem(certificatePem string) (*x509.Certificate, error) {
+	rest := []byte(certificatePem)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("could not find any certificate in the PEM")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// formatCertificateBytes returns the given bytes as colon-separated uppercase hexadecimal bytes, like 'AB:01:FF'
+func formatCertificateBytes(b []byte) string {
+	parts := make([]string, len(b))
+	for i := range b {
+		parts[i] = strings.ToUpper(hex.EncodeToString(b[i : i+1]))
+	}
+	return strings.Join(parts, ":")
+}
+
+// normalizeCertificateFingerprint removes the separators of the given fingerprint and lowercases it, so fingerprints
+// in different formats can be compared
+func normalizeCertificateFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "", "-", "").Replace(fingerprint))
+}
+
+// getCertificateMetadata returns the values of the attributes of certificateMetadataSchema for the given certificate
+func getCertificateMetadata(certificate *x509.Certificate) map[string]interface{} {
+	sans := make([]string, 0, len(certificate.DNSNames)+len(certificate.IPAddresses)+len(certificate.EmailAddresses)+len(certificate.URIs))
+	sans = append(sans, certificate.DNSNames...)
+	for _, ip := range certificate.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, certificate.EmailAddresses...)
+	for _, uri := range certificate.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	sha1Sum := sha1.Sum(certificate.Raw) // #nosec G401 -- Only used to show the fingerprint
+	sha256Sum := sha256.Sum256(certificate.Raw)
+	return map[string]interface{}{
+		"subject":                   certificate.Subject.String(),
+		"issuer":                    certificate.Issuer.String(),
+		"subject_alternative_names": sans,
+		"serial_number":             formatCertificateBytes(certificate.SerialNumber.Bytes()),
+		"not_before":                certificate.NotBefore.UTC().Format(time.RFC3339),
+		"not_after":                 certificate.NotAfter.UTC().Format(time.RFC3339),
+		"sha1_fingerprint":          formatCertificateBytes(sha1Sum[:]),
+		"sha256_fingerprint":        formatCertificateBytes(sha256Sum[:]),
+	}
+}
+
+// setCertificateMetadata parses the given PEM and saves its metadata in the attributes of certificateMetadataSchema.
+// It returns a warning if the certificate expires in less than 'expiry_warning_days' days
+func setCertificateMetadata(d *schema.ResourceData, alias, certificatePem string) diag.Diagnostics {
+	certificate, err := parseCertificatePem(certificatePem)
+	if err != nil {
+		return diag.Errorf("error parsing Certificate '%s': %s", alias, err)
+	}
+	for key, value := range getCertificateMetadata(certificate) {
+		if err = d.Set(key, value); err != nil {
+			return diag.Errorf("error setting %s: %s", key, err)
+		}
+	}
+	return getCertificateExpiryWarning(alias, certificate, d.Get("expiry_warning_days").(int), time.Now())
+}
+
+// getCertificateExpiryWarning returns a warning if the given certificate expired or expires in less than the given
+// amount of days
+func getCertificateExpiryWarning(alias string, certificate *x509.Certificate, warningDays int, now time.Time) diag.Diagnostics {
+	if warningDays <= 0 {
+		return nil
+	}
+	remaining := certificate.NotAfter.Sub(now)
+	if remaining >= time.Duration(warningDays)*24*time.Hour {
+		return nil
+	}
+	notAfter := certificate.NotAfter.UTC().Format(time.RFC3339)
+	if remaining <= 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Certificate '%s' has expired", alias),
+			Detail:   fmt.Sprintf("Certificate '%s' (%s) expired on %s", alias, certificate.Subject.String(), notAfter),
+		}}
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("Certificate '%s' expires soon", alias),
+		Detail: fmt.Sprintf("Certificate '%s' (%s) expires on %s, in %d days", alias, certificate.Subject.String(), notAfter,
+			int(math.Ceil(remaining.Hours()/24))),
+	}}
+}
@@ -0,0 +1,335 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// certificateSource contains the arguments that define the content of a Certificate: either a PEM certificate with its
+// optional private key, or a PKCS#12 bundle. In both cases, additional certificates of the chain can be provided
+type certificateSource struct {
+	certificate          string
+	privateKey           string
+	privateKeyPassphrase string
+	pkcs12Base64         string
+	pkcs12Password       string
+	chain                []string
+}
+
+// getCertificateSource reads the arguments that define the content of a Certificate with the given getter, so it can be
+// used with the current and the previous values of a resource, and during plan
+func getCertificateSource(get func(key string) interface{}) certificateSource {
+	chain := get("chain").([]interface{})
+	source := certificateSource{
+		certificate:          get("certificate").(string),
+		privateKey:           get("private_key").(string),
+		privateKeyPassphrase: get("private_key_passphrase").(string),
+		pkcs12Base64:         get("pkcs12_base64").(string),
+		pkcs12Password:       get("pkcs12_password").(string),
+		chain:                make([]string, 0, len(chain)),
+	}
+	for _, c := range chain {
+		if c != nil {
+			source.chain = append(source.chain, c.(string))
+		}
+	}
+	return source
+}
+
+// isBundle returns whether the Certificate is built from a PKCS#12 bundle or an explicit chain, in which case the
+// content that is sent to VCFA is not the same as the 'certificate' argument
+func (source certificateSource) isBundle() bool {
+	return source.pkcs12Base64 != "" || len(source.chain) > 0
+}
+
+// build validates the source and returns the certificate, private key and passphrase in the format that VCFA expects:
+// PEM certificates ordered from the leaf to the root, and an unencrypted PKCS#8 PEM private key when it comes from a
+// PKCS#12 bundle
+func (source certificateSource) build() (string, string, string, error) {
+	if !source.isBundle() {
+		err := validateCertificatePrivateKey(source.certificate, source.privateKey, source.privateKeyPassphrase)
+		if err != nil {
+			return "", "", "", err
+		}
+		return source.certificate, source.privateKey, source.privateKeyPassphrase, nil
+	}
+
+	var leaf *x509.Certificate
+	var others []*x509.Certificate
+	privateKey, passphrase := source.privateKey, source.privateKeyPassphrase
+	if source.pkcs12Base64 != "" {
+		var err error
+		leaf, others, privateKey, err = parsePkcs12Bundle(source.pkcs12Base64, source.pkcs12Password)
+		if err != nil {
+			return "", "", "", fmt.Errorf("error reading the PKCS#12 bundle: %s", err)
+		}
+		passphrase = ""
+	} else {
+		certificates, err := parseCertificatesPem(source.certificate)
+		if err != nil {
+			return "", "", "", fmt.Errorf("error parsing the certificate: %s", err)
+		}
+		err = validateCertificatePrivateKey(source.certificate, privateKey, passphrase)
+		if err != nil {
+			return "", "", "", err
+		}
+		leaf, others = certificates[0], certificates[1:]
+	}
+	for i, c := range source.chain {
+		certificates, err := parseCertificatesPem(c)
+		if err != nil {
+			return "", "", "", fmt.Errorf("error parsing the certificate #%d of the chain: %s", i, err)
+		}
+		others = append(others, certificates...)
+	}
+
+	chain, err := orderCertificateChain(leaf, others)
+	if err != nil {
+		return "", "", "", err
+	}
+	var certificatePem bytes.Buffer
+	for _, c := range chain {
+		err = pem.Encode(&certificatePem, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+		if err != nil {
+			return "", "", "", err
+		}
+	}
+	// VCFA trims the trailing newlines of the certificate
+	return strings.TrimSpace(certificatePem.String()), privateKey, passphrase, nil
+}
+
+// validateCertificatePrivateKey checks that the given PEM private key is the one of the first certificate of the given
+// PEM. Encrypted private keys are decrypted with the given passphrase
+func validateCertificatePrivateKey(certificatePem, privateKeyPem, passphrase string) error {
+	if privateKeyPem == "" {
+		return nil
+	}
+	certificate, err := parseCertificatePem(certificatePem)
+	if err != nil {
+		return fmt.Errorf("error parsing the certificate: %s", err)
+	}
+	block, _ := pem.Decode([]byte(privateKeyPem))
+	if block == nil {
+		return fmt.Errorf("could not find any private key in the PEM")
+	}
+	privateKey, err := parsePrivateKeyBlock(block, passphrase)
+	if err != nil {
+		return err
+	}
+	if !certificateMatchesPrivateKey(certificate, privateKey) {
+		return fmt.Errorf("the private key does not match the certificate '%s'", certificate.Subject.String())
+	}
+	return nil
+}
+
+// certificateMatchesPrivateKey returns whether the public key of the given certificate is the one of the private key
+func certificateMatchesPrivateKey(certificate *x509.Certificate, privateKey crypto.Signer) bool {
+	publicKey, ok := certificate.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && publicKey.Equal(privateKey.Public())
+}
+
+// parsePrivateKeyBlock parses the given PEM private key block, decrypting it with the given passphrase when it is an
+// encrypted PKCS#8 key or a legacy encrypted PEM
+func parsePrivateKeyBlock(block *pem.Block, passphrase string) (crypto.Signer, error) {
+	encrypted := block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] != ""
+	if !encrypted {
+		privateKey, err := parsePrivateKeyDer(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the private key: %s", err)
+		}
+		return privateKey, nil
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("the private key is encrypted, but 'private_key_passphrase' is not set")
+	}
+
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt the private key with 'private_key_passphrase': %s", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	// Legacy encrypted PEM keys are insecure, but they are still generated by some tools, like 'openssl genrsa -aes256'
+	// from OpenSSL 1.x
+	der, err := x509.DecryptPEMBlock(block, []byte(passphrase)) //nolint:staticcheck // Needed to read legacy keys
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt the private key with 'private_key_passphrase': %s", err)
+	}
+	privateKey, err := parsePrivateKeyDer(der)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the private key: %s", err)
+	}
+	return privateKey, nil
+}
+
+// parsePrivateKeyDer parses an unencrypted private key in PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) DER format
+func parsePrivateKeyDer(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("the private key is not in PKCS#8, PKCS#1 or SEC 1 format")
+}
+
+// parseCertificatesPem returns all the certificates of the given PEM, in the same order
+func parseCertificatesPem(certificatePem string) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	rest := []byte(certificatePem)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("could not find any certificate in the PEM")
+	}
+	return certificates, nil
+}
+
+// parsePkcs12Bundle decodes the given base64 PKCS#12 bundle, and returns the certificate that matches its private key,
+// the rest of certificates of the bundle, and the private key in PKCS#8 PEM format
+func parsePkcs12Bundle(pkcs12Base64, password string) (*x509.Certificate, []*x509.Certificate, string, error) {
+	// Line breaks are accepted, as base64 tools usually wrap their output
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(pkcs12Base64), ""))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("the bundle is not valid base64: %s", err)
+	}
+	key, leaf, caCertificates, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	privateKey, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, "", fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	// The first certificate of the bundle is not always the one of the private key
+	certificates := append([]*x509.Certificate{leaf}, caCertificates...)
+	for i, certificate := range certificates {
+		if !certificateMatchesPrivateKey(certificate, privateKey) {
+			continue
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		others := append(certificates[:i:i], certificates[i+1:]...)
+		return certificate, others, strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))), nil
+	}
+	return nil, nil, "", fmt.Errorf("the bundle does not contain any certificate that matches its private key")
+}
+
+// orderCertificateChain returns the given leaf certificate followed by its issuers, from the one that signed the leaf
+// to the root. Duplicated certificates are removed, and it fails if any of the given certificates does not belong to
+// the chain of the leaf
+func orderCertificateChain(leaf *x509.Certificate, others []*x509.Certificate) ([]*x509.Certificate, error) {
+	var remaining []*x509.Certificate
+	for _, c := range others {
+		if c.Equal(leaf) {
+			continue
+		}
+		duplicated := false
+		for _, r := range remaining {
+			duplicated = duplicated || c.Equal(r)
+		}
+		if !duplicated {
+			remaining = append(remaining, c)
+		}
+	}
+
+	chain := []*x509.Certificate{leaf}
+	for current := leaf; len(remaining) > 0; {
+		issuer := -1
+		for i, c := range remaining {
+			if bytes.Equal(current.RawIssuer, c.RawSubject) && current.CheckSignatureFrom(c) == nil {
+				issuer = i
+				break
+			}
+		}
+		if issuer < 0 {
+			break
+		}
+		current = remaining[issuer]
+		chain = append(chain, current)
+		remaining = append(remaining[:issuer], remaining[issuer+1:]...)
+	}
+	if len(remaining) > 0 {
+		return nil, fmt.Errorf("the certificate '%s' is not part of the chain of '%s'", remaining[0].Subject.String(), leaf.Subject.String())
+	}
+	return chain, nil
+}
+
+// getLeafCertificatePem returns the first certificate of the given PEM chain, in PEM format
+func getLeafCertificatePem(certificatePem string) (string, error) {
+	leaf, err := parseCertificatePem(certificatePem)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}))), nil
+}
+
+// suppressEquivalentCertificatePem suppresses the differences of PEM certificates that only differ in their encoding,
+// like line breaks or comments. When the Certificate is built from a bundle, only the leaf certificate is saved in
+// state, so only the leaf certificates are compared
+func suppressEquivalentCertificatePem(_, oldValue, newValue string, d *schema.ResourceData) bool {
+	if oldValue == newValue {
+		return true
+	}
+	oldCertificates, err := parseCertificatesPem(oldValue)
+	if err != nil {
+		return false
+	}
+	newCertificates, err := parseCertificatesPem(newValue)
+	if err != nil {
+		return false
+	}
+	if d != nil && getCertificateSource(d.Get).isBundle() {
+		return oldCertificates[0].Equal(newCertificates[0])
+	}
+	if len(oldCertificates) != len(newCertificates) {
+		return false
+	}
+	for i := range oldCertificates {
+		if !oldCertificates[i].Equal(newCertificates[i]) {
+			return false
+		}
+	}
+	return true
+}
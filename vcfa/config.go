@@ -49,6 +49,8 @@ type VCDClient struct {
 	SysOrg       string
 	Org          string // name of default Org
 	InsecureFlag bool
+
+	authConfig *Config // credentials used to authenticate, kept to obtain new session tokens when needed
 }
 
 // StringMap type is used to simplify reading resource definitions
@@ -191,7 +193,8 @@ func (c *Config) Client() (*VCDClient, error) {
 		),
 		SysOrg:       c.SysOrg,
 		Org:          c.Org,
-		InsecureFlag: c.InsecureFlag}
+		InsecureFlag: c.InsecureFlag,
+		authConfig:   c}
 
 	err = ProviderAuthenticate(tmClient.VCDClient, c.User, c.Password, c.Token, c.SysOrg, c.ApiToken, c.ApiTokenFile, c.ServiceAccountTokenFile)
 	if err != nil {
@@ -205,6 +208,34 @@ func (c *Config) Client() (*VCDClient, error) {
 	return tmClient, nil
 }
 
+// newSessionToken obtains a new session token using the same credentials that were used to create the client.
+// The token is obtained with a separate, short-lived client, so the session of this client, that is shared by all
+// the resources, is not modified while other requests are in flight. It fails when the client was created with a
+// static token, as it cannot be renewed
+func (cli *VCDClient) newSessionToken() (string, error) {
+	c := cli.authConfig
+	if c == nil {
+		return "", fmt.Errorf("the credentials used to create the client are not available")
+	}
+	if c.Token != "" {
+		return "", fmt.Errorf("a session token provided with 'auth_type' == 'token' cannot be renewed")
+	}
+	authUrl, err := url.ParseRequestURI(c.Href)
+	if err != nil {
+		return "", fmt.Errorf("something went wrong while retrieving URL: %s", err)
+	}
+	client := govcd.NewVCDClient(*authUrl, c.InsecureFlag,
+		govcd.WithHttpUserAgent(buildUserAgent(BuildVersion, c.SysOrg)),
+		govcd.WithAPIVersion(minVcfaApiVersion),
+	)
+	err = ProviderAuthenticate(client, c.User, c.Password, c.Token, c.SysOrg, c.ApiToken, c.ApiTokenFile, c.ServiceAccountTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("something went wrong during re-authentication: %s", err)
+	}
+	// The session is not logged out, as its token is the one that is handed over
+	return client.Client.VCDToken, nil
+}
+
 // callFuncName returns the name of the function that called the current function. It is used for
 // tracing
 func callFuncName() string {
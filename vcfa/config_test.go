@@ -106,12 +106,19 @@ type TestConfig struct {
 		Vpc            string `json:"vpc"`
 		StoragePolicy  string `json:"storagePolicy"`
 		SupervisorZone string `json:"supervisorZone"`
+		VmImage        string `json:"vmImage,omitempty"`
+		VmClass        string `json:"vmClass,omitempty"`
+
+		ClusterClass      string `json:"clusterClass,omitempty"`
+		KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+		ContentLibrary    string `json:"contentLibrary,omitempty"`
 	} `json:"cci"`
 	Tm struct {
 		Org             string   `json:"org"`
 		CreateRegion    bool     `json:"createRegion"`
 		Region          string   `json:"region"`
 		StorageClass    string   `json:"storageClass"`
+		StorageClass2   string   `json:"storageClass2,omitempty"`
 		RegionVmClasses []string `json:"regionVmClasses"`
 		ContentLibrary  string   `json:"contentLibrary"`
 
@@ -0,0 +1,177 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"github.com/vmware/go-vcloud-director/v3/types/v56"
+)
+
+// contentLibraryItemDownloadResumes is the amount of times that a failed file download is resumed from the last
+// received byte before giving up
+const contentLibraryItemDownloadResumes = 3
+
+// contentLibraryItemPartialSuffix is appended to the name of the files while they are being downloaded, so an
+// interrupted download never leaves an incomplete file with the final name
+const contentLibraryItemPartialSuffix = ".partial"
+
+// downloadContentLibraryItemFiles downloads all the files of the given Content Library Item to the given directory,
+// which is created if it does not exist. Existing files are only replaced if 'overwrite' is true, or if they are in
+// 'ownedPaths', which are the files of a previous download. It returns the paths of the downloaded files, in the same
+// order as VCFA lists them
+func downloadContentLibraryItemFiles(ctx context.Context, tmClient *VCDClient, cli *govcd.ContentLibraryItem, directory string, overwrite bool, ownedPaths []string) ([]string, error) {
+	if cli.ContentLibraryItem.Status != contentLibraryItemStatusReady {
+		return nil, fmt.Errorf("the %s is in status '%s', but it must be %s to be downloaded", labelVcfaContentLibraryItem,
+			cli.ContentLibraryItem.Status, contentLibraryItemStatusReady)
+	}
+	files, err := getContentLibraryItemFiles(tmClient, cli.ContentLibraryItem.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the %s does not have any file to download", labelVcfaContentLibraryItem)
+	}
+
+	filePaths := make([]string, len(files))
+	for i, file := range files {
+		// The file names come from the server, so they must not be able to escape the target directory
+		if file.Name == "" || file.Name != filepath.Base(file.Name) || file.Name == "." || file.Name == ".." {
+			return nil, fmt.Errorf("the %s has a file with an invalid name '%s'", labelVcfaContentLibraryItem, file.Name)
+		}
+		filePaths[i] = filepath.Join(directory, file.Name)
+		if _, err := os.Stat(filePaths[i]); err == nil && !overwrite && !slices.Contains(ownedPaths, filePaths[i]) {
+			return nil, fmt.Errorf("the file '%s' already exists, and overwriting is not allowed", filePaths[i])
+		}
+	}
+
+	err = os.MkdirAll(directory, 0750)
+	if err != nil {
+		return nil, fmt.Errorf("error creating directory '%s': %s", directory, err)
+	}
+
+	keepAliveCtx, stopKeepAlive := context.WithCancel(ctx)
+	defer stopKeepAlive()
+	go func() {
+		ticker := time.NewTicker(contentLibraryItemKeepAliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				keepSessionAlive(tmClient)
+			case <-keepAliveCtx.Done():
+				return
+			}
+		}
+	}()
+
+	for i, file := range files {
+		tflog.Info(ctx, fmt.Sprintf("Downloading '%s' of %s '%s' (%s)", file.Name, labelVcfaContentLibraryItem,
+			cli.ContentLibraryItem.Name, formatByteSize(float64(file.ExpectedSizeBytes))), map[string]interface{}{
+			"content_library_item": cli.ContentLibraryItem.Name,
+			"file":                 file.Name,
+			"total_bytes":          file.ExpectedSizeBytes,
+		})
+		err = downloadContentLibraryItemFile(ctx, tmClient, file, filePaths[i])
+		if err != nil {
+			// The files that were already downloaded are removed, so a retry does not find them
+			removeContentLibraryItemDownloadedFiles(filePaths[:i])
+			return nil, fmt.Errorf("error downloading '%s': %s", file.Name, err)
+		}
+	}
+	return filePaths, nil
+}
+
+// removeContentLibraryItemDownloadedFiles removes the given downloaded files, when they can't be used. Errors are only
+// logged, as they should not hide the reason of the removal
+func removeContentLibraryItemDownloadedFiles(filePaths []string) {
+	for _, filePath := range filePaths {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("[ERROR] could not remove the downloaded file '%s': %s", filePath, err)
+		}
+	}
+}
+
+// downloadContentLibraryItemFile downloads the given Content Library Item file to the given path, resuming the
+// download from the last received byte when it is interrupted
+func downloadContentLibraryItemFile(ctx context.Context, tmClient *VCDClient, file *types.ContentLibraryItemFile, filePath string) error {
+	if file.TransferUrl == "" {
+		return fmt.Errorf("the file does not have a transfer URL")
+	}
+	transferUrl, err := url.ParseRequestURI(file.TransferUrl)
+	if err != nil {
+		return fmt.Errorf("error parsing transfer URL '%s': %s", file.TransferUrl, err)
+	}
+
+	partialPath := filePath + contentLibraryItemPartialSuffix
+	target, err := os.OpenFile(filepath.Clean(partialPath), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	var received int64
+	for attempt := 0; attempt <= contentLibraryItemDownloadResumes; attempt++ {
+		if attempt > 0 {
+			tflog.Warn(ctx, fmt.Sprintf("Resuming the download of '%s' from byte %d: %s", file.Name, received, err))
+		}
+		var n int64
+		n, err = downloadContentLibraryItemFileFrom(ctx, tmClient, transferUrl, received, target)
+		received += n
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	closeErr := target.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && file.ExpectedSizeBytes > 0 && received != file.ExpectedSizeBytes {
+		err = fmt.Errorf("received %d bytes, but %d bytes were expected", received, file.ExpectedSizeBytes)
+	}
+	if err != nil {
+		_ = os.Remove(partialPath)
+		return err
+	}
+	return os.Rename(partialPath, filePath)
+}
+
+// downloadContentLibraryItemFileFrom downloads the contents of the given transfer URL, starting at the given offset,
+// and appends them to the given writer. It returns the amount of bytes that were written
+func downloadContentLibraryItemFileFrom(ctx context.Context, tmClient *VCDClient, transferUrl *url.URL, offset int64, w io.Writer) (int64, error) {
+	request := tmClient.Client.NewRequestWitNotEncodedParams(nil, nil, http.MethodGet, *transferUrl, nil)
+	request = request.WithContext(ctx)
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := tmClient.Client.Http.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(response.Body)
+		return 0, fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	if offset > 0 && response.StatusCode != http.StatusPartialContent {
+		// The server ignored the range, so the bytes that were already received must be skipped
+		_, err = io.CopyN(io.Discard, response.Body, offset)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return io.Copy(w, response.Body)
+}
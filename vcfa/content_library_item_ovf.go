@@ -0,0 +1,486 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+)
+
+// ovfDescriptorMaxSize is the maximum size of an OVF descriptor that is retrieved to be inspected
+const ovfDescriptorMaxSize = 16 * 1024 * 1024
+
+// CIM resource types of the virtual hardware items of an OVF descriptor
+const (
+	ovfResourceTypeProcessor       = "3"
+	ovfResourceTypeMemory          = "4"
+	ovfResourceTypeIdeController   = "5"
+	ovfResourceTypeScsiController  = "6"
+	ovfResourceTypeEthernetAdapter = "10"
+	ovfResourceTypeDiskDrive       = "17"
+	ovfResourceTypeSataController  = "20"
+)
+
+// ovfAllocationUnitsRegex matches programmatic units of an OVF descriptor, like 'byte * 2^20' or 'byte * 1024'
+var ovfAllocationUnitsRegex = regexp.MustCompile(`^byte(?:\s*\*\s*(\d+)(?:\s*\^\s*(\d+))?)?$`)
+
+// ovfDescriptor contains the parts of an OVF descriptor that describe its virtual systems. Elements and attributes are
+// matched by local name, so the namespace prefixes used by the descriptor do not matter
+type ovfDescriptor struct {
+	XMLName                 xml.Name                    `xml:"Envelope"`
+	References              []ovfFileReference          `xml:"References>File"`
+	Disks                   []ovfDisk                   `xml:"DiskSection>Disk"`
+	Networks                []ovfNetwork                `xml:"NetworkSection>Network"`
+	VirtualSystem           *ovfVirtualSystem           `xml:"VirtualSystem"`
+	VirtualSystemCollection *ovfVirtualSystemCollection `xml:"VirtualSystemCollection"`
+}
+
+type ovfDisk struct {
+	DiskId                  string `xml:"diskId,attr"`
+	FileRef                 string `xml:"fileRef,attr"`
+	Capacity                string `xml:"capacity,attr"`
+	CapacityAllocationUnits string `xml:"capacityAllocationUnits,attr"`
+}
+
+type ovfNetwork struct {
+	Name        string `xml:"name,attr"`
+	Description string `xml:"Description"`
+}
+
+type ovfVirtualSystemCollection struct {
+	Id                       string                       `xml:"id,attr"`
+	ProductSections          []ovfProductSection          `xml:"ProductSection"`
+	VirtualSystems           []ovfVirtualSystem           `xml:"VirtualSystem"`
+	VirtualSystemCollections []ovfVirtualSystemCollection `xml:"VirtualSystemCollection"`
+}
+
+type ovfVirtualSystem struct {
+	Id              string              `xml:"id,attr"`
+	Name            string              `xml:"Name"`
+	OperatingSystem *ovfOperatingSystem `xml:"OperatingSystemSection"`
+	VirtualHardware *ovfVirtualHardware `xml:"VirtualHardwareSection"`
+	ProductSections []ovfProductSection `xml:"ProductSection"`
+}
+
+type ovfOperatingSystem struct {
+	Id          string `xml:"id,attr"`
+	OsType      string `xml:"osType,attr"`
+	Description string `xml:"Description"`
+}
+
+type ovfVirtualHardware struct {
+	VirtualSystemType string               `xml:"System>VirtualSystemType"`
+	Items             []ovfVirtualHardItem `xml:"Item"`
+	StorageItems      []ovfVirtualHardItem `xml:"StorageItem"`
+	EthernetPortItems []ovfVirtualHardItem `xml:"EthernetPortItem"`
+}
+
+// ovfVirtualHardItem is a virtual hardware item, with the fields of CIM_ResourceAllocationSettingData
+type ovfVirtualHardItem struct {
+	InstanceID           string `xml:"InstanceID"`
+	ResourceType         string `xml:"ResourceType"`
+	ResourceSubType      string `xml:"ResourceSubType"`
+	ElementName          string `xml:"ElementName"`
+	AllocationUnits      string `xml:"AllocationUnits"`
+	VirtualQuantity      string `xml:"VirtualQuantity"`
+	VirtualQuantityUnits string `xml:"VirtualQuantityUnits"`
+	HostResource         string `xml:"HostResource"`
+	Connection           string `xml:"Connection"`
+	Parent               string `xml:"Parent"`
+	AddressOnParent      string `xml:"AddressOnParent"`
+	CoresPerSocket       string `xml:"CoresPerSocket"`
+}
+
+// ovfProductSection is a ProductSection of an OVF descriptor. Its properties are grouped by the Category element that
+// precedes them, so it is decoded manually
+type ovfProductSection struct {
+	Class      string
+	Instance   string
+	Product    string
+	Vendor     string
+	Version    string
+	Properties []ovfProperty
+}
+
+type ovfProperty struct {
+	Key              string `xml:"key,attr"`
+	Type             string `xml:"type,attr"`
+	Qualifiers       string `xml:"qualifiers,attr"`
+	UserConfigurable string `xml:"userConfigurable,attr"`
+	Value            string `xml:"value,attr"`
+	Password         string `xml:"password,attr"`
+	Label            string `xml:"Label"`
+	Description      string `xml:"Description"`
+	Category         string `xml:"-"`
+}
+
+// UnmarshalXML decodes a ProductSection, assigning to every property the last Category that was found before it
+func (p *ovfProductSection) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "class":
+			p.Class = attr.Value
+		case "instance":
+			p.Instance = attr.Value
+		}
+	}
+	category := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			var err error
+			switch element.Name.Local {
+			case "Product":
+				err = decoder.DecodeElement(&p.Product, &element)
+			case "Vendor":
+				err = decoder.DecodeElement(&p.Vendor, &element)
+			case "Version":
+				err = decoder.DecodeElement(&p.Version, &element)
+			case "Category":
+				err = decoder.DecodeElement(&category, &element)
+				category = strings.TrimSpace(category)
+			case "Property":
+				property := ovfProperty{}
+				err = decoder.DecodeElement(&property, &element)
+				property.Category = category
+				p.Properties = append(p.Properties, property)
+			default:
+				err = decoder.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// fullKey returns the key of the given property as it appears in the OVF environment, which is
+// '<class>.<key>.<instance>', omitting the class and instance when they are empty
+func (p *ovfProductSection) fullKey(property ovfProperty) string {
+	parts := []string{}
+	if p.Class != "" {
+		parts = append(parts, p.Class)
+	}
+	parts = append(parts, property.Key)
+	if p.Instance != "" {
+		parts = append(parts, p.Instance)
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseOvfDescriptor decodes the given OVF descriptor
+func parseOvfDescriptor(descriptor []byte) (*ovfDescriptor, error) {
+	envelope := &ovfDescriptor{}
+	decoder := xml.NewDecoder(bytes.NewReader(descriptor))
+	decoder.CharsetReader = ovfCharsetReader
+	err := decoder.Decode(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OVF descriptor: %s", err)
+	}
+	return envelope, nil
+}
+
+// ovfCharsetReader converts OVF descriptors that are not encoded in UTF-8 to UTF-8. Only US-ASCII, which is a subset of
+// UTF-8, and ISO-8859-1 are supported, as any other encoding would be decoded with wrong characters
+func ovfCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1":
+		latin1, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		// Every ISO-8859-1 byte is the Unicode code point with the same value
+		runes := make([]rune, len(latin1))
+		for i, b := range latin1 {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	default:
+		return nil, fmt.Errorf("the encoding '%s' is not supported, only UTF-8, US-ASCII and ISO-8859-1 are", charset)
+	}
+}
+
+// parseOvfAllocationUnits returns the amount of bytes of the given OVF programmatic unit, like 'byte * 2^20'. Empty
+// units are considered bytes
+func parseOvfAllocationUnits(units string) (*big.Int, error) {
+	units = strings.TrimSpace(units)
+	if units == "" {
+		return big.NewInt(1), nil
+	}
+	switch strings.ToLower(units) {
+	case "kilobytes":
+		return big.NewInt(1 << 10), nil
+	case "megabytes":
+		return big.NewInt(1 << 20), nil
+	case "gigabytes":
+		return big.NewInt(1 << 30), nil
+	}
+	matches := ovfAllocationUnitsRegex.FindStringSubmatch(units)
+	if matches == nil {
+		return nil, fmt.Errorf("unsupported allocation units '%s'", units)
+	}
+	multiplier := big.NewInt(1)
+	if matches[1] != "" {
+		multiplier.SetString(matches[1], 10)
+		if matches[2] != "" {
+			exponent, _ := new(big.Int).SetString(matches[2], 10)
+			multiplier.Exp(multiplier, exponent, nil)
+		}
+	}
+	return multiplier, nil
+}
+
+// ovfQuantityToMb converts the given quantity, in the given OVF programmatic units, to MB
+func ovfQuantityToMb(quantity, units string) (int, error) {
+	quantity = strings.TrimSpace(quantity)
+	if quantity == "" {
+		return 0, nil
+	}
+	value, ok := new(big.Int).SetString(quantity, 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid quantity '%s'", quantity)
+	}
+	multiplier, err := parseOvfAllocationUnits(units)
+	if err != nil {
+		return 0, err
+	}
+	value.Mul(value, multiplier)
+	value.Div(value, big.NewInt(1<<20))
+	return int(value.Int64()), nil
+}
+
+// getOvfVirtualSystems returns all the virtual systems of the given OVF descriptor, including the ones in nested
+// collections, and all the product sections, including the ones of the collections
+func getOvfVirtualSystems(envelope *ovfDescriptor) ([]ovfVirtualSystem, []ovfProductSectionOf) {
+	var systems []ovfVirtualSystem
+	var sections []ovfProductSectionOf
+	if envelope.VirtualSystem != nil {
+		systems = append(systems, *envelope.VirtualSystem)
+	}
+	var walk func(collection ovfVirtualSystemCollection)
+	walk = func(collection ovfVirtualSystemCollection) {
+		for _, section := range collection.ProductSections {
+			sections = append(sections, ovfProductSectionOf{virtualSystemId: collection.Id, section: section})
+		}
+		systems = append(systems, collection.VirtualSystems...)
+		for _, nested := range collection.VirtualSystemCollections {
+			walk(nested)
+		}
+	}
+	if envelope.VirtualSystemCollection != nil {
+		walk(*envelope.VirtualSystemCollection)
+	}
+	for _, system := range systems {
+		for _, section := range system.ProductSections {
+			sections = append(sections, ovfProductSectionOf{virtualSystemId: system.Id, section: section})
+		}
+	}
+	return systems, sections
+}
+
+// ovfProductSectionOf is a product section together with the ID of the virtual system or collection that contains it
+type ovfProductSectionOf struct {
+	virtualSystemId string
+	section         ovfProductSection
+}
+
+// flattenOvfVirtualSystem converts the given virtual system to the schema of the data source
+func flattenOvfVirtualSystem(envelope *ovfDescriptor, system ovfVirtualSystem) (map[string]interface{}, error) {
+	result := map[string]interface{}{
+		"id":                   system.Id,
+		"name":                 strings.TrimSpace(system.Name),
+		"hardware_version":     "",
+		"guest_os_id":          "",
+		"guest_os_type":        "",
+		"guest_os_description": "",
+		"cpu_count":            0,
+		"cores_per_socket":     0,
+		"memory_mb":            0,
+	}
+	if system.OperatingSystem != nil {
+		result["guest_os_id"] = system.OperatingSystem.Id
+		result["guest_os_type"] = system.OperatingSystem.OsType
+		result["guest_os_description"] = strings.TrimSpace(system.OperatingSystem.Description)
+	}
+	disks := make([]interface{}, 0)
+	adapters := make([]interface{}, 0)
+	if system.VirtualHardware == nil {
+		result["disks"] = disks
+		result["network_adapters"] = adapters
+		return result, nil
+	}
+	result["hardware_version"] = strings.TrimSpace(system.VirtualHardware.VirtualSystemType)
+
+	items := append(append(append([]ovfVirtualHardItem{}, system.VirtualHardware.Items...),
+		system.VirtualHardware.StorageItems...), system.VirtualHardware.EthernetPortItems...)
+	controllers := map[string]ovfVirtualHardItem{}
+	for _, item := range items {
+		switch strings.TrimSpace(item.ResourceType) {
+		case ovfResourceTypeIdeController, ovfResourceTypeScsiController, ovfResourceTypeSataController:
+			controllers[strings.TrimSpace(item.InstanceID)] = item
+		}
+	}
+	diskSizes := map[string]string{}
+	diskFiles := map[string]string{}
+	for _, disk := range envelope.Disks {
+		diskSizes[disk.DiskId] = disk.Capacity
+		diskFiles[disk.DiskId] = disk.FileRef
+		for _, reference := range envelope.References {
+			if reference.Id == disk.FileRef {
+				diskFiles[disk.DiskId] = path.Base(reference.Href)
+			}
+		}
+	}
+
+	for _, item := range items {
+		var err error
+		switch strings.TrimSpace(item.ResourceType) {
+		case ovfResourceTypeProcessor:
+			result["cpu_count"], err = strconv.Atoi(strings.TrimSpace(item.VirtualQuantity))
+			if err == nil && strings.TrimSpace(item.CoresPerSocket) != "" {
+				result["cores_per_socket"], err = strconv.Atoi(strings.TrimSpace(item.CoresPerSocket))
+			}
+		case ovfResourceTypeMemory:
+			result["memory_mb"], err = ovfQuantityToMb(item.VirtualQuantity, item.AllocationUnits)
+		case ovfResourceTypeDiskDrive:
+			var disk map[string]interface{}
+			disk, err = flattenOvfDiskItem(envelope, item, controllers, diskFiles)
+			disks = append(disks, disk)
+		case ovfResourceTypeEthernetAdapter:
+			adapters = append(adapters, map[string]interface{}{
+				"name":         strings.TrimSpace(item.ElementName),
+				"network":      strings.TrimSpace(item.Connection),
+				"adapter_type": strings.TrimSpace(item.ResourceSubType),
+				"unit_number":  strings.TrimSpace(item.AddressOnParent),
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading item '%s' of virtual system '%s': %s", strings.TrimSpace(item.ElementName), system.Id, err)
+		}
+	}
+	result["disks"] = disks
+	result["network_adapters"] = adapters
+	return result, nil
+}
+
+// flattenOvfDiskItem converts the given disk drive item to the schema of the data source. The size of the disk is
+// taken from the DiskSection of the descriptor, or from the item itself when it is not found there
+func flattenOvfDiskItem(envelope *ovfDescriptor, item ovfVirtualHardItem, controllers map[string]ovfVirtualHardItem, diskFiles map[string]string) (map[string]interface{}, error) {
+	disk := map[string]interface{}{
+		"disk_id":         "",
+		"name":            strings.TrimSpace(item.ElementName),
+		"size_mb":         0,
+		"file_name":       "",
+		"controller_type": "",
+		"unit_number":     strings.TrimSpace(item.AddressOnParent),
+	}
+	if controller, ok := controllers[strings.TrimSpace(item.Parent)]; ok {
+		disk["controller_type"] = strings.TrimSpace(controller.ResourceSubType)
+		if disk["controller_type"] == "" {
+			switch strings.TrimSpace(controller.ResourceType) {
+			case ovfResourceTypeIdeController:
+				disk["controller_type"] = "ide"
+			case ovfResourceTypeSataController:
+				disk["controller_type"] = "sata"
+			}
+		}
+	}
+
+	hostResource := strings.TrimSpace(item.HostResource)
+	var diskId string
+	if hostResourceUrl, err := url.Parse(hostResource); err == nil {
+		diskId = path.Base(hostResourceUrl.Opaque + hostResourceUrl.Path)
+	}
+	for _, d := range envelope.Disks {
+		if d.DiskId != diskId || diskId == "" {
+			continue
+		}
+		size, err := ovfQuantityToMb(d.Capacity, d.CapacityAllocationUnits)
+		if err != nil {
+			return nil, err
+		}
+		disk["disk_id"] = diskId
+		disk["size_mb"] = size
+		disk["file_name"] = diskFiles[diskId]
+		return disk, nil
+	}
+	if item.VirtualQuantity != "" {
+		units := item.VirtualQuantityUnits
+		if units == "" {
+			units = item.AllocationUnits
+		}
+		size, err := ovfQuantityToMb(item.VirtualQuantity, units)
+		if err != nil {
+			return nil, err
+		}
+		disk["size_mb"] = size
+	}
+	return disk, nil
+}
+
+// getContentLibraryItemOvfDescriptor retrieves the contents of the OVF descriptor of the given Content Library Item,
+// which must be a template. It returns the name of the descriptor file and its contents
+func getContentLibraryItemOvfDescriptor(ctx context.Context, tmClient *VCDClient, cli *govcd.ContentLibraryItem) (string, []byte, error) {
+	if cli.ContentLibraryItem.ItemType != contentLibraryItemTypeTemplate {
+		return "", nil, fmt.Errorf("the %s is of type '%s', but only %s items have an OVF descriptor", labelVcfaContentLibraryItem,
+			cli.ContentLibraryItem.ItemType, contentLibraryItemTypeTemplate)
+	}
+	files, err := getContentLibraryItemFiles(tmClient, cli.ContentLibraryItem.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, file := range files {
+		if !strings.EqualFold(path.Ext(file.Name), ".ovf") {
+			continue
+		}
+		if file.ExpectedSizeBytes > ovfDescriptorMaxSize {
+			return "", nil, fmt.Errorf("the OVF descriptor '%s' has %d bytes, which is more than the maximum of %d", file.Name, file.ExpectedSizeBytes, ovfDescriptorMaxSize)
+		}
+		transferUrl, err := url.ParseRequestURI(file.TransferUrl)
+		if err != nil {
+			return "", nil, fmt.Errorf("error parsing transfer URL '%s': %s", file.TransferUrl, err)
+		}
+		descriptor := &bytes.Buffer{}
+		_, err = downloadContentLibraryItemFileFrom(ctx, tmClient, transferUrl, 0, &limitedWriter{w: descriptor, remaining: ovfDescriptorMaxSize})
+		if err != nil {
+			return "", nil, fmt.Errorf("error retrieving the OVF descriptor '%s': %s", file.Name, err)
+		}
+		return file.Name, descriptor.Bytes(), nil
+	}
+	return "", nil, fmt.Errorf("the %s does not have an OVF descriptor", labelVcfaContentLibraryItem)
+}
+
+// limitedWriter writes to the given writer until the remaining bytes are exhausted, and fails afterward
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		return 0, fmt.Errorf("the file is bigger than the maximum of %d bytes", ovfDescriptorMaxSize)
+	}
+	l.remaining -= int64(len(p))
+	return l.w.Write(p)
+}
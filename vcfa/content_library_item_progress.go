@@ -0,0 +1,211 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"github.com/vmware/go-vcloud-director/v3/types/v56"
+)
+
+// contentLibraryItemProcessingTimeout is the maximum time that VCFA can take to process the files of a Content
+// Library Item once they are uploaded
+const contentLibraryItemProcessingTimeout = time.Hour
+
+// Statuses of a Content Library Item
+const (
+	contentLibraryItemStatusReady             = "READY"
+	contentLibraryItemStatusFailed            = "FAILED"
+	contentLibraryItemStatusError             = "ERROR"
+	contentLibraryItemStatusQuarantined       = "QUARANTINED"
+	contentLibraryItemStatusQuarantineExpired = "QUARANTINE_EXPIRED"
+	contentLibraryItemStatusRejected          = "REJECTED"
+)
+
+// contentLibraryItemUploadProgress periodically reports the progress of a file upload through tflog
+type contentLibraryItemUploadProgress struct {
+	itemName    string
+	fileName    string
+	total       int64
+	startOffset int64
+	start       time.Time
+	sent        atomic.Int64
+	done        chan struct{}
+	interval    time.Duration
+}
+
+// startContentLibraryItemUploadProgress starts reporting the progress of the upload of the given file, that starts at the
+// given offset, every 'interval'. It does not report anything if the interval is not positive
+func startContentLibraryItemUploadProgress(ctx context.Context, itemName, fileName string, total, offset int64, interval time.Duration) *contentLibraryItemUploadProgress {
+	p := &contentLibraryItemUploadProgress{
+		itemName:    itemName,
+		fileName:    fileName,
+		total:       total,
+		startOffset: offset,
+		start:       time.Now(),
+		done:        make(chan struct{}),
+		interval:    interval,
+	}
+	p.sent.Store(offset)
+	if interval <= 0 {
+		return p
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.report(ctx, "Uploading")
+			case <-p.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// add records that the given amount of bytes were sent
+func (p *contentLibraryItemUploadProgress) add(sent int64) {
+	p.sent.Add(sent)
+}
+
+// resume records that the upload is resumed from the given offset
+func (p *contentLibraryItemUploadProgress) resume(offset int64) {
+	p.sent.Store(offset)
+}
+
+// stop finishes the progress reports, with a last report if the upload finished successfully
+func (p *contentLibraryItemUploadProgress) stop(ctx context.Context, err error) {
+	close(p.done)
+	if p.interval > 0 && err == nil {
+		p.report(ctx, "Finished uploading")
+	}
+}
+
+// report logs the bytes that were sent, the upload rate and the estimated time until the upload finishes
+func (p *contentLibraryItemUploadProgress) report(ctx context.Context, message string) {
+	sent := p.sent.Load()
+	elapsed := time.Since(p.start)
+	rate := float64(sent-p.startOffset) / max(elapsed.Seconds(), 1)
+	percent := 100.0
+	if p.total > 0 {
+		percent = 100 * float64(sent) / float64(p.total)
+	}
+	eta := "unknown"
+	if rate > 0 {
+		eta = time.Duration(float64(p.total-sent) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("%s '%s' of %s '%s': %s of %s (%.1f%%), %s/s, ETA %s", message, p.fileName, labelVcfaContentLibraryItem, p.itemName,
+		formatByteSize(float64(sent)), formatByteSize(float64(p.total)), percent, formatByteSize(rate), eta), map[string]interface{}{
+		"content_library_item": p.itemName,
+		"file":                 p.fileName,
+		"bytes_sent":           sent,
+		"total_bytes":          p.total,
+		"bytes_per_second":     int64(rate),
+		"elapsed":              elapsed.Round(time.Second).String(),
+		"eta":                  eta,
+	})
+}
+
+// formatByteSize returns the given amount of bytes in a human-readable format, like '1.5 GiB'
+func formatByteSize(size float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", size, units[unit])
+}
+
+// waitForContentLibraryItemProcessing waits until VCFA finishes importing and processing the uploaded files of the given
+// Content Library Item, reporting its status through tflog every 'interval'. If the item is put in quarantine, it is
+// approved or rejected according to the given approval, or it waits for someone else to do it when the approval is nil.
+// It returns an error if the upload task fails or the item does not end in READY status
+func waitForContentLibraryItemProcessing(ctx context.Context, tmClient *VCDClient, cl *govcd.ContentLibrary, cli *govcd.ContentLibraryItem, interval time.Duration, approval *contentLibraryItemQuarantineApproval) (*govcd.ContentLibraryItem, error) {
+	id, name := cli.ContentLibraryItem.ID, cli.ContentLibraryItem.Name
+	lastReport := time.Now()
+	shouldReport := func() bool {
+		if interval <= 0 || time.Since(lastReport) < interval {
+			return false
+		}
+		lastReport = time.Now()
+		return true
+	}
+
+	task, err := getContentLibraryItemUploadTask(tmClient, cli)
+	if err != nil {
+		return nil, err
+	}
+	if task != nil && approval != nil {
+		err = resolveContentLibraryItemQuarantine(ctx, tmClient, cl, task, id, name, approval)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// When the task does not exist, the upload has finished already
+	if task != nil {
+		err = task.WaitInspectTaskCompletion(func(task *types.Task, _ int, elapsed time.Duration, _, _ bool) {
+			if shouldReport() {
+				tflog.Info(ctx, fmt.Sprintf("VCFA is importing %s '%s': task is %s (%d%%)", labelVcfaContentLibraryItem, name, task.Status, task.Progress), map[string]interface{}{
+					"content_library_item": name,
+					"task_status":          task.Status,
+					"task_progress":        task.Progress,
+					"elapsed":              elapsed.Round(time.Second).String(),
+				})
+			}
+		}, 3*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("VCFA could not import the files of %s '%s': %s", labelVcfaContentLibraryItem, name, err)
+		}
+	}
+
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"PROCESSING"},
+		Target:  []string{contentLibraryItemStatusReady},
+		Refresh: func() (any, string, error) {
+			cli, err := cl.GetContentLibraryItemById(id)
+			if err != nil {
+				return nil, "", err
+			}
+			status := cli.ContentLibraryItem.Status
+			if shouldReport() {
+				tflog.Info(ctx, fmt.Sprintf("VCFA is processing %s '%s': status is %s", labelVcfaContentLibraryItem, name, status), map[string]interface{}{
+					"content_library_item": name,
+					"status":               status,
+				})
+			}
+			switch status {
+			case contentLibraryItemStatusReady:
+				return cli, status, nil
+			case contentLibraryItemStatusFailed, contentLibraryItemStatusError, contentLibraryItemStatusQuarantineExpired, contentLibraryItemStatusRejected:
+				return cli, status, fmt.Errorf("VCFA could not process the files of %s '%s', that ended in status '%s'", labelVcfaContentLibraryItem, name, status)
+			}
+			return cli, "PROCESSING", nil
+		},
+		Timeout:    contentLibraryItemProcessingTimeout,
+		Delay:      time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+	result, err := stateChangeFunc.WaitForStateContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tflog.Info(ctx, fmt.Sprintf("%s '%s' is %s", labelVcfaContentLibraryItem, name, contentLibraryItemStatusReady), map[string]interface{}{
+		"content_library_item": name,
+		"status":               contentLibraryItemStatusReady,
+	})
+	return result.(*govcd.ContentLibraryItem), nil
+}
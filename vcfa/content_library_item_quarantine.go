@@ -0,0 +1,192 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"github.com/vmware/go-vcloud-director/v3/types/v56"
+)
+
+// Content types and namespace of the Blocking Task API, that VCFA uses to hold the upload of quarantined items
+const (
+	blockingTaskExtensionNamespace = "http://www.vmware.com/vcloud/extension/v1.5"
+	blockingTaskOperationMimeType  = "application/vnd.vmware.admin.BlockingTaskOperationParams+xml"
+)
+
+// contentLibraryItemQuarantineApproval defines how a Content Library Item is approved or rejected when VCFA puts it in
+// quarantine after its files are uploaded
+type contentLibraryItemQuarantineApproval struct {
+	approve          bool   // Whether to approve the item. Otherwise, it is rejected
+	expectedChecksum string // Optional SHA-256 checksum that the source must match to be approved
+	message          string // Message that is recorded with the decision
+}
+
+// blockingTaskReferences is the list of Blocking Tasks that are waiting for a decision
+type blockingTaskReferences struct {
+	XMLName   xml.Name           `xml:"BlockingTaskReferences"`
+	Reference []*types.Reference `xml:"Reference"`
+}
+
+// blockingTask holds a task until it is resumed, aborted or failed
+type blockingTask struct {
+	XMLName xml.Name       `xml:"BlockingTask"`
+	HREF    string         `xml:"href,attr"`
+	Status  string         `xml:"status,attr"`
+	Link    types.LinkList `xml:"Link"`
+}
+
+// blockingTaskOperationParams is the payload of the actions of a Blocking Task
+type blockingTaskOperationParams struct {
+	XMLName xml.Name `xml:"BlockingTaskOperationParams"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Message string   `xml:"Message"`
+}
+
+// withSourceChecksum returns a copy of the approval that rejects the item when the checksum of the given source does not
+// match the expected one. The checksum covers all the uploaded files: It is the one of the ISO file or the OVA archive,
+// or the one of the OVF manifest for OVF descriptors, after verifying that the descriptor and its referenced files match
+// the manifest
+func (approval *contentLibraryItemQuarantineApproval) withSourceChecksum(source *contentLibraryItemSource) (*contentLibraryItemQuarantineApproval, error) {
+	if approval == nil || !approval.approve || approval.expectedChecksum == "" {
+		return approval, nil
+	}
+	var checksum string
+	switch {
+	case source.verifiedManifest != nil:
+		manifest, err := source.verifiedManifest()
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(manifest)
+		checksum = hex.EncodeToString(sum[:])
+	case source.checksum != nil:
+		var err error
+		checksum, err = source.checksum()
+		if err != nil {
+			return nil, err
+		}
+	case len(source.files) > 0:
+		checksums, err := getContentLibraryItemFileChecksums(source.files[:1])
+		if err != nil {
+			return nil, err
+		}
+		checksum = checksums[filepath.Base(source.files[0])]
+	default:
+		return nil, fmt.Errorf("the checksum of the source can't be calculated to approve the %s", labelVcfaContentLibraryItem)
+	}
+	if strings.EqualFold(checksum, approval.expectedChecksum) {
+		return approval, nil
+	}
+	return &contentLibraryItemQuarantineApproval{
+		approve: false,
+		message: fmt.Sprintf("the SHA-256 checksum of the source is '%s', but '%s' was required to approve it", checksum, approval.expectedChecksum),
+	}, nil
+}
+
+// resolveContentLibraryItemQuarantine waits until the given upload task finishes or the Content Library Item is put in
+// quarantine. In the latter case, the item is approved or rejected according to the given approval. It returns an error
+// if the item is rejected
+func resolveContentLibraryItemQuarantine(ctx context.Context, tmClient *VCDClient, cl *govcd.ContentLibrary, task *govcd.Task, id, name string, approval *contentLibraryItemQuarantineApproval) error {
+	quarantined := false
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"WAITING"},
+		Target:  []string{"DONE", contentLibraryItemStatusQuarantined},
+		Refresh: func() (any, string, error) {
+			err := task.Refresh()
+			if err != nil {
+				return nil, "", err
+			}
+			if !slices.Contains([]string{"queued", "preRunning", "running"}, task.Task.Status) {
+				return task, "DONE", nil
+			}
+			cli, err := cl.GetContentLibraryItemById(id)
+			if err != nil {
+				return nil, "", err
+			}
+			if cli.ContentLibraryItem.Status == contentLibraryItemStatusQuarantined {
+				quarantined = true
+				return task, contentLibraryItemStatusQuarantined, nil
+			}
+			return task, "WAITING", nil
+		},
+		Timeout:    contentLibraryItemProcessingTimeout,
+		Delay:      time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+	_, err := stateChangeFunc.WaitForStateContext(ctx)
+	if err != nil {
+		return err
+	}
+	// The task finished without quarantine, so its result is checked by the caller
+	if !quarantined {
+		return nil
+	}
+
+	action := "resume"
+	if !approval.approve {
+		action = "abort"
+	}
+	tflog.Info(ctx, fmt.Sprintf("%s '%s' is %s, performing '%s'", labelVcfaContentLibraryItem, name, contentLibraryItemStatusQuarantined, action), map[string]interface{}{
+		"content_library_item": name,
+		"status":               contentLibraryItemStatusQuarantined,
+		"action":               action,
+	})
+	err = performBlockingTaskAction(tmClient, task, action, approval.message)
+	if err != nil {
+		return fmt.Errorf("error performing '%s' on quarantined %s '%s': %s", action, labelVcfaContentLibraryItem, name, err)
+	}
+	if !approval.approve {
+		if approval.message != "" {
+			return fmt.Errorf("the %s '%s' was rejected from quarantine: %s", labelVcfaContentLibraryItem, name, approval.message)
+		}
+		return fmt.Errorf("the %s '%s' was rejected from quarantine", labelVcfaContentLibraryItem, name)
+	}
+	return nil
+}
+
+// performBlockingTaskAction finds the Blocking Task that holds the given task, and performs the given action on it,
+// which is one of 'resume', 'abort' or 'fail'
+func performBlockingTaskAction(tmClient *VCDClient, task *govcd.Task, action, message string) error {
+	references := &blockingTaskReferences{}
+	_, err := tmClient.Client.ExecuteRequest(tmClient.Client.VCDHREF.String()+"/admin/extension/blockingTask", http.MethodGet,
+		"", "error retrieving Blocking Tasks: %s", nil, references)
+	if err != nil {
+		return err
+	}
+
+	taskUuid := task.Task.ID[strings.LastIndex(task.Task.ID, ":")+1:]
+	for _, reference := range references.Reference {
+		bt := &blockingTask{}
+		_, err = tmClient.Client.ExecuteRequest(reference.HREF, http.MethodGet, "", "error retrieving Blocking Task: %s", nil, bt)
+		if err != nil {
+			return err
+		}
+		holdsTask := bt.Link.Find(func(link *types.Link) bool {
+			return link.Rel == "up" && strings.Contains(link.HREF, taskUuid)
+		})
+		if holdsTask == nil {
+			continue
+		}
+		return tmClient.Client.ExecuteRequestWithoutResponse(bt.HREF+"/action/"+action, http.MethodPost, blockingTaskOperationMimeType,
+			"error performing Blocking Task action: %s", &blockingTaskOperationParams{
+				Xmlns:   blockingTaskExtensionNamespace,
+				Message: message,
+			})
+	}
+	return fmt.Errorf("could not find the Blocking Task of task '%s'", task.Task.ID)
+}
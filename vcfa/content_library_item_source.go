@@ -0,0 +1,633 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vmware/go-vcloud-director/v3/util"
+)
+
+// Content Library Item types, as expected by the 'itemType' field during creation
+const (
+	contentLibraryItemTypeIso      = "ISO"
+	contentLibraryItemTypeTemplate = "TEMPLATE"
+)
+
+// contentLibraryItemSourceFile is a file that can be uploaded to a Content Library Item. The 'open' function
+// returns a reader of the file contents starting at the given offset
+type contentLibraryItemSourceFile struct {
+	name string
+	size int64 // -1 when unknown
+	open func(offset int64) (io.ReadCloser, error)
+}
+
+// contentLibraryItemSource contains the files that are uploaded to create a Content Library Item
+type contentLibraryItemSource struct {
+	// itemType is either contentLibraryItemTypeIso or contentLibraryItemTypeTemplate
+	itemType string
+	// main is the file that is uploaded first: Either the ISO file or the OVF descriptor
+	main *contentLibraryItemSourceFile
+	// companion retrieves the file with the given name, that is referenced by the OVF descriptor
+	companion func(name string) (*contentLibraryItemSourceFile, error)
+	// checksum returns the SHA-256 checksum of the source. It is nil when the source does not support checksums
+	checksum func() (string, error)
+	// verifiedManifest returns the contents of the OVF manifest of an OVF descriptor, after verifying that the descriptor
+	// and all its referenced files match it. It is nil for ISO and OVA sources, as their checksum covers all their files
+	verifiedManifest func() ([]byte, error)
+	// close releases the resources that are held by the source
+	close func() error
+	// files are the paths of all the local files that compose the source. It is empty for remote sources
+	files []string
+}
+
+// ovfEnvelope contains the parts of an OVF descriptor that are needed to upload its referenced files
+type ovfEnvelope struct {
+	XMLName    xml.Name `xml:"Envelope"`
+	References struct {
+		Files []ovfFileReference `xml:"File"`
+	} `xml:"References"`
+}
+
+// ovfFileReference is a file that is referenced by an OVF descriptor
+type ovfFileReference struct {
+	Href string `xml:"href,attr"`
+	Id   string `xml:"id,attr"`
+	Size int64  `xml:"size,attr"` // 0 when not declared
+}
+
+// parseOvfReferences returns the file references of the given OVF descriptor
+func parseOvfReferences(descriptor []byte) ([]ovfFileReference, error) {
+	var envelope ovfEnvelope
+	decoder := xml.NewDecoder(bytes.NewReader(descriptor))
+	decoder.CharsetReader = ovfCharsetReader
+	if err := decoder.Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("error parsing OVF descriptor: %s", err)
+	}
+	return envelope.References.Files, nil
+}
+
+// sequentialHash calculates the hash of a file that is read in order, possibly with several readers that
+// overlap, as it only takes into account the bytes that come right after the ones that were already hashed
+type sequentialHash struct {
+	hash   hash.Hash
+	offset int64
+}
+
+func newSequentialHash() *sequentialHash {
+	return &sequentialHash{hash: sha256.New()}
+}
+
+// reader wraps the given reader, that starts at the given offset of the file, so the read bytes are hashed
+func (s *sequentialHash) reader(r io.ReadCloser, offset int64) io.ReadCloser {
+	return &sequentialHashReader{ReadCloser: r, parent: s, position: offset}
+}
+
+// sum returns the hash in hexadecimal format
+func (s *sequentialHash) sum() string {
+	return hex.EncodeToString(s.hash.Sum(nil))
+}
+
+type sequentialHashReader struct {
+	io.ReadCloser
+	parent   *sequentialHash
+	position int64
+}
+
+func (r *sequentialHashReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		start, end := r.position, r.position+int64(n)
+		if start <= r.parent.offset && r.parent.offset < end {
+			r.parent.hash.Write(p[r.parent.offset-start : n])
+			r.parent.offset = end
+		}
+		r.position = end
+	}
+	return n, err
+}
+
+// Timeouts of the requests to the HTTP(S) server of a 'source_url'. There is no timeout for the whole request, as the
+// files can be big, but connecting and receiving the response headers must not take longer than these
+const (
+	contentLibraryItemSourceConnectTimeout        = 30 * time.Second
+	contentLibraryItemSourceResponseHeaderTimeout = 2 * time.Minute
+)
+
+// newContentLibraryItemSourceHttpClient returns an HTTP client to read the files of a 'source_url', with the same
+// proxy and TLS verification settings as the client of the provider
+func newContentLibraryItemSourceHttpClient(tmClient *VCDClient) *http.Client {
+	var transport *http.Transport
+	if providerTransport, ok := tmClient.Client.Http.Transport.(*http.Transport); ok {
+		transport = providerTransport.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyFromEnvironment
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: tmClient.InsecureFlag} // #nosec G402 -- Follows 'allow_unverified_ssl'
+	}
+	transport.DialContext = (&net.Dialer{Timeout: contentLibraryItemSourceConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = contentLibraryItemSourceConnectTimeout
+	transport.ResponseHeaderTimeout = contentLibraryItemSourceResponseHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// httpFileSource reads files from an HTTP(S) server
+type httpFileSource struct {
+	client     *http.Client
+	baseUrl    *url.URL
+	authHeader string
+}
+
+// get performs a GET request to the given URL, asking for the contents starting at the given offset.
+// If the server does not support ranges, the bytes before the offset are discarded
+func (s *httpFileSource) get(fileUrl *url.URL, offset int64) (io.ReadCloser, error) {
+	response, err := s.do(http.MethodGet, fileUrl, offset)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 && response.StatusCode != http.StatusPartialContent {
+		util.Logger.Printf("[DEBUG] %s does not support ranges, discarding the first %d bytes", fileUrl.Redacted(), offset)
+		if _, err := io.CopyN(io.Discard, response.Body, offset); err != nil {
+			_ = response.Body.Close()
+			return nil, fmt.Errorf("error skipping the first %d bytes of %s: %s", offset, fileUrl.Redacted(), err)
+		}
+	}
+	return response.Body, nil
+}
+
+// size returns the size of the file in the given URL, or -1 if the server does not report it. Some servers
+// (like the ones that use pre-signed URLs) only accept GET requests, so it is used when HEAD fails
+func (s *httpFileSource) size(fileUrl *url.URL) (int64, error) {
+	response, err := s.do(http.MethodHead, fileUrl, 0)
+	if err != nil {
+		util.Logger.Printf("[DEBUG] could not retrieve the size of %s with a HEAD request, retrying with GET: %s", fileUrl.Redacted(), err)
+		response, err = s.do(http.MethodGet, fileUrl, 0)
+		if err != nil {
+			return -1, err
+		}
+	}
+	_ = response.Body.Close()
+	return response.ContentLength, nil
+}
+
+func (s *httpFileSource) do(method string, fileUrl *url.URL, offset int64) (*http.Response, error) {
+	request, err := http.NewRequest(method, fileUrl.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building request for %s: %s", fileUrl.Redacted(), err)
+	}
+	// The authorization header is only sent to the host of the source URL, so the credentials are not leaked
+	// when the OVF references files in other hosts
+	if s.authHeader != "" && strings.EqualFold(fileUrl.Host, s.baseUrl.Host) {
+		request.Header.Set("Authorization", s.authHeader)
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error performing %s request to %s: %s", method, fileUrl.Redacted(), err)
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("error performing %s request to %s: %s", method, fileUrl.Redacted(), response.Status)
+	}
+	return response, nil
+}
+
+// file returns the file in the given URL
+func (s *httpFileSource) file(fileUrl *url.URL, size int64) *contentLibraryItemSourceFile {
+	return &contentLibraryItemSourceFile{
+		name: path.Base(fileUrl.Path),
+		size: size,
+		open: func(offset int64) (io.ReadCloser, error) {
+			return s.get(fileUrl, offset)
+		},
+	}
+}
+
+// getContentLibraryItemUrlSource builds the source of a Content Library Item that is located in the given HTTP(S) URL,
+// which must point to an ISO, OVA or OVF file. The files are streamed from the server during the upload, without storing
+// them in local disk. The files referenced by an OVF descriptor are resolved relative to the descriptor URL.
+func getContentLibraryItemUrlSource(client *http.Client, sourceUrl, authHeader string) (*contentLibraryItemSource, error) {
+	parsedUrl, err := url.ParseRequestURI(sourceUrl)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing source URL: %s", err)
+	}
+	s := &httpFileSource{
+		client:     client,
+		baseUrl:    parsedUrl,
+		authHeader: authHeader,
+	}
+
+	size, err := s.size(parsedUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	// The checksum is calculated while the source URL is streamed. If some of its contents were not read during
+	// the upload, they are read afterward
+	checksum := newSequentialHash()
+	openSource := func(offset int64) (io.ReadCloser, error) {
+		body, err := s.get(parsedUrl, offset)
+		if err != nil {
+			return nil, err
+		}
+		return checksum.reader(body, offset), nil
+	}
+	source := &contentLibraryItemSource{
+		checksum: func() (string, error) {
+			body, err := openSource(checksum.offset)
+			if err != nil {
+				return "", err
+			}
+			defer body.Close()
+			if _, err := io.Copy(io.Discard, body); err != nil {
+				return "", fmt.Errorf("error reading %s to calculate its checksum: %s", parsedUrl.Redacted(), err)
+			}
+			return checksum.sum(), nil
+		},
+		close: func() error { return nil },
+	}
+
+	switch strings.ToLower(path.Ext(parsedUrl.Path)) {
+	case ".iso":
+		if size < 0 {
+			return nil, fmt.Errorf("the server did not report the size of %s, which is required to upload ISO files", parsedUrl.Redacted())
+		}
+		source.itemType = contentLibraryItemTypeIso
+		source.main = s.file(parsedUrl, size)
+		source.main.open = openSource
+	case ".ovf":
+		body, err := openSource(0)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		descriptor, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading OVF descriptor from %s: %s", parsedUrl.Redacted(), err)
+		}
+		references, err := parseOvfReferences(descriptor)
+		if err != nil {
+			return nil, err
+		}
+		source.itemType = contentLibraryItemTypeTemplate
+		source.main = &contentLibraryItemSourceFile{
+			name: path.Base(parsedUrl.Path),
+			size: int64(len(descriptor)),
+			open: func(offset int64) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(descriptor[offset:])), nil
+			},
+		}
+		// The manifest is expected next to the descriptor, with its same name and the '.mf' extension
+		manifestUrl := parsedUrl.ResolveReference(&url.URL{Path: strings.TrimSuffix(source.main.name, path.Ext(source.main.name)) + ".mf"})
+		source.verifiedManifest = func() ([]byte, error) {
+			body, err := s.get(manifestUrl, 0)
+			if err != nil {
+				return nil, fmt.Errorf("error reading the OVF manifest %s, which is required to verify the files of the OVF descriptor: %s", manifestUrl.Redacted(), err)
+			}
+			defer body.Close()
+			manifest, err := io.ReadAll(body)
+			if err != nil {
+				return nil, fmt.Errorf("error reading the OVF manifest %s: %s", manifestUrl.Redacted(), err)
+			}
+			entries, err := parseOvfManifest(manifest)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", manifestUrl.Redacted(), err)
+			}
+			toVerify := map[string]func() (io.ReadCloser, error){source.main.name: func() (io.ReadCloser, error) { return source.main.open(0) }}
+			for _, reference := range references {
+				file, err := source.companion(path.Base(reference.Href))
+				if err != nil {
+					return nil, err
+				}
+				toVerify[reference.Href] = func() (io.ReadCloser, error) { return file.open(0) }
+			}
+			problems, err := verifyOvfManifestEntries(entries, manifestUrl.Redacted(), toVerify)
+			if err != nil {
+				return nil, err
+			}
+			if len(problems) > 0 {
+				return nil, fmt.Errorf("the files of the OVF descriptor %s do not match its manifest:\n  - %s", parsedUrl.Redacted(), strings.Join(problems, "\n  - "))
+			}
+			return manifest, nil
+		}
+		source.companion = func(name string) (*contentLibraryItemSourceFile, error) {
+			href := name
+			for _, reference := range references {
+				if path.Base(reference.Href) == name {
+					href = reference.Href
+					break
+				}
+			}
+			referenceUrl, err := url.Parse(href)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing OVF file reference '%s': %s", href, err)
+			}
+			fileUrl := parsedUrl.ResolveReference(referenceUrl)
+			size, err := s.size(fileUrl)
+			if err != nil {
+				return nil, err
+			}
+			return s.file(fileUrl, size), nil
+		}
+	case ".ova":
+		ova := &ovaReader{open: openSource}
+		source.itemType = contentLibraryItemTypeTemplate
+		source.main = ova.file("OVF descriptor", func(name string) bool { return strings.EqualFold(path.Ext(name), ".ovf") })
+		source.companion = func(name string) (*contentLibraryItemSourceFile, error) {
+			return ova.file(name, func(entryName string) bool { return entryName == name }), nil
+		}
+		source.close = ova.close
+	default:
+		return nil, fmt.Errorf("the source URL %s must point to an ISO, OVA or OVF file", parsedUrl.Redacted())
+	}
+	return source, nil
+}
+
+// localFile returns the file in the given local path
+func localFile(filePath string) (*contentLibraryItemSourceFile, error) {
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &contentLibraryItemSourceFile{
+		name: filepath.Base(filePath),
+		size: fileInfo.Size(),
+		open: func(offset int64) (io.ReadCloser, error) {
+			return openLocalFile(filePath, offset)
+		},
+	}, nil
+}
+
+// openLocalFile opens the file in the given local path, starting at the given offset
+func openLocalFile(filePath string, offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error seeking offset %d of %s: %s", offset, filePath, err)
+	}
+	return file, nil
+}
+
+// getContentLibraryItemLocalSource builds the source of a Content Library Item from the given local paths, that must
+// be a single ISO 9660 image or OVA archive, or an OVF descriptor. The files that the OVF descriptor references are
+// taken from the given paths or discovered next to the descriptor. The type of every file is detected from its content,
+// and the files are validated before any upload starts.
+func getContentLibraryItemLocalSource(filePaths []string) (*contentLibraryItemSource, error) {
+	source := &contentLibraryItemSource{
+		close: func() error { return nil },
+	}
+	descriptorPath, manifestPath := "", ""
+	explicitFiles := map[string]string{}
+	for _, p := range filePaths {
+		p = filepath.Clean(p)
+		content, err := detectContentLibraryItemFileContent(p)
+		if err != nil {
+			return nil, err
+		}
+		switch content {
+		case contentLibraryItemContentIso, contentLibraryItemContentOva:
+			if len(filePaths) > 1 {
+				return nil, fmt.Errorf("'%s' is an %s, which must be the only file in 'file_paths'", p, content)
+			}
+			return getContentLibraryItemLocalSingleFileSource(source, p, content)
+		case contentLibraryItemContentOvfDescriptor:
+			if descriptorPath != "" {
+				return nil, fmt.Errorf("only one OVF descriptor can be uploaded, but found '%s' and '%s'", descriptorPath, p)
+			}
+			descriptorPath = p
+		case contentLibraryItemContentOvfManifest:
+			if manifestPath != "" {
+				return nil, fmt.Errorf("only one OVF manifest can be provided, but found '%s' and '%s'", manifestPath, p)
+			}
+			manifestPath = p
+		default:
+			explicitFiles[filepath.Base(p)] = p
+		}
+	}
+	if descriptorPath == "" {
+		if len(filePaths) == 1 {
+			return nil, fmt.Errorf("'%s' is not an ISO 9660 image, an OVA archive nor an OVF descriptor", filePaths[0])
+		}
+		return nil, fmt.Errorf("could not find an OVF descriptor in any of the provided paths: %v", filePaths)
+	}
+
+	files, err := resolveLocalOvfFiles(descriptorPath, manifestPath, explicitFiles)
+	if err != nil {
+		return nil, err
+	}
+	main, err := localFile(descriptorPath)
+	if err != nil {
+		return nil, err
+	}
+	source.itemType = contentLibraryItemTypeTemplate
+	source.main = main
+	source.companion = func(name string) (*contentLibraryItemSourceFile, error) {
+		if p, ok := files[name]; ok {
+			return localFile(p)
+		}
+		return nil, fmt.Errorf("'%s' is not referenced by the OVF descriptor '%s'", name, descriptorPath)
+	}
+	if manifestPath == "" {
+		candidate := strings.TrimSuffix(descriptorPath, filepath.Ext(descriptorPath)) + ".mf"
+		if files[filepath.Base(candidate)] == candidate {
+			manifestPath = candidate
+		}
+	}
+	source.verifiedManifest = func() ([]byte, error) {
+		if manifestPath == "" {
+			return nil, fmt.Errorf("the OVF descriptor '%s' has no OVF manifest, which is required to verify its files", descriptorPath)
+		}
+		// The files are verified again, as they may have changed since they were validated
+		if _, err := resolveLocalOvfFiles(descriptorPath, manifestPath, explicitFiles); err != nil {
+			return nil, err
+		}
+		return os.ReadFile(filepath.Clean(manifestPath))
+	}
+	source.files = []string{descriptorPath}
+	for _, p := range files {
+		source.files = append(source.files, p)
+	}
+	sort.Strings(source.files[1:])
+	return source, nil
+}
+
+// getContentLibraryItemLocalSingleFileSource fills the given source with a single ISO 9660 image or OVA archive
+func getContentLibraryItemLocalSingleFileSource(source *contentLibraryItemSource, filePath, content string) (*contentLibraryItemSource, error) {
+	source.files = []string{filePath}
+	if content == contentLibraryItemContentIso {
+		main, err := localFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		source.itemType = contentLibraryItemTypeIso
+		source.main = main
+		return source, nil
+	}
+
+	if err := validateLocalOva(filePath); err != nil {
+		return nil, err
+	}
+	ova := &ovaReader{open: func(offset int64) (io.ReadCloser, error) {
+		return openLocalFile(filePath, offset)
+	}}
+	source.itemType = contentLibraryItemTypeTemplate
+	source.main = ova.file("OVF descriptor", func(name string) bool { return strings.EqualFold(path.Ext(name), ".ovf") })
+	source.companion = func(name string) (*contentLibraryItemSourceFile, error) {
+		return ova.file(name, func(entryName string) bool { return entryName == name }), nil
+	}
+	source.close = ova.close
+	return source, nil
+}
+
+// getContentLibraryItemFileChecksums computes the SHA-256 checksum of every given local file, in hexadecimal format. The
+// checksums are keyed by file name, so moving the files to a different directory does not change the result
+func getContentLibraryItemFileChecksums(filePaths []string) (map[string]string, error) {
+	checksums := make(map[string]string, len(filePaths))
+	for _, p := range filePaths {
+		file, err := openLocalFile(p, 0)
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %s", p, err)
+		}
+		checksums[filepath.Base(p)] = hex.EncodeToString(h.Sum(nil))
+	}
+	return checksums, nil
+}
+
+// getContentLibraryItemFileStats returns the size and modification time of every given local file, keyed by file name
+// like the checksums, so the checksums are only computed again when the files change
+func getContentLibraryItemFileStats(filePaths []string) (map[string]string, error) {
+	stats := make(map[string]string, len(filePaths))
+	for _, p := range filePaths {
+		fileInfo, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		stats[filepath.Base(p)] = fmt.Sprintf("%d:%d", fileInfo.Size(), fileInfo.ModTime().UnixNano())
+	}
+	return stats, nil
+}
+
+// findContentLibraryItemLocalFiles returns the paths of the files with the names of the given checksums, taken from the
+// given paths or found next to them, like the files referenced by an OVF descriptor. The second returned value is false
+// if any of the files can't be found
+func findContentLibraryItemLocalFiles(filePaths []string, checksums map[string]string) ([]string, bool) {
+	result := make([]string, 0, len(checksums))
+	for name := range checksums {
+		found := ""
+		for _, p := range filePaths {
+			if filepath.Base(p) == name {
+				found = p
+				break
+			}
+		}
+		for i := 0; found == "" && i < len(filePaths); i++ {
+			candidate := filepath.Join(filepath.Dir(filePaths[i]), name)
+			if fileInfo, err := os.Stat(candidate); err == nil && fileInfo.Mode().IsRegular() {
+				found = candidate
+			}
+		}
+		if found == "" {
+			return nil, false
+		}
+		result = append(result, found)
+	}
+	return result, true
+}
+
+// ovaReader reads the files inside an OVA archive. As the files are usually requested in the same order as they appear
+// in the archive, the archive is read sequentially and only re-opened when a previous file is requested.
+type ovaReader struct {
+	open func(offset int64) (io.ReadCloser, error)
+	body io.ReadCloser
+	tar  *tar.Reader
+}
+
+// file returns the first file inside the OVA whose name matches the given function
+func (o *ovaReader) file(name string, matches func(name string) bool) *contentLibraryItemSourceFile {
+	return &contentLibraryItemSourceFile{
+		name: name,
+		size: -1,
+		open: func(offset int64) (io.ReadCloser, error) {
+			entry, err := o.seek(name, matches)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := io.CopyN(io.Discard, o.tar, offset); err != nil {
+				return nil, fmt.Errorf("error skipping the first %d bytes of '%s' in the OVA: %s", offset, entry.Name, err)
+			}
+			return io.NopCloser(o.tar), nil
+		},
+	}
+}
+
+// seek moves the archive to the first entry that matches the given function, starting from the current position
+// and re-opening the archive if it is not found
+func (o *ovaReader) seek(name string, matches func(name string) bool) (*tar.Header, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if o.tar == nil || attempt > 0 {
+			if err := o.close(); err != nil {
+				return nil, err
+			}
+			body, err := o.open(0)
+			if err != nil {
+				return nil, err
+			}
+			o.body = body
+			o.tar = tar.NewReader(body)
+		}
+		for {
+			header, err := o.tar.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error reading OVA archive: %s", err)
+			}
+			if header.Typeflag == tar.TypeReg && matches(path.Base(header.Name)) {
+				return header, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("'%s' was not found inside the OVA archive", name)
+}
+
+func (o *ovaReader) close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	o.tar = nil
+	return err
+}
@@ -0,0 +1,664 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"github.com/vmware/go-vcloud-director/v3/types/v56"
+	"github.com/vmware/go-vcloud-director/v3/util"
+)
+
+// contentLibraryItemKeepAliveInterval is the time between requests that keep the session alive during
+// long uploads, as the upload requests do not refresh it
+const contentLibraryItemKeepAliveInterval = time.Minute
+
+// contentLibraryItemPartRetries is the amount of times that the upload of a file part is retried before
+// considering that the file upload failed
+const contentLibraryItemPartRetries = 4
+
+// contentLibraryItemUploadResumes is the amount of times that a failed file upload is resumed from the last
+// acknowledged offset before giving up
+const contentLibraryItemUploadResumes = 3
+
+// contentLibraryItemUploadArguments defines how the files of a Content Library Item are uploaded
+type contentLibraryItemUploadArguments struct {
+	pieceSize        int64         // Size of the chunks in which the files are split
+	concurrency      int           // Amount of chunks that are uploaded at the same time
+	expectedChecksum string        // Optional SHA-256 checksum that the source must match
+	uploadSlots      chan struct{} // Optional provider-wide limit of simultaneous uploads
+	progressInterval time.Duration // Time between progress reports. Not positive values disable them
+	itemName         string        // Name of the Content Library Item, that is set when the upload starts
+
+	// approval is the optional decision to take when VCFA puts the item in quarantine
+	approval *contentLibraryItemQuarantineApproval
+}
+
+// contentLibraryItemTransferError is returned when transferring a file fails even after retrying. In that case, the
+// partially uploaded Content Library Item is kept, so the upload can be resumed in the next apply
+type contentLibraryItemTransferError struct {
+	err error
+}
+
+func (e *contentLibraryItemTransferError) Error() string {
+	return e.err.Error()
+}
+
+// uploadContentLibraryItem creates a Content Library Item in the given Content Library and uploads the files from the given
+// source. If an item with the same name has an upload in progress, which was interrupted in a previous apply, its upload
+// is resumed instead. If any step fails, the Content Library Item is removed, so it is not left stranded, unless the
+// failure happened while transferring the files, so the upload can be resumed later.
+func uploadContentLibraryItem(ctx context.Context, tmClient *VCDClient, cl *govcd.ContentLibrary, config *types.ContentLibraryItem, source *contentLibraryItemSource, args contentLibraryItemUploadArguments) (*govcd.ContentLibraryItem, error) {
+	defer func() {
+		if err := source.close(); err != nil {
+			util.Logger.Printf("[DEBUG] could not close the source of %s '%s': %s", labelVcfaContentLibraryItem, config.Name, err)
+		}
+	}()
+
+	args = args.withDefaults()
+	args.itemName = config.Name
+	release, err := acquireContentLibraryItemUploadSlot(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cli, err := getResumableContentLibraryItem(tmClient, cl, config.Name, source)
+	if err != nil {
+		return nil, err
+	}
+	if cli != nil {
+		util.Logger.Printf("[INFO] resuming the interrupted upload of %s '%s'", labelVcfaContentLibraryItem, config.Name)
+	} else {
+		cli, err = createContentLibraryItemSkeleton(tmClient, cl, config, source)
+		if err != nil {
+			if cli == nil || cli.ContentLibraryItem.ID == "" {
+				// The item may have been created even if the request failed, so it is searched by name
+				return nil, cleanupContentLibraryItemOnUploadError(tmClient, cl, config.Name, err)
+			}
+			return nil, cleanupContentLibraryItemOnUploadError(tmClient, cl, cli.ContentLibraryItem.ID, err)
+		}
+	}
+	id := cli.ContentLibraryItem.ID
+
+	err = uploadContentLibraryItemFiles(ctx, tmClient, id, source, args, false)
+	// The processing in VCFA can take long, and it does not use the network of the provider
+	release()
+	if err != nil {
+		var transferError *contentLibraryItemTransferError
+		if errors.As(err, &transferError) {
+			return nil, fmt.Errorf("%s\nThe partially uploaded %s '%s' was kept, and its upload will be resumed in the next apply",
+				err, labelVcfaContentLibraryItem, config.Name)
+		}
+		return nil, cleanupContentLibraryItemOnUploadError(tmClient, cl, id, err)
+	}
+
+	if args.expectedChecksum != "" && source.checksum != nil {
+		checksum, err := source.checksum()
+		if err != nil {
+			return nil, cleanupContentLibraryItemOnUploadError(tmClient, cl, id, err)
+		}
+		if !strings.EqualFold(checksum, args.expectedChecksum) {
+			return nil, cleanupContentLibraryItemOnUploadError(tmClient, cl, id,
+				fmt.Errorf("the SHA-256 checksum of the source is '%s', but '%s' was expected", checksum, args.expectedChecksum))
+		}
+	}
+
+	approval, err := args.approval.withSourceChecksum(source)
+	if err != nil {
+		return nil, cleanupContentLibraryItemOnUploadError(tmClient, cl, id, err)
+	}
+	cli, err = waitForContentLibraryItemProcessing(ctx, tmClient, cl, cli, args.progressInterval, approval)
+	if err != nil {
+		return nil, cleanupContentLibraryItemOnUploadError(tmClient, cl, id, err)
+	}
+	return cli, nil
+}
+
+// uploadContentLibraryItemVersion uploads the files of the given source as a new version of an existing Content Library Item,
+// which keeps its ID. VCFA starts a new upload session when the item is updated with the type and size of the new content,
+// and increments its version once the files are processed. The item is not removed if the upload fails, as it still
+// contains the previous version.
+func uploadContentLibraryItemVersion(ctx context.Context, tmClient *VCDClient, cl *govcd.ContentLibrary, cli *govcd.ContentLibraryItem, source *contentLibraryItemSource, args contentLibraryItemUploadArguments) (*govcd.ContentLibraryItem, error) {
+	defer func() {
+		if err := source.close(); err != nil {
+			util.Logger.Printf("[DEBUG] could not close the source of %s '%s': %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name, err)
+		}
+	}()
+
+	if cli.ContentLibraryItem.ItemType != source.itemType {
+		return nil, fmt.Errorf("the type of %s '%s' cannot change from %s to %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name,
+			cli.ContentLibraryItem.ItemType, source.itemType)
+	}
+
+	args = args.withDefaults()
+	args.itemName = cli.ContentLibraryItem.Name
+	release, err := acquireContentLibraryItemUploadSlot(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	config := *cli.ContentLibraryItem
+	if source.itemType == contentLibraryItemTypeIso {
+		config.FileUploadSizeBytes = source.main.size
+	}
+	cli, err = cli.Update(&config)
+	if err != nil {
+		return nil, fmt.Errorf("error starting the upload of a new version of %s '%s': %s", labelVcfaContentLibraryItem, config.Name, err)
+	}
+
+	id := cli.ContentLibraryItem.ID
+	err = uploadContentLibraryItemFiles(ctx, tmClient, id, source, args, true)
+	// The processing in VCFA can take long, and it does not use the network of the provider
+	release()
+	if err != nil {
+		return nil, fmt.Errorf("error uploading a new version of %s '%s': %s", labelVcfaContentLibraryItem, config.Name, err)
+	}
+
+	approval, err := args.approval.withSourceChecksum(source)
+	if err != nil {
+		return nil, fmt.Errorf("error processing the new version of %s '%s': %s", labelVcfaContentLibraryItem, config.Name, err)
+	}
+	cli, err = waitForContentLibraryItemProcessing(ctx, tmClient, cl, cli, args.progressInterval, approval)
+	if err != nil {
+		return nil, fmt.Errorf("error processing the new version of %s '%s': %s", labelVcfaContentLibraryItem, config.Name, err)
+	}
+	return cli, nil
+}
+
+// withDefaults returns a copy of the upload arguments where piece sizes that are not positive fall back to 1 MB, and
+// the concurrency is at least 1
+func (args contentLibraryItemUploadArguments) withDefaults() contentLibraryItemUploadArguments {
+	if args.pieceSize <= 0 {
+		args.pieceSize = 1024 * 1024
+	}
+	args.concurrency = max(args.concurrency, 1)
+	return args
+}
+
+// acquireContentLibraryItemUploadSlot waits until the provider-wide limit of simultaneous uploads allows a new one, and
+// returns the function that frees the slot when the files are transferred. The function can be called more than once
+func acquireContentLibraryItemUploadSlot(ctx context.Context, args contentLibraryItemUploadArguments) (func(), error) {
+	if args.uploadSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case args.uploadSlots <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-args.uploadSlots }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// getResumableContentLibraryItem returns the Content Library Item with the given name if it has an upload in progress that
+// can be resumed with the given source, or nil otherwise. An upload can be resumed when it has the same type as the source,
+// and the sizes of its files match the source files. Uploads in progress that can't be resumed are cancelled.
+func getResumableContentLibraryItem(tmClient *VCDClient, cl *govcd.ContentLibrary, name string, source *contentLibraryItemSource) (*govcd.ContentLibraryItem, error) {
+	cli, err := cl.GetContentLibraryItemByName(name)
+	if govcd.ContainsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error searching for an interrupted upload of %s '%s': %s", labelVcfaContentLibraryItem, name, err)
+	}
+	task, err := getContentLibraryItemUploadTask(tmClient, cli)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		// The item is not being uploaded, so it is left untouched and the creation reports the name conflict
+		return nil, nil
+	}
+	if cli.ContentLibraryItem.ItemType == source.itemType && task.Task.Status != "error" {
+		matches, err := contentLibraryItemFilesMatchSource(tmClient, cli.ContentLibraryItem.ID, source)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			return cli, nil
+		}
+	}
+	util.Logger.Printf("[DEBUG] the interrupted upload of %s '%s' can't be resumed, removing it", labelVcfaContentLibraryItem, name)
+	if task.Task.Status == "error" {
+		err = cli.Delete()
+	} else {
+		err = task.CancelTask()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error removing the interrupted upload of %s '%s': %s", labelVcfaContentLibraryItem, name, err)
+	}
+	return nil, waitForContentLibraryItemDeletion(cl, cli.ContentLibraryItem.ID)
+}
+
+// contentLibraryItemFilesMatchSource returns whether the files of the given Content Library Item, that has an upload in
+// progress, have the same sizes as the files of the source. The size of the main file must be known, so it is calculated
+// when the source does not report it. Referenced files of unknown size are not compared, as their sizes are declared in the
+// OVF descriptor.
+func contentLibraryItemFilesMatchSource(tmClient *VCDClient, id string, source *contentLibraryItemSource) (bool, error) {
+	files, err := getContentLibraryItemFiles(tmClient, id)
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		sourceFile := source.main
+		if source.itemType == contentLibraryItemTypeTemplate && !strings.EqualFold(path.Ext(file.Name), ".ovf") {
+			sourceFile, err = source.companion(file.Name)
+			if err != nil {
+				util.Logger.Printf("[DEBUG] the file '%s' of %s '%s' is not in the source: %s", file.Name, labelVcfaContentLibraryItem, id, err)
+				return false, nil
+			}
+		}
+		size := sourceFile.size
+		if size < 0 && sourceFile == source.main {
+			size, err = getContentLibraryItemSourceFileSize(sourceFile)
+			if err != nil {
+				return false, err
+			}
+		}
+		if size >= 0 && size != file.ExpectedSizeBytes {
+			util.Logger.Printf("[DEBUG] the file '%s' of %s '%s' has %d bytes, but the source has %d", file.Name, labelVcfaContentLibraryItem, id, file.ExpectedSizeBytes, size)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// getContentLibraryItemSourceFileSize calculates the size of a source file that does not report it, by reading it entirely
+func getContentLibraryItemSourceFileSize(sourceFile *contentLibraryItemSourceFile) (int64, error) {
+	reader, err := sourceFile.open(0)
+	if err != nil {
+		return 0, fmt.Errorf("error opening '%s': %s", sourceFile.name, err)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			util.Logger.Printf("[DEBUG] could not close '%s': %s", sourceFile.name, err)
+		}
+	}()
+	size, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		return 0, fmt.Errorf("error reading '%s': %s", sourceFile.name, err)
+	}
+	return size, nil
+}
+
+// waitForContentLibraryItemDeletion waits until the Content Library Item with the given ID does not exist, as
+// VCFA removes it asynchronously after cancelling its upload
+func waitForContentLibraryItemDeletion(cl *govcd.ContentLibrary, id string) error {
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"DELETING"},
+		Target:  []string{"DELETED"},
+		Refresh: func() (any, string, error) {
+			cli, err := cl.GetContentLibraryItemById(id)
+			if err != nil {
+				if govcd.ContainsNotFound(err) {
+					return "", "DELETED", nil
+				}
+				return nil, "", err
+			}
+			return cli, "DELETING", nil
+		},
+		Timeout:    5 * time.Minute,
+		Delay:      time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	_, err := stateChangeFunc.WaitForState()
+	if err != nil {
+		return fmt.Errorf("error waiting for the removal of %s '%s': %s", labelVcfaContentLibraryItem, id, err)
+	}
+	return nil
+}
+
+// uploadContentLibraryItemFiles uploads the pending files of the source to an already created Content Library Item. The main
+// file (ISO or OVF descriptor) is uploaded first, and then the files that VCFA requests after processing the OVF descriptor.
+// When a new version is uploaded, the files of the previous version are listed until VCFA requests the new ones, so it waits
+// for pending files to appear.
+func uploadContentLibraryItemFiles(ctx context.Context, tmClient *VCDClient, id string, source *contentLibraryItemSource, args contentLibraryItemUploadArguments, newVersion bool) error {
+	files, err := waitForContentLibraryItemFiles(ctx, tmClient, id, 1, newVersion)
+	if err != nil {
+		return err
+	}
+	err = uploadPendingContentLibraryItemFiles(ctx, tmClient, files, source, args)
+	if err != nil {
+		return err
+	}
+
+	if source.itemType != contentLibraryItemTypeTemplate {
+		return nil
+	}
+
+	// When the OVF descriptor is uploaded, the remaining files appear in the file list
+	files, err = waitForContentLibraryItemFiles(ctx, tmClient, id, 2, newVersion)
+	if err != nil {
+		return err
+	}
+	return uploadPendingContentLibraryItemFiles(ctx, tmClient, files, source, args)
+}
+
+// uploadPendingContentLibraryItemFiles uploads the given Content Library Item files that were not completely transferred yet
+func uploadPendingContentLibraryItemFiles(ctx context.Context, tmClient *VCDClient, files []*types.ContentLibraryItemFile, source *contentLibraryItemSource, args contentLibraryItemUploadArguments) error {
+	for _, file := range files {
+		if !isContentLibraryItemFilePending(file) {
+			continue
+		}
+		sourceFile := source.main
+		if source.itemType == contentLibraryItemTypeTemplate && !strings.EqualFold(path.Ext(file.Name), ".ovf") {
+			var err error
+			sourceFile, err = source.companion(file.Name)
+			if err != nil {
+				return err
+			}
+		}
+		err := uploadContentLibraryItemFile(ctx, tmClient, file, sourceFile, args)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadContentLibraryItemFile uploads the given source file to the transfer URL of the Content Library Item file, starting
+// from the bytes that were already transferred. When the upload fails, it is resumed from the last acknowledged offset.
+func uploadContentLibraryItemFile(ctx context.Context, tmClient *VCDClient, file *types.ContentLibraryItemFile, sourceFile *contentLibraryItemSourceFile, args contentLibraryItemUploadArguments) error {
+	if sourceFile.size >= 0 && sourceFile.size != file.ExpectedSizeBytes {
+		return fmt.Errorf("the size of '%s' is %d bytes, but %d bytes were expected", file.Name, sourceFile.size, file.ExpectedSizeBytes)
+	}
+	transferUrl, err := url.ParseRequestURI(file.TransferUrl)
+	if err != nil {
+		return fmt.Errorf("error parsing transfer URL of '%s': %s", file.Name, err)
+	}
+
+	offset := getContentLibraryItemResumeOffset(file.BytesTransferred, args)
+	util.Logger.Printf("[DEBUG] Uploading %s file '%s' (%d bytes) from offset %d", labelVcfaContentLibraryItem, file.Name, file.ExpectedSizeBytes, offset)
+	progress := startContentLibraryItemUploadProgress(ctx, args.itemName, file.Name, file.ExpectedSizeBytes, offset, args.progressInterval)
+	for resumes := 0; ; resumes++ {
+		acknowledged, err := uploadContentLibraryItemFileFrom(ctx, tmClient, transferUrl, file, sourceFile, offset, args, progress)
+		if err == nil {
+			progress.stop(ctx, nil)
+			return nil
+		}
+		if ctx.Err() != nil || resumes >= contentLibraryItemUploadResumes {
+			progress.stop(ctx, err)
+			return &contentLibraryItemTransferError{err: fmt.Errorf("error uploading '%s': %s", file.Name, err)}
+		}
+		util.Logger.Printf("[DEBUG] Resuming upload of %s file '%s' from offset %d after error: %s", labelVcfaContentLibraryItem, file.Name, acknowledged, err)
+		offset = acknowledged
+		progress.resume(offset)
+	}
+}
+
+// getContentLibraryItemResumeOffset returns the offset from which the upload of a file continues, given the amount of bytes
+// that VCFA acknowledged. As parts are uploaded concurrently, the acknowledged bytes may not be contiguous, so the parts that
+// could have been in flight are uploaded again
+func getContentLibraryItemResumeOffset(transferred int64, args contentLibraryItemUploadArguments) int64 {
+	return max((transferred/args.pieceSize-int64(args.concurrency))*args.pieceSize, 0)
+}
+
+// uploadContentLibraryItemFileFrom uploads the given file starting at the given offset. The file is read sequentially in
+// batches of parts, and the parts of every batch are uploaded concurrently. Returns the offset up to which all the
+// bytes were acknowledged, which is where the upload can be resumed if it fails.
+func uploadContentLibraryItemFileFrom(ctx context.Context, tmClient *VCDClient, transferUrl *url.URL, file *types.ContentLibraryItemFile, sourceFile *contentLibraryItemSourceFile, offset int64, args contentLibraryItemUploadArguments, progress *contentLibraryItemUploadProgress) (int64, error) {
+	reader, err := sourceFile.open(offset)
+	if err != nil {
+		return offset, fmt.Errorf("error opening '%s': %s", file.Name, err)
+	}
+	defer reader.Close()
+
+	buffers := make([][]byte, args.concurrency)
+	parts := make([][]byte, 0, args.concurrency)
+	lastKeepAlive := time.Now()
+	for offset < file.ExpectedSizeBytes {
+		if err := ctx.Err(); err != nil {
+			return offset, err
+		}
+		if time.Since(lastKeepAlive) > contentLibraryItemKeepAliveInterval {
+			keepSessionAlive(tmClient)
+			lastKeepAlive = time.Now()
+		}
+
+		// Read the next batch of parts
+		parts = parts[:0]
+		batchEnd := offset
+		for i := 0; i < args.concurrency && batchEnd < file.ExpectedSizeBytes; i++ {
+			partSize := min(args.pieceSize, file.ExpectedSizeBytes-batchEnd)
+			if buffers[i] == nil {
+				buffers[i] = make([]byte, args.pieceSize)
+			}
+			if _, err := io.ReadFull(reader, buffers[i][:partSize]); err != nil {
+				return offset, fmt.Errorf("error reading '%s' at offset %d: %s", file.Name, batchEnd, err)
+			}
+			parts = append(parts, buffers[i][:partSize])
+			batchEnd += partSize
+		}
+
+		// Upload the parts of the batch concurrently
+		errs := make([]error, len(parts))
+		var wg sync.WaitGroup
+		partOffset := offset
+		for i, part := range parts {
+			wg.Add(1)
+			go func(i int, part []byte, partOffset int64) {
+				defer wg.Done()
+				errs[i] = uploadContentLibraryItemFilePartWithRetries(ctx, tmClient, transferUrl, part, partOffset, file.ExpectedSizeBytes)
+				if errs[i] == nil {
+					progress.add(int64(len(part)))
+				}
+			}(i, part, partOffset)
+			partOffset += int64(len(part))
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return offset, err
+		}
+
+		offset = batchEnd
+		util.Logger.Printf("[DEBUG] Uploaded %s file '%s': %d/%d", labelVcfaContentLibraryItem, file.Name, offset, file.ExpectedSizeBytes)
+	}
+	return offset, nil
+}
+
+// uploadContentLibraryItemFilePartWithRetries uploads a file part, retrying with an exponential backoff when it fails
+func uploadContentLibraryItemFilePartWithRetries(ctx context.Context, tmClient *VCDClient, transferUrl *url.URL, part []byte, offset, totalSize int64) error {
+	var err error
+	for attempt := 0; attempt <= contentLibraryItemPartRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<(attempt-1)) * time.Second
+			util.Logger.Printf("[DEBUG] Retrying upload of part at offset %d in %s after error: %s", offset, backoff, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+		err = uploadContentLibraryItemFilePart(tmClient, transferUrl, part, offset, totalSize)
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("error uploading part at offset %d after %d attempts: %s", offset, contentLibraryItemPartRetries+1, err)
+}
+
+// uploadContentLibraryItemFilePart sends a chunk of a file, that starts at the given offset, to the transfer URL
+func uploadContentLibraryItemFilePart(tmClient *VCDClient, transferUrl *url.URL, part []byte, offset, totalSize int64) error {
+	request := tmClient.Client.NewRequestWitNotEncodedParams(nil, nil, http.MethodPut, *transferUrl, bytes.NewReader(part))
+	request.ContentLength = int64(len(part))
+	request.Header.Set("Content-Length", strconv.FormatInt(request.ContentLength, 10))
+	request.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(part))-1, totalSize))
+
+	response, err := tmClient.Client.Http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(response.Body)
+		return fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// keepSessionAlive performs a lightweight request, so the session does not expire during long uploads
+func keepSessionAlive(tmClient *VCDClient) {
+	_, err := tmClient.Client.QueryTaskList(map[string]string{"status": "running"})
+	if err != nil {
+		util.Logger.Printf("[DEBUG] error performing keep-alive request: %s", err)
+	}
+}
+
+// createContentLibraryItemSkeleton creates an empty Content Library Item, which receives the files afterward
+func createContentLibraryItemSkeleton(tmClient *VCDClient, cl *govcd.ContentLibrary, config *types.ContentLibraryItem, source *contentLibraryItemSource) (*govcd.ContentLibraryItem, error) {
+	config.ContentLibrary = types.OpenApiReference{ID: cl.ContentLibrary.ID, Name: cl.ContentLibrary.Name}
+	config.ItemType = source.itemType
+	if source.itemType == contentLibraryItemTypeIso {
+		// The size of ISO files is required during creation
+		config.FileUploadSizeBytes = source.main.size
+	}
+
+	urlRef, err := tmClient.Client.OpenApiBuildEndpoint(types.OpenApiPathVcf + types.OpenApiEndpointContentLibraryItems)
+	if err != nil {
+		return nil, err
+	}
+	created := &types.ContentLibraryItem{}
+	err = tmClient.Client.OpenApiPostItem(tmClient.Client.APIVersion, urlRef, nil, config, created, getContentLibraryTenantContextHeader(cl))
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s '%s': %s", labelVcfaContentLibraryItem, config.Name, err)
+	}
+	return &govcd.ContentLibraryItem{ContentLibraryItem: created}, nil
+}
+
+// getContentLibraryTenantContextHeader returns the headers that are needed to create items in the given Content Library
+// when it belongs to a tenant
+func getContentLibraryTenantContextHeader(cl *govcd.ContentLibrary) map[string]string {
+	org := cl.ContentLibrary.Org
+	if org == nil || org.Name == "" || strings.EqualFold(org.Name, "system") {
+		return nil
+	}
+	return map[string]string{
+		types.HeaderTenantContext: org.ID[strings.LastIndex(org.ID, ":")+1:],
+		types.HeaderAuthContext:   org.Name,
+	}
+}
+
+// getContentLibraryItemFiles retrieves the files of the given Content Library Item and their transfer status
+func getContentLibraryItemFiles(tmClient *VCDClient, id string) ([]*types.ContentLibraryItemFile, error) {
+	urlRef, err := tmClient.Client.OpenApiBuildEndpoint(types.OpenApiPathVcf + fmt.Sprintf(types.OpenApiEndpointContentLibraryItemFiles, id))
+	if err != nil {
+		return nil, err
+	}
+	var files []*types.ContentLibraryItemFile
+	err = tmClient.Client.OpenApiGetAllItems(tmClient.Client.APIVersion, urlRef, nil, &files, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving files of %s '%s': %s", labelVcfaContentLibraryItem, id, err)
+	}
+	return files, nil
+}
+
+// waitForContentLibraryItemFiles polls the given Content Library Item until it has at least the expected amount of files,
+// as VCFA adds them asynchronously during the upload process. If 'pendingRequired' is true, it also waits until one of
+// the files is pending to be transferred
+func waitForContentLibraryItemFiles(ctx context.Context, tmClient *VCDClient, id string, expectedAtLeast int, pendingRequired bool) ([]*types.ContentLibraryItemFile, error) {
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"WAITING"},
+		Target:  []string{"DONE"},
+		Refresh: func() (any, string, error) {
+			files, err := getContentLibraryItemFiles(tmClient, id)
+			if err != nil {
+				return nil, "", err
+			}
+			if len(files) < expectedAtLeast {
+				return files, "WAITING", nil
+			}
+			if pendingRequired && !slices.ContainsFunc(files, isContentLibraryItemFilePending) {
+				return files, "WAITING", nil
+			}
+			return files, "DONE", nil
+		},
+		Timeout:    2 * time.Minute,
+		Delay:      time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	files, err := stateChangeFunc.WaitForStateContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for at least %d files to upload in %s '%s': %s", expectedAtLeast, labelVcfaContentLibraryItem, id, err)
+	}
+	return files.([]*types.ContentLibraryItemFile), nil
+}
+
+// isContentLibraryItemFilePending returns true if the given Content Library Item file was not completely transferred
+func isContentLibraryItemFilePending(file *types.ContentLibraryItemFile) bool {
+	return file.BytesTransferred == 0 || file.BytesTransferred < file.ExpectedSizeBytes
+}
+
+// getContentLibraryItemUploadTask returns the task that tracks the upload of the given Content Library Item, or nil
+// if it does not exist
+func getContentLibraryItemUploadTask(tmClient *VCDClient, cli *govcd.ContentLibraryItem) (*govcd.Task, error) {
+	taskRecords, err := tmClient.Client.QueryTaskList(map[string]string{
+		"name":       "contentLibraryItemUpload",
+		"status":     "running,preRunning,queued,error",
+		"objectType": "contentLibraryItem",
+		"objectName": cli.ContentLibraryItem.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	uuid := cli.ContentLibraryItem.ID[strings.LastIndex(cli.ContentLibraryItem.ID, ":")+1:]
+	for _, taskRecord := range taskRecords {
+		if strings.Contains(taskRecord.Object, uuid) {
+			task, err := tmClient.Client.GetTaskByHREF(taskRecord.HREF)
+			if err != nil && !govcd.ContainsNotFound(err) {
+				return nil, err
+			}
+			return task, nil
+		}
+	}
+	return nil, nil
+}
+
+// cleanupContentLibraryItemOnUploadError removes the Content Library Item with the given ID or name when its upload fails,
+// and returns the original error
+func cleanupContentLibraryItemOnUploadError(tmClient *VCDClient, cl *govcd.ContentLibrary, identifier string, originalError error) error {
+	var cli *govcd.ContentLibraryItem
+	var err error
+	if strings.HasPrefix(identifier, "urn:vcloud:contentLibraryItem:") {
+		cli, err = cl.GetContentLibraryItemById(identifier)
+	} else {
+		cli, err = cl.GetContentLibraryItemByName(identifier)
+	}
+	if govcd.ContainsNotFound(err) {
+		return originalError
+	}
+	if err == nil {
+		var task *govcd.Task
+		task, err = getContentLibraryItemUploadTask(tmClient, cli)
+		if err == nil {
+			if task != nil {
+				// Cancelling the task makes VCFA remove the item
+				err = task.CancelTask()
+			} else {
+				err = cli.Delete()
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("the %s upload failed with error: %s\nCleanup of stranded %s also failed: %s",
+			labelVcfaContentLibraryItem, originalError, labelVcfaContentLibraryItem, err)
+	}
+	return originalError
+}
@@ -0,0 +1,379 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha1" // #nosec G505 -- SHA1 is needed to verify the manifests of older OVF packages
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vmware/go-vcloud-director/v3/util"
+)
+
+// Kinds of content of the local files of a Content Library Item. They are detected by inspecting the files, as their
+// extensions can't be trusted
+const (
+	contentLibraryItemContentUnknown       = "unknown file"
+	contentLibraryItemContentIso           = "ISO 9660 image"
+	contentLibraryItemContentOva           = "OVA archive"
+	contentLibraryItemContentOvfDescriptor = "OVF descriptor"
+	contentLibraryItemContentOvfManifest   = "OVF manifest"
+)
+
+const (
+	// iso9660IdentifierOffset is the position of the 'CD001' identifier of the first ISO 9660 volume descriptor,
+	// which comes right after the 32 KB of system area
+	iso9660IdentifierOffset = 32769
+	// tarMagicOffset is the position of the 'ustar' magic in the first header of a tar archive, like an OVA
+	tarMagicOffset = 257
+)
+
+// ovfManifestLineRegex matches every line of an OVF manifest, like 'SHA256(disk1.vmdk)= <hexadecimal checksum>'
+var ovfManifestLineRegex = regexp.MustCompile(`^(SHA1|SHA256|SHA512)\s*\((.+)\)\s*=\s*([0-9a-fA-F]+)$`)
+
+// ovfManifestEntry is the checksum of a file that is listed in an OVF manifest
+type ovfManifestEntry struct {
+	algorithm string
+	checksum  string
+}
+
+// detectContentLibraryItemFileContent returns the kind of content of the given local file, by inspecting its headers
+func detectContentLibraryItemFileContent(filePath string) (string, error) {
+	file, err := openLocalFile(filePath, 0)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, iso9660IdentifierOffset+len("CD001"))
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("error reading %s: %s", filePath, err)
+	}
+	header = header[:n]
+
+	if len(header) == iso9660IdentifierOffset+len("CD001") && string(header[iso9660IdentifierOffset:]) == "CD001" {
+		return contentLibraryItemContentIso, nil
+	}
+	if len(header) >= tarMagicOffset+len("ustar") && string(header[tarMagicOffset:tarMagicOffset+len("ustar")]) == "ustar" {
+		return contentLibraryItemContentOva, nil
+	}
+
+	text := bytes.TrimLeft(bytes.TrimPrefix(header, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if bytes.HasPrefix(text, []byte("<")) {
+		// The root element is searched in the whole file, as it can be preceded by long comments
+		descriptor, err := openLocalFile(filePath, 0)
+		if err != nil {
+			return "", err
+		}
+		defer descriptor.Close()
+		decoder := xml.NewDecoder(descriptor)
+		decoder.CharsetReader = ovfCharsetReader
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return contentLibraryItemContentUnknown, nil
+			}
+			if element, ok := token.(xml.StartElement); ok {
+				if element.Name.Local == "Envelope" {
+					return contentLibraryItemContentOvfDescriptor, nil
+				}
+				return contentLibraryItemContentUnknown, nil
+			}
+		}
+	}
+
+	firstLine, _, _ := bytes.Cut(text, []byte("\n"))
+	if ovfManifestLineRegex.Match(bytes.TrimSpace(firstLine)) {
+		return contentLibraryItemContentOvfManifest, nil
+	}
+	return contentLibraryItemContentUnknown, nil
+}
+
+// parseOvfManifest returns the checksums of the given OVF manifest, keyed by file name
+func parseOvfManifest(manifest []byte) (map[string]ovfManifestEntry, error) {
+	entries := map[string]ovfManifestEntry{}
+	for i, line := range strings.Split(string(manifest), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		match := ovfManifestLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d of the OVF manifest is not valid: '%s'", i+1, line)
+		}
+		entries[match[2]] = ovfManifestEntry{algorithm: match[1], checksum: strings.ToLower(match[3])}
+	}
+	return entries, nil
+}
+
+// verify returns a problem description if the contents of the given reader do not match the checksum of the manifest entry
+func (e ovfManifestEntry) verify(name string, r io.Reader) (string, error) {
+	var h hash.Hash
+	switch e.algorithm {
+	case "SHA1":
+		h = sha1.New() // #nosec G401 -- SHA1 is needed to verify the manifests of older OVF packages
+	case "SHA256":
+		h = sha256.New()
+	default:
+		h = sha512.New()
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fmt.Errorf("error reading '%s' to verify its checksum: %s", name, err)
+	}
+	if checksum := hex.EncodeToString(h.Sum(nil)); checksum != e.checksum {
+		return fmt.Sprintf("the %s checksum of '%s' is '%s', but the OVF manifest expects '%s'", e.algorithm, name, checksum, e.checksum), nil
+	}
+	return "", nil
+}
+
+// resolveLocalOvfFiles finds the local files that are referenced by the given OVF descriptor. The files are taken from
+// 'explicitFiles', keyed by file name, or otherwise from the location that the reference points to, relative to the
+// descriptor. When there is an OVF manifest, either the given one or the one next to the descriptor with the same name,
+// the checksums of the descriptor and its referenced files are verified. It returns the path of the found files,
+// including the manifest, keyed by file name, or an error describing all the problems that were found.
+func resolveLocalOvfFiles(descriptorPath, manifestPath string, explicitFiles map[string]string) (map[string]string, error) {
+	descriptor, err := os.ReadFile(filepath.Clean(descriptorPath))
+	if err != nil {
+		return nil, err
+	}
+	references, err := parseOvfReferences(descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %s", descriptorPath, err)
+	}
+
+	directory := filepath.Dir(descriptorPath)
+	files := map[string]string{}
+	var problems []string
+	for _, reference := range references {
+		if referenceUrl, err := url.Parse(reference.Href); err == nil && referenceUrl.Scheme != "" {
+			problems = append(problems, fmt.Sprintf("'%s' is a remote reference, which is not supported for local files", reference.Href))
+			continue
+		}
+		name := path.Base(reference.Href)
+		filePath, ok := explicitFiles[name]
+		if !ok {
+			filePath = filepath.Join(directory, filepath.FromSlash(reference.Href))
+		}
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("'%s' is referenced by the OVF descriptor, but it is not in 'file_paths' nor in '%s'", reference.Href, directory))
+			continue
+		}
+		if reference.Size > 0 && fileInfo.Size() != reference.Size {
+			problems = append(problems, fmt.Sprintf("'%s' has %d bytes, but the OVF descriptor declares %d", filePath, fileInfo.Size(), reference.Size))
+			continue
+		}
+		files[name] = filePath
+	}
+	for name, filePath := range explicitFiles {
+		if _, ok := files[name]; !ok {
+			util.Logger.Printf("[DEBUG] '%s' is not referenced by the OVF descriptor '%s' and won't be uploaded", filePath, descriptorPath)
+		}
+	}
+
+	if manifestPath == "" {
+		candidate := strings.TrimSuffix(descriptorPath, filepath.Ext(descriptorPath)) + ".mf"
+		if _, err := os.Stat(candidate); err == nil {
+			manifestPath = candidate
+		}
+	}
+	if manifestPath != "" {
+		manifestProblems, err := verifyLocalOvfManifest(manifestPath, descriptorPath, references, files)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, manifestProblems...)
+		files[filepath.Base(manifestPath)] = manifestPath
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("the files of the OVF descriptor '%s' are not valid:\n  - %s", descriptorPath, strings.Join(problems, "\n  - "))
+	}
+	return files, nil
+}
+
+// verifyLocalOvfManifest verifies the checksums of the given OVF descriptor and its referenced files against the given
+// OVF manifest, and returns the problems that were found
+func verifyLocalOvfManifest(manifestPath, descriptorPath string, references []ovfFileReference, files map[string]string) ([]string, error) {
+	manifest, err := os.ReadFile(filepath.Clean(manifestPath))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseOvfManifest(manifest)
+	if err != nil {
+		return []string{fmt.Sprintf("'%s': %s", manifestPath, err)}, nil
+	}
+
+	toVerify := map[string]func() (io.ReadCloser, error){filepath.Base(descriptorPath): localFileOpener(descriptorPath)}
+	for _, reference := range references {
+		if filePath, ok := files[path.Base(reference.Href)]; ok {
+			toVerify[reference.Href] = localFileOpener(filePath)
+		}
+	}
+	return verifyOvfManifestEntries(entries, manifestPath, toVerify)
+}
+
+// localFileOpener returns a function that opens the local file of the given path from the beginning
+func localFileOpener(filePath string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return openLocalFile(filePath, 0)
+	}
+}
+
+// verifyOvfManifestEntries verifies the checksums of the given files, keyed by their name in the OVF manifest, against
+// the given manifest entries, and returns the problems that were found
+func verifyOvfManifestEntries(entries map[string]ovfManifestEntry, manifestName string, files map[string]func() (io.ReadCloser, error)) ([]string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		entry, ok := entries[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("'%s' is not listed in the OVF manifest '%s'", name, manifestName))
+			continue
+		}
+		file, err := files[name]()
+		if err != nil {
+			return nil, err
+		}
+		problem, err := entry.verify(name, file)
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems, nil
+}
+
+// validateLocalOva verifies that the given OVA archive contains an OVF descriptor and all the files that it references,
+// with the declared sizes and the checksums of its OVF manifest, if it has one
+func validateLocalOva(ovaPath string) error {
+	sizes := map[string]int64{}
+	var descriptorName string
+	var descriptor, manifest []byte
+	// Only the headers are read, as the archive entries are skipped with seeks
+	err := readLocalOva(ovaPath, func(header *tar.Header, r io.Reader) error {
+		sizes[header.Name] = header.Size
+		var err error
+		switch strings.ToLower(path.Ext(header.Name)) {
+		case ".ovf":
+			if descriptor == nil {
+				descriptorName = header.Name
+				descriptor, err = io.ReadAll(r)
+			}
+		case ".mf":
+			if manifest == nil {
+				manifest, err = io.ReadAll(r)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if descriptor == nil {
+		return fmt.Errorf("the OVA archive '%s' does not contain an OVF descriptor", ovaPath)
+	}
+	references, err := parseOvfReferences(descriptor)
+	if err != nil {
+		return fmt.Errorf("'%s' in the OVA archive '%s': %s", descriptorName, ovaPath, err)
+	}
+
+	var problems []string
+	toVerify := map[string]bool{descriptorName: true}
+	for _, reference := range references {
+		size, ok := sizes[reference.Href]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("'%s' is referenced by the OVF descriptor, but it is not in the OVA archive", reference.Href))
+			continue
+		}
+		if reference.Size > 0 && size != reference.Size {
+			problems = append(problems, fmt.Sprintf("'%s' has %d bytes, but the OVF descriptor declares %d", reference.Href, size, reference.Size))
+			continue
+		}
+		toVerify[reference.Href] = true
+	}
+
+	if manifest != nil {
+		entries, err := parseOvfManifest(manifest)
+		if err != nil {
+			return fmt.Errorf("the OVF manifest of the OVA archive '%s' is not valid: %s", ovaPath, err)
+		}
+		for name := range toVerify {
+			if _, ok := entries[name]; !ok {
+				problems = append(problems, fmt.Sprintf("'%s' is not listed in the OVF manifest", name))
+			}
+		}
+		err = readLocalOva(ovaPath, func(header *tar.Header, r io.Reader) error {
+			entry, ok := entries[header.Name]
+			if !ok || !toVerify[header.Name] {
+				return nil
+			}
+			problem, err := entry.verify(header.Name, r)
+			if problem != "" {
+				problems = append(problems, problem)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("the files of the OVA archive '%s' are not valid:\n  - %s", ovaPath, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// readLocalOva calls the given function with every regular file of the given OVA archive
+func readLocalOva(ovaPath string, readEntry func(header *tar.Header, r io.Reader) error) error {
+	file, err := openLocalFile(ovaPath, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid OVA archive: %s", ovaPath, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := readEntry(header, reader); err != nil {
+			return err
+		}
+	}
+}
@@ -7,14 +7,19 @@ package vcfa
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/vmware/go-vcloud-director/v3/govcd"
 )
 
+// certificateLookupFields are the arguments of the data source that identify the Certificate to read
+var certificateLookupFields = []string{"alias", "id", "sha256_fingerprint", "sha1_fingerprint", "subject"}
+
 func datasourceVcfaCertificate() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		ReadContext: datasourceVcfaCertificateRead,
 
 		Schema: map[string]*schema.Schema{
@@ -27,14 +32,14 @@ func datasourceVcfaCertificate() *schema.Resource {
 				Type:         schema.TypeString,
 				Optional:     true,
 				Computed:     true,
-				ExactlyOneOf: []string{"alias", "id"},
+				ExactlyOneOf: certificateLookupFields,
 				Description:  "Alias of the Certificate",
 			},
 			"id": {
 				Type:         schema.TypeString,
 				Optional:     true,
 				Computed:     true,
-				ExactlyOneOf: []string{"alias", "id"},
+				ExactlyOneOf: certificateLookupFields,
 				Description:  "Certificate ID",
 			},
 			"description": {
@@ -49,8 +54,33 @@ func datasourceVcfaCertificate() *schema.Resource {
 				Computed:    true,
 				Description: "Certificate content",
 			},
+			"expiry_warning_days": certificateExpiryWarningDaysSchema,
 		},
 	}
+	maps.Copy(r.Schema, certificateMetadataSchema)
+	// These attributes can also identify the Certificate to read
+	r.Schema["sha256_fingerprint"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ExactlyOneOf: certificateLookupFields,
+		Description:  "SHA-256 fingerprint of the Certificate, as hexadecimal bytes that can be separated by colons",
+	}
+	r.Schema["sha1_fingerprint"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ExactlyOneOf: certificateLookupFields,
+		Description:  "SHA-1 fingerprint of the Certificate, as hexadecimal bytes that can be separated by colons",
+	}
+	r.Schema["subject"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ExactlyOneOf: certificateLookupFields,
+		Description:  "Distinguished name of the subject of the Certificate, like 'CN=example.com,O=Example'",
+	}
+	return r
 }
 
 func datasourceVcfaCertificateRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -64,27 +94,37 @@ func datasourceVcfaCertificateRead(_ context.Context, d *schema.ResourceData, me
 
 	// get by ID when it's available
 	var certificate *govcd.Certificate
-	if isSystem(org) {
-		if alias != "" {
-			certificate, err = tmClient.Client.GetCertificateFromLibraryByName(alias)
-		} else if d.Get("id").(string) != "" {
-			certificate, err = tmClient.Client.GetCertificateFromLibraryById(d.Get("id").(string))
-		} else {
-			return diag.Errorf("Id or Alias value is missing %s", err)
-		}
-	} else {
+	var adminOrg *govcd.AdminOrg
+	if !isSystem(org) {
 		// TODO: TM: Implement these methods in TmOrg
-		var adminOrg *govcd.AdminOrg
 		adminOrg, err = tmClient.GetAdminOrgById(org.TmOrg.ID)
 		if err != nil {
 			return diag.FromErr(err)
 		}
-		if alias != "" {
+	}
+	switch {
+	case alias != "":
+		if adminOrg == nil {
+			certificate, err = tmClient.Client.GetCertificateFromLibraryByName(alias)
+		} else {
 			certificate, err = adminOrg.GetCertificateFromLibraryByName(alias)
-		} else if d.Get("id").(string) != "" {
+		}
+	case d.Get("id").(string) != "":
+		if adminOrg == nil {
+			certificate, err = tmClient.Client.GetCertificateFromLibraryById(d.Get("id").(string))
+		} else {
 			certificate, err = adminOrg.GetCertificateFromLibraryById(d.Get("id").(string))
+		}
+	default:
+		var certificates []*govcd.Certificate
+		if adminOrg == nil {
+			certificates, err = tmClient.Client.GetAllCertificatesFromLibrary(nil)
 		} else {
-			return diag.Errorf("Id or Alias value is missing %s", err)
+			certificates, err = adminOrg.GetAllCertificatesFromLibrary(nil)
+		}
+		if err == nil {
+			certificate, err = findCertificateByMetadata(certificates, d.Get("sha256_fingerprint").(string),
+				d.Get("sha1_fingerprint").(string), d.Get("subject").(string))
 		}
 	}
 	if err != nil {
@@ -92,7 +132,43 @@ func datasourceVcfaCertificateRead(_ context.Context, d *schema.ResourceData, me
 	}
 
 	d.SetId(certificate.CertificateLibrary.Id)
-	setCertificateConfigurationData(certificate.CertificateLibrary, d)
+	return setCertificateConfigurationData(certificate.CertificateLibrary, d)
+}
 
-	return nil
+// findCertificateByMetadata returns the only Certificate of the given ones that matches the given SHA-256 fingerprint,
+// SHA-1 fingerprint or subject, whichever is not empty
+func findCertificateByMetadata(certificates []*govcd.Certificate, sha256Fingerprint, sha1Fingerprint, subject string) (*govcd.Certificate, error) {
+	key, value := "subject", subject
+	if sha256Fingerprint != "" {
+		key, value = "sha256_fingerprint", normalizeCertificateFingerprint(sha256Fingerprint)
+	} else if sha1Fingerprint != "" {
+		key, value = "sha1_fingerprint", normalizeCertificateFingerprint(sha1Fingerprint)
+	}
+
+	var found []*govcd.Certificate
+	for _, certificate := range certificates {
+		parsed, err := parseCertificatePem(certificate.CertificateLibrary.Certificate)
+		if err != nil {
+			// Certificates that can't be parsed can't match
+			continue
+		}
+		actual := getCertificateMetadata(parsed)[key].(string)
+		if key != "subject" {
+			actual = normalizeCertificateFingerprint(actual)
+		}
+		if actual == value {
+			found = append(found, certificate)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%s: could not find any Certificate with %s '%s'", govcd.ErrorEntityNotFound, key, value)
+	}
+	if len(found) > 1 {
+		aliases := make([]string, len(found))
+		for i, certificate := range found {
+			aliases[i] = certificate.CertificateLibrary.Alias
+		}
+		return nil, fmt.Errorf("found %d Certificates with %s '%s': %s", len(found), key, value, strings.Join(aliases, ", "))
+	}
+	return found[0], nil
 }
@@ -79,6 +79,30 @@ func datasourceVcfaContentLibrary() *schema.Resource {
 							Computed:    true,
 							Description: fmt.Sprintf("Subscription url of this %s", labelVcfaContentLibrary),
 						},
+						"need_local_copy": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the contents of all the items are downloaded from the publisher during sync",
+						},
+					},
+				},
+			},
+			"publish_config": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: fmt.Sprintf("A block representing publishing settings of a %s", labelVcfaContentLibrary),
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: fmt.Sprintf("Whether this %s is published", labelVcfaContentLibrary),
+						},
+						"subscription_url": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: fmt.Sprintf("URL that other libraries can use to subscribe to this %s", labelVcfaContentLibrary),
+						},
 					},
 				},
 			},
@@ -0,0 +1,346 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var ovfVirtualSystemSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "ID of the virtual system in the OVF descriptor",
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the virtual system",
+		},
+		"hardware_version": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Virtual hardware family of the virtual system, like 'vmx-19'",
+		},
+		"guest_os_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "CIM identifier of the guest operating system",
+		},
+		"guest_os_type": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "VMware identifier of the guest operating system, like 'ubuntu64Guest'",
+		},
+		"guest_os_description": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Description of the guest operating system",
+		},
+		"cpu_count": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Number of virtual CPUs",
+		},
+		"cores_per_socket": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Number of cores per socket, or 0 if it is not defined",
+		},
+		"memory_mb": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Memory size in MB",
+		},
+		"disks": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Virtual disks of the virtual system",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"disk_id": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "ID of the disk in the OVF descriptor",
+					},
+					"name": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Name of the disk",
+					},
+					"size_mb": {
+						Type:        schema.TypeInt,
+						Computed:    true,
+						Description: "Capacity of the disk in MB",
+					},
+					"file_name": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Name of the file that contains the disk, if any",
+					},
+					"controller_type": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Type of the controller that the disk is attached to, like 'lsilogicsas' or 'ide'",
+					},
+					"unit_number": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Unit number of the disk in its controller",
+					},
+				},
+			},
+		},
+		"network_adapters": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Network adapters of the virtual system",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Name of the network adapter",
+					},
+					"network": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Name of the OVF network that the adapter is connected to",
+					},
+					"adapter_type": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Type of the network adapter, like 'VMXNET3' or 'E1000E'",
+					},
+					"unit_number": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Unit number of the network adapter",
+					},
+				},
+			},
+		},
+	},
+}
+
+var ovfPropertySchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"key": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Key of the property",
+		},
+		"full_key": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Key of the property in the OVF environment, including the class and instance of its product section",
+		},
+		"class": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Class of the product section of the property",
+		},
+		"instance": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Instance of the product section of the property",
+		},
+		"virtual_system_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "ID of the virtual system, or collection, that contains the property",
+		},
+		"product": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Product of the product section of the property",
+		},
+		"category": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Category of the property",
+		},
+		"type": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Type of the property, like 'string', 'boolean' or 'uint16'",
+		},
+		"qualifiers": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Constraints of the property value, like 'MinLen(1)' or 'ValueMap{\"a\",\"b\"}'",
+		},
+		"user_configurable": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether the property can be set when deploying the template",
+		},
+		"password": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether the property value is a password",
+		},
+		"default_value": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Default value of the property. It is empty for passwords, so they are not saved in state",
+		},
+		"label": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Label of the property",
+		},
+		"description": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Description of the property",
+		},
+	},
+}
+
+func datasourceVcfaContentLibraryItemOvf() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceVcfaContentLibraryItemOvfRead,
+		Schema: map[string]*schema.Schema{
+			"content_library_item_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("ID of the %s of type TEMPLATE to inspect", labelVcfaContentLibraryItem),
+			},
+			"descriptor_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the OVF descriptor file",
+			},
+			"networks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Logical networks of the OVF descriptor",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Name of the network",
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Description of the network",
+						},
+					},
+				},
+			},
+			"virtual_systems": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Virtual systems of the OVF descriptor, including the ones inside collections",
+				Elem:        ovfVirtualSystemSchema,
+			},
+			"properties": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Properties of all the ProductSections of the OVF descriptor",
+				Elem:        ovfPropertySchema,
+			},
+			"property_defaults": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Default values of all the ProductSection properties, keyed by 'full_key'. Passwords have an empty value",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func datasourceVcfaContentLibraryItemOvfRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+
+	id := d.Get("content_library_item_id").(string)
+	cli, err := tmClient.GetContentLibraryItemById(id)
+	if err != nil {
+		return diag.Errorf("could not retrieve %s with ID '%s': %s", labelVcfaContentLibraryItem, id, err)
+	}
+	descriptorName, descriptor, err := getContentLibraryItemOvfDescriptor(ctx, tmClient, cli)
+	if err != nil {
+		return diag.Errorf("error retrieving the OVF descriptor of %s '%s': %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name, err)
+	}
+	envelope, err := parseOvfDescriptor(descriptor)
+	if err != nil {
+		return diag.Errorf("error reading the OVF descriptor of %s '%s': %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name, err)
+	}
+
+	networks := make([]interface{}, len(envelope.Networks))
+	for i, network := range envelope.Networks {
+		networks[i] = map[string]interface{}{
+			"name":        network.Name,
+			"description": strings.TrimSpace(network.Description),
+		}
+	}
+
+	systems, sections := getOvfVirtualSystems(envelope)
+	virtualSystems := make([]interface{}, len(systems))
+	for i, system := range systems {
+		virtualSystems[i], err = flattenOvfVirtualSystem(envelope, system)
+		if err != nil {
+			return diag.Errorf("error reading the OVF descriptor of %s '%s': %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name, err)
+		}
+	}
+
+	properties := make([]interface{}, 0)
+	propertyDefaults := map[string]string{}
+	for _, s := range sections {
+		for _, property := range s.section.Properties {
+			fullKey := s.section.fullKey(property)
+			isPassword := strings.EqualFold(property.Password, "true")
+			// Default passwords are not saved in state
+			defaultValue := property.Value
+			if isPassword {
+				defaultValue = ""
+			}
+			properties = append(properties, map[string]interface{}{
+				"key":               property.Key,
+				"full_key":          fullKey,
+				"class":             s.section.Class,
+				"instance":          s.section.Instance,
+				"virtual_system_id": s.virtualSystemId,
+				"product":           strings.TrimSpace(s.section.Product),
+				"category":          property.Category,
+				"type":              property.Type,
+				"qualifiers":        property.Qualifiers,
+				"user_configurable": strings.EqualFold(property.UserConfigurable, "true"),
+				"password":          isPassword,
+				"default_value":     defaultValue,
+				"label":             strings.TrimSpace(property.Label),
+				"description":       strings.TrimSpace(property.Description),
+			})
+			propertyDefaults[fullKey] = defaultValue
+		}
+	}
+
+	dSet(d, "descriptor_name", descriptorName)
+	if err = d.Set("networks", networks); err != nil {
+		return diag.Errorf("error setting networks: %s", err)
+	}
+	if err = d.Set("virtual_systems", virtualSystems); err != nil {
+		return diag.Errorf("error setting virtual_systems: %s", err)
+	}
+	if err = d.Set("properties", properties); err != nil {
+		return diag.Errorf("error setting properties: %s", err)
+	}
+	if err = d.Set("property_defaults", propertyDefaults); err != nil {
+		return diag.Errorf("error setting property_defaults: %s", err)
+	}
+	d.SetId(cli.ContentLibraryItem.ID)
+	return nil
+}
@@ -9,24 +9,73 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vmware/go-vcloud-director/v3/ccitypes"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/yaml"
 )
 
+var kubeconfigSupervisorNamespaceSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"project_name": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: fmt.Sprintf("The name of the Project where the %s belongs to", labelSupervisorNamespace),
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: fmt.Sprintf("The name of the %s", labelSupervisorNamespace),
+		},
+	},
+}
+
+var kubeconfigContextSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the context",
+		},
+		"cluster_name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the cluster referenced by the context",
+		},
+		"host": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Hostname of the Kubernetes cluster referenced by the context",
+		},
+		"project_name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("The name of the Project where the %s belongs to", labelSupervisorNamespace),
+		},
+		"supervisor_namespace_name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("The name of the %s of the context", labelSupervisorNamespace),
+		},
+	},
+}
+
 func datasourceVcfaKubeConfig() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: datasourceVcfaKubeConfigRead,
 		Schema: map[string]*schema.Schema{
 			"project_name": {
-				Type:         schema.TypeString,
-				Optional:     true,
-				Description:  fmt.Sprintf("The name of the Project where the %s belongs to", labelSupervisorNamespace),
-				RequiredWith: []string{"supervisor_namespace_name"},
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   fmt.Sprintf("The name of the Project where the %s belongs to", labelSupervisorNamespace),
+				RequiredWith:  []string{"supervisor_namespace_name"},
+				ConflictsWith: []string{"supervisor_namespace", "all_supervisor_namespaces_project_name"},
 			},
 			"supervisor_namespace_name": {
 				Type:         schema.TypeString,
@@ -34,6 +83,54 @@ func datasourceVcfaKubeConfig() *schema.Resource {
 				Description:  fmt.Sprintf("The name of the %s to retrieve the kubeconfig for", labelSupervisorNamespace),
 				RequiredWith: []string{"project_name"},
 			},
+			"supervisor_namespace": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Description:   fmt.Sprintf("A list of %ss to retrieve the kubeconfig for. A context is generated for each of them", labelSupervisorNamespace),
+				Elem:          kubeconfigSupervisorNamespaceSchema,
+				ConflictsWith: []string{"all_supervisor_namespaces_project_name"},
+			},
+			"all_supervisor_namespaces_project_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: fmt.Sprintf("The name of a Project. A context is generated for each ready %s of the Project", labelSupervisorNamespace),
+			},
+			"current_context": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the context that is set as current context in the kubeconfig. Defaults to the first generated context",
+			},
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      kubeconfigModeToken,
+				Description:  "How the kubeconfig authenticates. 'token' embeds the current bearer token, 'exec' uses the provider binary as credential plugin to obtain fresh tokens",
+				ValidateFunc: validation.StringInSlice([]string{kubeconfigModeToken, kubeconfigModeExec}, false),
+			},
+			"exec_command": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "terraform-provider-vcfa",
+				Description: "Command that runs the provider binary, used when 'mode' is 'exec'. It can be a full path or a binary available in PATH",
+			},
+			"exec_api_token_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "File containing an API token, used by the credential plugin when 'mode' is 'exec'. Defaults to the 'api_token_file' of the provider",
+				ConflictsWith: []string{"exec_service_account_token_file"},
+			},
+			"exec_service_account_token_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "File containing a Service Account token, used by the credential plugin when 'mode' is 'exec'. Defaults to the 'service_account_token_file' of the provider",
+			},
+			"min_remaining_validity": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Description:      "Minimum validity that the token must have, as a duration like '30m' or '2h'. If the session token expires before, the provider re-authenticates to get a new one",
+				ValidateDiagFunc: IsPositiveDuration(),
+			},
 			"host": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -55,114 +152,364 @@ func datasourceVcfaKubeConfig() *schema.Resource {
 				Computed:    true,
 				Description: "Bearer token username",
 			},
+			"issued_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Date and time when the bearer token was issued, in RFC3339 format",
+			},
+			"expires_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Date and time when the bearer token expires, in RFC3339 format",
+			},
 			"context_name": {
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "Name of the generated context",
 			},
+			"contexts": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "All the contexts generated in the kubeconfig",
+				Elem:        kubeconfigContextSchema,
+			},
 			"kube_config_raw": {
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "Raw kubeconfig",
 				Sensitive:   true,
 			},
+			"kube_config_raw_yaml": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Raw kubeconfig in YAML format",
+				Sensitive:   true,
+			},
 		},
 	}
 }
 
+// kubeconfigTarget contains the information needed to generate a cluster and a context in a kubeconfig
+type kubeconfigTarget struct {
+	clusterName             string
+	clusterServer           string
+	contextName             string
+	projectName             string
+	supervisorNamespaceName string
+}
+
 func datasourceVcfaKubeConfigRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	tmClient := meta.(ClientContainer).tmClient
 
-	clusterName := fmt.Sprintf("%s:%s", tmClient.Org, tmClient.Client.VCDHREF.Host)
-	clusterServer := fmt.Sprintf(ccitypes.KubernetesSubpath, tmClient.Client.VCDHREF.Scheme, tmClient.Client.VCDHREF.Host)
-	contextName := tmClient.Org
+	targets, err := getKubeconfigTargets(tmClient, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	projectName, okProjectName := d.GetOk("project_name")
-	supervisorNamespaceName, okSupervisorNamespace := d.GetOk("supervisor_namespace_name")
-	if okProjectName && okSupervisorNamespace {
-		supervisorNamespace, err := readSupervisorNamespace(tmClient, projectName.(string), supervisorNamespaceName.(string))
-		if err != nil {
-			return diag.Errorf("error reading %s: %s", labelSupervisorNamespace, err)
-		}
-		readyStatus := false
-		for _, condition := range supervisorNamespace.Status.Conditions {
-			if strings.ToLower(condition.Type) == "ready" {
-				if strings.ToLower(condition.Status) == "true" {
-					readyStatus = true
-				}
+	currentContext := targets[0].contextName
+	currentTarget := targets[0]
+	if requestedContext, ok := d.GetOk("current_context"); ok {
+		found := false
+		for _, target := range targets {
+			if target.contextName == requestedContext.(string) {
+				currentContext = target.contextName
+				currentTarget = target
+				found = true
 				break
 			}
 		}
-		if !readyStatus {
-			return diag.Errorf("%s %s is not in a ready status", labelSupervisorNamespace, supervisorNamespaceName)
+		if !found {
+			return diag.Errorf("current_context '%s' is not one of the generated contexts", requestedContext)
 		}
-		if supervisorNamespace.Status.NamespaceEndpointURL == "" {
-			return diag.Errorf("unable to retrieve the endpoint URL for %s %s", labelSupervisorNamespace, supervisorNamespaceName)
-		}
-		clusterName = fmt.Sprintf("%s:%s@%s", tmClient.Org, supervisorNamespaceName.(string), tmClient.Client.VCDHREF.Host)
-		clusterServer = supervisorNamespace.Status.NamespaceEndpointURL
-		contextName = fmt.Sprintf("%s:%s:%s", tmClient.Org, supervisorNamespaceName.(string), projectName.(string))
 	}
 
-	token, _, err := new(jwt.Parser).ParseUnverified(tmClient.Client.VCDToken, jwt.MapClaims{})
+	token, claims, err := parseSessionToken(tmClient)
 	if err != nil {
-		return diag.Errorf("error parsing JWT token: %s", err)
+		return diag.FromErr(err)
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return diag.FromErr(errors.New("could not parse claims from JWT token"))
+	// In exec mode the token is not stored in the kubeconfig, so its validity does not matter
+	mode := d.Get("mode").(string)
+
+	var diags diag.Diagnostics
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return diag.Errorf("could not parse expiration time from JWT token claims: %s", err)
+	}
+	if minRemainingValidity, ok := d.GetOk("min_remaining_validity"); ok && expiresAt != nil && mode == kubeconfigModeToken {
+		// validated by the schema
+		minValidity, _ := time.ParseDuration(minRemainingValidity.(string))
+		if time.Until(expiresAt.Time) < minValidity {
+			token, claims, expiresAt, err = renewSessionToken(tmClient)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			if expiresAt != nil && time.Until(expiresAt.Time) < minValidity {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  "The kubeconfig token is valid for less time than requested",
+					Detail: fmt.Sprintf("A new token was obtained, but it expires at %s, which is earlier than the requested "+
+						"'min_remaining_validity' of %s. The maximum session duration is configured in VCFA", expiresAt.Format(time.RFC3339), minValidity),
+				})
+			}
+		}
 	}
+	if expiresAt != nil && time.Until(expiresAt.Time) < kubeconfigTokenExpiryWarning && mode == kubeconfigModeToken {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "The kubeconfig token expires soon",
+			Detail: fmt.Sprintf("The token of the generated kubeconfig expires at %s. Any stored kubeconfig will stop working after that. "+
+				"Use 'min_remaining_validity' to obtain a fresh token", expiresAt.Format(time.RFC3339)),
+		})
+	}
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil {
+		return diag.Errorf("could not parse issue time from JWT token claims: %s", err)
+	}
+
 	preferredUsername, ok := claims["preferred_username"].(string)
 	if !ok {
 		return diag.FromErr(errors.New("could not parse preferred username from JWT token claims"))
 	}
 	username := fmt.Sprintf("%s:%s@%s", tmClient.Org, preferredUsername, tmClient.Client.VCDHREF.Host)
 
+	authInfo := clientcmdapi.AuthInfo{
+		Token: token.Raw,
+	}
+	if mode == kubeconfigModeExec {
+		execConfig, err := getKubeconfigExecConfig(tmClient, d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		authInfo = clientcmdapi.AuthInfo{
+			Exec: execConfig,
+		}
+	}
+
 	kubeconfig := &clientcmdapi.Config{
 		Kind:       "Config",
 		APIVersion: clientcmdapi.SchemeGroupVersion.Version,
-		Clusters: []clientcmdapi.NamedCluster{{
-			Name: clusterName,
-			Cluster: clientcmdapi.Cluster{
-				InsecureSkipTLSVerify: tmClient.InsecureFlag,
-				Server:                clusterServer,
-			},
-		}},
-		Contexts: []clientcmdapi.NamedContext{
-			{
-				Name: contextName,
-				Context: clientcmdapi.Context{
-					Cluster:  clusterName,
-					AuthInfo: username,
-				},
-			},
-		},
+		// All the contexts share the same user, as they are accessed with the same credentials
 		AuthInfos: []clientcmdapi.NamedAuthInfo{
 			{
-				Name: username,
-				AuthInfo: clientcmdapi.AuthInfo{
-					Token: token.Raw,
-				},
+				Name:     username,
+				AuthInfo: authInfo,
 			},
 		},
-		CurrentContext: contextName,
+		CurrentContext: currentContext,
 	}
-	if okProjectName && okSupervisorNamespace {
-		kubeconfig.Contexts[0].Context.Namespace = supervisorNamespaceName.(string)
+	contexts := make([]interface{}, 0, len(targets))
+	for _, target := range targets {
+		kubeconfig.Clusters = append(kubeconfig.Clusters, clientcmdapi.NamedCluster{
+			Name: target.clusterName,
+			Cluster: clientcmdapi.Cluster{
+				InsecureSkipTLSVerify: tmClient.InsecureFlag,
+				Server:                target.clusterServer,
+			},
+		})
+		kubeconfig.Contexts = append(kubeconfig.Contexts, clientcmdapi.NamedContext{
+			Name: target.contextName,
+			Context: clientcmdapi.Context{
+				Cluster:   target.clusterName,
+				AuthInfo:  username,
+				Namespace: target.supervisorNamespaceName,
+			},
+		})
+		contexts = append(contexts, map[string]interface{}{
+			"name":                      target.contextName,
+			"cluster_name":              target.clusterName,
+			"host":                      target.clusterServer,
+			"project_name":              target.projectName,
+			"supervisor_namespace_name": target.supervisorNamespaceName,
+		})
 	}
 
 	kubeconfigBytes, err := json.MarshalIndent(kubeconfig, "", "  ")
 	if err != nil {
 		return diag.Errorf("error marshaling kubeconfig: %s", err)
 	}
+	kubeconfigYamlBytes, err := yaml.JSONToYAML(kubeconfigBytes)
+	if err != nil {
+		return diag.Errorf("error converting kubeconfig to YAML: %s", err)
+	}
 
-	d.SetId(contextName)
-	dSet(d, "host", clusterServer)
+	d.SetId(currentContext)
+	dSet(d, "host", currentTarget.clusterServer)
 	dSet(d, "insecure_skip_tls_verify", tmClient.InsecureFlag)
-	dSet(d, "token", token.Raw)
 	dSet(d, "user", username)
-	dSet(d, "context_name", contextName)
+	if mode == kubeconfigModeToken {
+		dSet(d, "token", token.Raw)
+		dSet(d, "issued_at", formatNumericDate(issuedAt))
+		dSet(d, "expires_at", formatNumericDate(expiresAt))
+	} else {
+		dSet(d, "token", "")
+		dSet(d, "issued_at", "")
+		dSet(d, "expires_at", "")
+	}
+	dSet(d, "context_name", currentContext)
+	dSet(d, "current_context", currentContext)
+	if err := d.Set("contexts", contexts); err != nil {
+		return diag.Errorf("error setting contexts: %s", err)
+	}
 	dSet(d, "kube_config_raw", string(kubeconfigBytes))
+	dSet(d, "kube_config_raw_yaml", string(kubeconfigYamlBytes))
 
-	return nil
+	return diags
+}
+
+// getKubeconfigTargets returns the clusters and contexts to generate, depending on the arguments of the
+// data source. When no Supervisor Namespace is requested, a single context for the Organization is returned
+func getKubeconfigTargets(tmClient *VCDClient, d *schema.ResourceData) ([]kubeconfigTarget, error) {
+	type namespaceReference struct {
+		projectName string
+		name        string
+	}
+	var namespaces []namespaceReference
+
+	projectName, okProjectName := d.GetOk("project_name")
+	supervisorNamespaceName, okSupervisorNamespace := d.GetOk("supervisor_namespace_name")
+	if okProjectName && okSupervisorNamespace {
+		namespaces = append(namespaces, namespaceReference{projectName: projectName.(string), name: supervisorNamespaceName.(string)})
+	}
+	for _, sn := range d.Get("supervisor_namespace").([]interface{}) {
+		supervisorNamespace := sn.(map[string]interface{})
+		namespaces = append(namespaces, namespaceReference{projectName: supervisorNamespace["project_name"].(string), name: supervisorNamespace["name"].(string)})
+	}
+
+	var targets []kubeconfigTarget
+	if allProjectName, ok := d.GetOk("all_supervisor_namespaces_project_name"); ok {
+		supervisorNamespaces, err := listSupervisorNamespaces(tmClient, allProjectName.(string))
+		if err != nil {
+			return nil, err
+		}
+		// Sorting makes the generated kubeconfig stable between reads
+		sort.SliceStable(supervisorNamespaces, func(i, j int) bool {
+			return supervisorNamespaces[i].Name < supervisorNamespaces[j].Name
+		})
+		for _, supervisorNamespace := range supervisorNamespaces {
+			if !isSupervisorNamespaceReady(supervisorNamespace) || supervisorNamespace.Status.NamespaceEndpointURL == "" {
+				continue
+			}
+			targets = append(targets, newKubeconfigSupervisorNamespaceTarget(tmClient, allProjectName.(string), supervisorNamespace.Name, supervisorNamespace.Status.NamespaceEndpointURL))
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("there are no ready %ss in Project %s", labelSupervisorNamespace, allProjectName)
+		}
+		return targets, nil
+	}
+
+	for _, namespace := range namespaces {
+		namespaceEndpoint, err := getSupervisorNamespaceEndpoint(tmClient, namespace.projectName, namespace.name)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, newKubeconfigSupervisorNamespaceTarget(tmClient, namespace.projectName, namespace.name, namespaceEndpoint))
+	}
+
+	if len(targets) == 0 {
+		targets = append(targets, kubeconfigTarget{
+			clusterName:   fmt.Sprintf("%s:%s", tmClient.Org, tmClient.Client.VCDHREF.Host),
+			clusterServer: fmt.Sprintf(ccitypes.KubernetesSubpath, tmClient.Client.VCDHREF.Scheme, tmClient.Client.VCDHREF.Host),
+			contextName:   tmClient.Org,
+		})
+	}
+	return targets, nil
+}
+
+func newKubeconfigSupervisorNamespaceTarget(tmClient *VCDClient, projectName, supervisorNamespaceName, namespaceEndpoint string) kubeconfigTarget {
+	return kubeconfigTarget{
+		clusterName:             fmt.Sprintf("%s:%s@%s", tmClient.Org, supervisorNamespaceName, tmClient.Client.VCDHREF.Host),
+		clusterServer:           namespaceEndpoint,
+		contextName:             fmt.Sprintf("%s:%s:%s", tmClient.Org, supervisorNamespaceName, projectName),
+		projectName:             projectName,
+		supervisorNamespaceName: supervisorNamespaceName,
+	}
+}
+
+const (
+	kubeconfigModeToken = "token"
+	kubeconfigModeExec  = "exec"
+)
+
+// getKubeconfigExecConfig returns the exec credential plugin configuration that runs the credential helper of
+// the provider binary. The credentials file defaults to the one used by the provider
+func getKubeconfigExecConfig(tmClient *VCDClient, d *schema.ResourceData) (*clientcmdapi.ExecConfig, error) {
+	apiTokenFile := d.Get("exec_api_token_file").(string)
+	saTokenFile := d.Get("exec_service_account_token_file").(string)
+	if apiTokenFile == "" && saTokenFile == "" && tmClient.authConfig != nil {
+		apiTokenFile = tmClient.authConfig.ApiTokenFile
+		saTokenFile = tmClient.authConfig.ServiceAccountTokenFile
+	}
+
+	args := []string{
+		CredentialHelperCommand,
+		"--url", fmt.Sprintf("%s://%s", tmClient.Client.VCDHREF.Scheme, tmClient.Client.VCDHREF.Host),
+		"--org", tmClient.SysOrg,
+	}
+	switch {
+	case apiTokenFile != "":
+		args = append(args, "--api-token-file", apiTokenFile)
+	case saTokenFile != "":
+		args = append(args, "--service-account-token-file", saTokenFile)
+	default:
+		return nil, fmt.Errorf("'mode' = '%s' requires an API token file or a Service Account token file, "+
+			"either in 'exec_api_token_file', 'exec_service_account_token_file' or in the provider configuration", kubeconfigModeExec)
+	}
+	if tmClient.InsecureFlag {
+		args = append(args, "--insecure")
+	}
+
+	return &clientcmdapi.ExecConfig{
+		Command:         d.Get("exec_command").(string),
+		Args:            args,
+		APIVersion:      execCredentialAPIVersion,
+		InstallHint:     "The VCFA Terraform provider binary is required to obtain credentials. Add it to the PATH or set 'exec_command' to its full path",
+		InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+	}, nil
+}
+
+// kubeconfigTokenExpiryWarning is the remaining validity of a token below which a warning is shown
+const kubeconfigTokenExpiryWarning = 15 * time.Minute
+
+// parseSessionToken parses the JWT session token of the given client, without verifying its signature
+func parseSessionToken(tmClient *VCDClient) (*jwt.Token, jwt.MapClaims, error) {
+	return parseJwtToken(tmClient.Client.VCDToken)
+}
+
+// parseJwtToken parses the given JWT token, without verifying its signature
+func parseJwtToken(rawToken string) (*jwt.Token, jwt.MapClaims, error) {
+	token, _, err := new(jwt.Parser).ParseUnverified(rawToken, jwt.MapClaims{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing JWT token: %s", err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, nil, errors.New("could not parse claims from JWT token")
+	}
+	return token, claims, nil
+}
+
+// renewSessionToken obtains a new session token for the kubeconfig, with the credentials of the given client. The
+// session of the client itself is not modified
+func renewSessionToken(tmClient *VCDClient) (*jwt.Token, jwt.MapClaims, *jwt.NumericDate, error) {
+	rawToken, err := tmClient.newSessionToken()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error renewing the session token: %s", err)
+	}
+	token, claims, err := parseJwtToken(rawToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not parse expiration time from JWT token claims: %s", err)
+	}
+	return token, claims, expiresAt, nil
+}
+
+// formatNumericDate returns the given JWT date in RFC3339 format, or an empty string if it is not set
+func formatNumericDate(date *jwt.NumericDate) string {
+	if date == nil {
+		return ""
+	}
+	return date.UTC().Format(time.RFC3339)
 }
@@ -9,6 +9,7 @@ package vcfa
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -51,6 +52,16 @@ func TestAccVcfaKubeConfig(t *testing.T) {
 					resource.TestCheckResourceAttr("data.vcfa_kubeconfig.test", "user", fmt.Sprintf("%s:%s@%s", testConfig.Org.Name, testConfig.Org.User, ref.Host)),
 					resource.TestCheckResourceAttrSet("data.vcfa_kubeconfig.test", "token"),
 					resource.TestCheckResourceAttrSet("data.vcfa_kubeconfig.test", "kube_config_raw"),
+					resource.TestCheckResourceAttrSet("data.vcfa_kubeconfig.test", "kube_config_raw_yaml"),
+					resource.TestCheckResourceAttrSet("data.vcfa_kubeconfig.test", "issued_at"),
+					resource.TestCheckResourceAttrSet("data.vcfa_kubeconfig.test", "expires_at"),
+					resource.TestCheckResourceAttrSet("data.vcfa_kubeconfig.test-validity", "expires_at"),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-validity", "user", "data.vcfa_kubeconfig.test", "user"),
+					resource.TestCheckResourceAttr("data.vcfa_kubeconfig.test-exec", "token", ""),
+					resource.TestCheckResourceAttr("data.vcfa_kubeconfig.test-exec", "expires_at", ""),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-exec", "user", "data.vcfa_kubeconfig.test", "user"),
+					resource.TestMatchResourceAttr("data.vcfa_kubeconfig.test-exec", "kube_config_raw", regexp.MustCompile(CredentialHelperCommand)),
+					resource.TestMatchResourceAttr("data.vcfa_kubeconfig.test-exec", "kube_config_raw", regexp.MustCompile(`/tmp/vcfa-api-token\.json`)),
 				),
 			},
 		},
@@ -59,4 +70,13 @@ func TestAccVcfaKubeConfig(t *testing.T) {
 
 const testAccVcfaKubeConfigStep1 = `
 data "vcfa_kubeconfig" "test" {}
+
+data "vcfa_kubeconfig" "test-validity" {
+  min_remaining_validity = "1m"
+}
+
+data "vcfa_kubeconfig" "test-exec" {
+  mode                = "exec"
+  exec_api_token_file = "/tmp/vcfa-api-token.json"
+}
 `
@@ -0,0 +1,194 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const labelVirtualMachineImage = "Virtual Machine Image"
+
+var supervisorNamespaceImageSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("Name of the %s, as used in the 'image_name' of Virtual Machines. It is the Virtual Machine Identifier (VMI) of the %s", labelVirtualMachineImage, labelVcfaContentLibraryItem),
+		},
+		"display_name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("Display name of the %s, which is the name of the %s", labelVirtualMachineImage, labelVcfaContentLibraryItem),
+		},
+		"type": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("Type of the %s, like 'OVF' or 'ISO'", labelVirtualMachineImage),
+		},
+		"os_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Guest operating system identifier",
+		},
+		"os_type": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Guest operating system type",
+		},
+		"os_version": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Guest operating system version",
+		},
+		"product_version": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Version of the product contained in the image",
+		},
+		"content_library_item_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("ID of the source %s in vCenter", labelVcfaContentLibraryItem),
+		},
+		"content_version": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("Content version of the source %s", labelVcfaContentLibraryItem),
+		},
+		"ready": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: fmt.Sprintf("Whether the %s is ready to be used", labelVirtualMachineImage),
+		},
+	},
+}
+
+func datasourceVcfaSupervisorNamespaceImages() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceVcfaSupervisorNamespaceImagesRead,
+		Schema: map[string]*schema.Schema{
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("The name of the Project the %s belongs to", labelSupervisorNamespace),
+			},
+			"supervisor_namespace_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("The name of the %s to list the %ss from", labelSupervisorNamespace, labelVirtualMachineImage),
+			},
+			"name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   fmt.Sprintf("Only list the %ss whose name or display name is equal to this value", labelVirtualMachineImage),
+				ConflictsWith: []string{"name_regex"},
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  fmt.Sprintf("Only list the %ss whose display name matches this regular expression", labelVirtualMachineImage),
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"ready_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: fmt.Sprintf("Only list the %ss that are ready to be used", labelVirtualMachineImage),
+			},
+			"images": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: fmt.Sprintf("List of %ss, sorted by display name", labelVirtualMachineImage),
+				Elem:        supervisorNamespaceImageSchema,
+			},
+		},
+	}
+}
+
+func datasourceVcfaSupervisorNamespaceImagesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName := d.Get("project_name").(string)
+	supervisorNamespaceName := d.Get("supervisor_namespace_name").(string)
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	images, err := listVirtualMachineImages(tmClient, endpoint, supervisorNamespaceName)
+	if err != nil {
+		return diag.Errorf("error listing %ss in %s %s: %s", labelVirtualMachineImage, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	var nameRegex *regexp.Regexp
+	if rawRegex, ok := d.GetOk("name_regex"); ok {
+		nameRegex, err = regexp.Compile(rawRegex.(string))
+		if err != nil {
+			return diag.Errorf("error compiling 'name_regex': %s", err)
+		}
+	}
+	name := d.Get("name").(string)
+	readyOnly := d.Get("ready_only").(bool)
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].Status.Name < images[j].Status.Name
+	})
+
+	result := make([]interface{}, 0, len(images))
+	for _, image := range images {
+		ready := isCciConditionTrue(image.Status.Conditions, "Ready")
+		if readyOnly && !ready {
+			continue
+		}
+		if name != "" && image.Name != name && image.Status.Name != name {
+			continue
+		}
+		if nameRegex != nil && !nameRegex.MatchString(image.Status.Name) {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"name":                    image.Name,
+			"display_name":            image.Status.Name,
+			"type":                    image.Status.Type,
+			"os_id":                   image.Status.OSInfo.ID,
+			"os_type":                 image.Status.OSInfo.Type,
+			"os_version":              image.Status.OSInfo.Version,
+			"product_version":         image.Status.ProductInfo.Version,
+			"content_library_item_id": image.Status.ProviderItemID,
+			"content_version":         image.Status.ProviderContentVersion,
+			"ready":                   ready,
+		})
+	}
+
+	d.SetId(buildResourceId(projectName, supervisorNamespaceName))
+	if err := d.Set("images", result); err != nil {
+		return diag.Errorf("error setting images: %s", err)
+	}
+
+	return nil
+}
+
+// listVirtualMachineImages retrieves the VM Service images that are available in the given Supervisor Namespace
+func listVirtualMachineImages(tmClient *VCDClient, endpoint, supervisorNamespaceName string) ([]cciVirtualMachineImage, error) {
+	imagesURL, err := url.ParseRequestURI(strings.TrimSuffix(endpoint, "/") + fmt.Sprintf(cciVirtualMachineImagesPath, url.PathEscape(supervisorNamespaceName)))
+	if err != nil {
+		return nil, fmt.Errorf("error building %s URL: %s", labelVirtualMachineImage, err)
+	}
+	var images cciVirtualMachineImageList
+	if err := performCciRequest(tmClient, http.MethodGet, imagesURL, "application/json", nil, &images); err != nil {
+		return nil, err
+	}
+	return images.Items, nil
+}
@@ -0,0 +1,84 @@
+//go:build cci || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccVcfaSupervisorNamespaceImagesDS(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfSysAdmin(t)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	var params = StringMap{
+		"Testname":           t.Name(),
+		"ProjectName":        "tf-project-images",
+		"RegionName":         testConfig.Cci.Region,
+		"VpcName":            testConfig.Cci.Vpc,
+		"StorageClassName":   testConfig.Cci.StoragePolicy,
+		"SupervisorZoneName": testConfig.Cci.SupervisorZone,
+		"VmImage":            testConfig.Cci.VmImage,
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	// Setup project and defer cleanup
+	cleanup := setupProject(t, params["ProjectName"].(string))
+	defer cleanup()
+
+	configText1 := templateFill(testAccVcfaSupervisorNamespaceImagesDSStep1, params)
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.vcfa_supervisor_namespace_images.by-name", "id", "vcfa_supervisor_namespace.test", "id"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_images.by-name", "images.#", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_images.by-name", "images.0.ready", "true"),
+					resource.TestCheckResourceAttrSet("data.vcfa_supervisor_namespace_images.by-name", "images.0.display_name"),
+					resource.TestCheckResourceAttrSet("data.vcfa_supervisor_namespace_images.by-name", "images.0.os_type"),
+					resource.TestCheckResourceAttrSet("data.vcfa_supervisor_namespace_images.by-name", "images.0.content_library_item_id"),
+					resource.TestMatchResourceAttr("data.vcfa_supervisor_namespace_images.all", "images.#", regexp.MustCompile(`^[1-9][0-9]*$`)),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_images.none", "images.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+const testAccVcfaSupervisorNamespaceImagesDSStep1 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+data "vcfa_supervisor_namespace_images" "by-name" {
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  name                      = "{{.VmImage}}"
+}
+
+data "vcfa_supervisor_namespace_images" "all" {
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  ready_only                = true
+}
+
+data "vcfa_supervisor_namespace_images" "none" {
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  name_regex                = "^this-image-does-not-exist-[0-9]+$"
+}
+`
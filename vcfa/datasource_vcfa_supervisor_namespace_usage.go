@@ -0,0 +1,316 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const labelSupervisorNamespaceUsage = "Supervisor Namespace Usage"
+
+const (
+	bytesInMebibyte  = 1 << 20
+	hertzInMegahertz = 1000 * 1000
+)
+
+var supervisorNamespaceUsageZoneSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the Zone",
+		},
+		"cpu_limit_mhz": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "CPU limit of the Zone, in MHz",
+		},
+		"cpu_reservation_mhz": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "CPU reservation of the Zone, in MHz",
+		},
+		"cpu_quota_millicores": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "CPU that workloads of the Zone can request, in millicores. 0 if unlimited",
+		},
+		"cpu_used_millicores": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "CPU requested by the workloads of the Zone, in millicores",
+		},
+		"cpu_used_percent": {
+			Type:        schema.TypeFloat,
+			Computed:    true,
+			Description: "Percentage of 'cpu_quota_millicores', the ResourceQuota hard limit, that is used. 0 if unlimited",
+		},
+		"memory_limit_mib": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Memory limit of the Zone, in MiB",
+		},
+		"memory_reservation_mib": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Memory reservation of the Zone, in MiB",
+		},
+		"memory_quota_mib": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Memory that workloads of the Zone can request, in MiB. 0 if unlimited",
+		},
+		"memory_used_mib": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Memory requested by the workloads of the Zone, in MiB",
+		},
+		"memory_used_percent": {
+			Type:        schema.TypeFloat,
+			Computed:    true,
+			Description: "Percentage of 'memory_quota_mib', the ResourceQuota hard limit, that is used. 0 if unlimited",
+		},
+		"usage_reported": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether the Supervisor Namespace reports the consumption of this Zone",
+		},
+	},
+}
+
+var supervisorNamespaceUsageStorageClassSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the Storage Class",
+		},
+		"limit_mib": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Storage limit of the Storage Class, in MiB",
+		},
+		"used_mib": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Storage requested by the volumes of the Storage Class, in MiB",
+		},
+		"used_percent": {
+			Type:        schema.TypeFloat,
+			Computed:    true,
+			Description: "Percentage of 'limit_mib' that is used. 0 if unlimited",
+		},
+	},
+}
+
+func datasourceVcfaSupervisorNamespaceUsage() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceVcfaSupervisorNamespaceUsageRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("Name of the %s", labelSupervisorNamespace),
+			},
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("The name of the Project the %s belongs to", labelSupervisorNamespace),
+			},
+			"zone": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "CPU and memory limits and consumption of each Zone",
+				Elem:        supervisorNamespaceUsageZoneSchema,
+			},
+			"storage_class": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Storage limits and consumption of each Storage Class",
+				Elem:        supervisorNamespaceUsageStorageClassSchema,
+			},
+		},
+	}
+}
+
+func datasourceVcfaSupervisorNamespaceUsageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName := d.Get("project_name").(string)
+	name := d.Get("name").(string)
+
+	supervisorNamespace, err := readSupervisorNamespace(tmClient, projectName, name)
+	if err != nil {
+		return diag.Errorf("error reading %s: %s", labelSupervisorNamespace, err)
+	}
+	if !isSupervisorNamespaceReady(supervisorNamespace) || supervisorNamespace.Status.NamespaceEndpointURL == "" {
+		return diag.Errorf("%s %s is not in a ready status", labelSupervisorNamespace, name)
+	}
+
+	quotas, err := listResourceQuotas(tmClient, supervisorNamespace.Status.NamespaceEndpointURL, name)
+	if err != nil {
+		return diag.Errorf("error reading %s of %s %s: %s", labelSupervisorNamespaceUsage, labelSupervisorNamespace, name, err)
+	}
+
+	// Quotas that are not bound to a Zone apply to the whole Supervisor Namespace
+	var namespaceQuotas []cciResourceQuota
+	for _, quota := range quotas {
+		if quota.Labels[cciZoneLabel] == "" {
+			namespaceQuotas = append(namespaceQuotas, quota)
+		}
+	}
+
+	zones := make([]interface{}, 0, len(supervisorNamespace.Status.Zones))
+	for _, zone := range supervisorNamespace.Status.Zones {
+		var zoneQuotas []cciResourceQuota
+		for _, quota := range quotas {
+			if quota.Labels[cciZoneLabel] == zone.Name {
+				zoneQuotas = append(zoneQuotas, quota)
+			}
+		}
+		// When there is a single Zone, the consumption of the Supervisor Namespace is the consumption of the Zone
+		if len(zoneQuotas) == 0 && len(supervisorNamespace.Status.Zones) == 1 {
+			zoneQuotas = namespaceQuotas
+		}
+
+		cpuLimit, err := parseQuantityAttribute(zone.CpuLimit, "cpuLimit")
+		if err != nil {
+			return diag.Errorf("error parsing limits of Zone %s: %s", zone.Name, err)
+		}
+		cpuReservation, err := parseQuantityAttribute(zone.CpuReservation, "cpuReservation")
+		if err != nil {
+			return diag.Errorf("error parsing limits of Zone %s: %s", zone.Name, err)
+		}
+		memoryLimit, err := parseQuantityAttribute(zone.MemoryLimit, "memoryLimit")
+		if err != nil {
+			return diag.Errorf("error parsing limits of Zone %s: %s", zone.Name, err)
+		}
+		memoryReservation, err := parseQuantityAttribute(zone.MemoryReservation, "memoryReservation")
+		if err != nil {
+			return diag.Errorf("error parsing limits of Zone %s: %s", zone.Name, err)
+		}
+
+		// Both percentages are measured against the ResourceQuota hard limits, as those are the ones that workloads hit
+		cpuQuota, _ := getResourceQuotaHard(zoneQuotas, "limits.cpu")
+		memoryQuota, _ := getResourceQuotaHard(zoneQuotas, "limits.memory")
+		cpuUsed, cpuReported := getResourceQuotaUsed(zoneQuotas, "limits.cpu")
+		memoryUsed, memoryReported := getResourceQuotaUsed(zoneQuotas, "limits.memory")
+
+		zones = append(zones, map[string]interface{}{
+			"name":                   zone.Name,
+			"cpu_limit_mhz":          int(cpuLimit.Value() / hertzInMegahertz),
+			"cpu_reservation_mhz":    int(cpuReservation.Value() / hertzInMegahertz),
+			"cpu_quota_millicores":   int(cpuQuota.MilliValue()),
+			"cpu_used_millicores":    int(cpuUsed.MilliValue()),
+			"cpu_used_percent":       getUsedPercent(cpuUsed.MilliValue(), cpuQuota.MilliValue()),
+			"memory_limit_mib":       int(memoryLimit.Value() / bytesInMebibyte),
+			"memory_reservation_mib": int(memoryReservation.Value() / bytesInMebibyte),
+			"memory_quota_mib":       int(memoryQuota.Value() / bytesInMebibyte),
+			"memory_used_mib":        int(memoryUsed.Value() / bytesInMebibyte),
+			"memory_used_percent":    getUsedPercent(memoryUsed.Value(), memoryQuota.Value()),
+			"usage_reported":         cpuReported || memoryReported,
+		})
+	}
+
+	storageClasses := make([]interface{}, 0, len(supervisorNamespace.Status.StorageClasses))
+	for _, storageClass := range supervisorNamespace.Status.StorageClasses {
+		limit, err := parseQuantityAttribute(storageClass.Limit, "limit")
+		if err != nil {
+			return diag.Errorf("error parsing limit of Storage Class %s: %s", storageClass.Name, err)
+		}
+		quotaKey := storageClass.Name + cciStorageClassQuotaSuffix
+		// The limit enforced by the quota takes precedence, as it is the one that workloads hit
+		if hard, ok := getResourceQuotaHard(namespaceQuotas, quotaKey); ok {
+			limit = hard
+		}
+		used, _ := getResourceQuotaUsed(namespaceQuotas, quotaKey)
+
+		storageClasses = append(storageClasses, map[string]interface{}{
+			"name":         storageClass.Name,
+			"limit_mib":    int(limit.Value() / bytesInMebibyte),
+			"used_mib":     int(used.Value() / bytesInMebibyte),
+			"used_percent": getUsedPercent(used.Value(), limit.Value()),
+		})
+	}
+
+	d.SetId(buildResourceId(projectName, name))
+	if err := d.Set("zone", zones); err != nil {
+		return diag.Errorf("error setting zone: %s", err)
+	}
+	if err := d.Set("storage_class", storageClasses); err != nil {
+		return diag.Errorf("error setting storage_class: %s", err)
+	}
+
+	return nil
+}
+
+// listResourceQuotas retrieves the Kubernetes ResourceQuotas of the given Supervisor Namespace
+func listResourceQuotas(tmClient *VCDClient, endpoint, supervisorNamespaceName string) ([]cciResourceQuota, error) {
+	quotasURL, err := url.ParseRequestURI(strings.TrimSuffix(endpoint, "/") + fmt.Sprintf(cciResourceQuotasPath, url.PathEscape(supervisorNamespaceName)))
+	if err != nil {
+		return nil, fmt.Errorf("error building ResourceQuotas URL: %s", err)
+	}
+	var quotas cciResourceQuotaList
+	if err := performCciRequest(tmClient, http.MethodGet, quotasURL, "application/json", nil, &quotas); err != nil {
+		return nil, fmt.Errorf("error listing ResourceQuotas: %s", err)
+	}
+	return quotas.Items, nil
+}
+
+// getResourceQuotaHard returns the most restrictive limit of the given resource among the quotas. The second
+// returned value is false if no quota limits the resource
+func getResourceQuotaHard(quotas []cciResourceQuota, resourceName string) (resource.Quantity, bool) {
+	var result resource.Quantity
+	found := false
+	for _, quota := range quotas {
+		if hard, ok := quota.Status.Hard[resourceName]; ok && (!found || hard.Cmp(result) < 0) {
+			result = hard
+			found = true
+		}
+	}
+	return result, found
+}
+
+// getResourceQuotaUsed returns the consumption of the given resource reported by the quotas. Quotas with the same
+// scope report the same consumption, so the highest one is returned. The second returned value is false if no
+// quota reports the resource
+func getResourceQuotaUsed(quotas []cciResourceQuota, resourceName string) (resource.Quantity, bool) {
+	var result resource.Quantity
+	found := false
+	for _, quota := range quotas {
+		if used, ok := quota.Status.Used[resourceName]; ok && (!found || used.Cmp(result) > 0) {
+			result = used
+			found = true
+		}
+	}
+	return result, found
+}
+
+// parseQuantityAttribute parses a Kubernetes quantity, like '100Mi' or '2G'. Empty values are parsed as zero
+func parseQuantityAttribute(value, attributeName string) (resource.Quantity, error) {
+	if value == "" {
+		return resource.Quantity{}, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid '%s' quantity '%s': %s", attributeName, value, err)
+	}
+	return quantity, nil
+}
+
+// getUsedPercent returns the percentage of the limit that is used, or 0 if there is no limit
+func getUsedPercent(used, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(limit)
+}
@@ -0,0 +1,105 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func datasourceVcfaVpc() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceVcfaVpcRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("Name of the %s", labelVcfaVpc),
+			},
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("The name of the Project the %s belongs to", labelVcfaVpc),
+			},
+			"region_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Name of the %s", labelVcfaRegion),
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Description",
+			},
+			"private_ips": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: fmt.Sprintf("A set of private IP CIDRs used by the %s", labelVcfaVpc),
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"connectivity_profile_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Name of the VPC Connectivity Profile used by the %s", labelVcfaVpc),
+			},
+			"is_default": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: fmt.Sprintf("Whether this %s is the default one of the Project in the %s", labelVcfaVpc, labelVcfaRegion),
+			},
+			"ingress_committed_bandwidth_mbps": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Ingress committed bandwidth in Mbps for the %s", labelVcfaVpc),
+			},
+			"ingress_burst_size_bytes": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Ingress burst size bytes for the %s", labelVcfaVpc),
+			},
+			"egress_committed_bandwidth_mbps": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Egress committed bandwidth in Mbps for the %s", labelVcfaVpc),
+			},
+			"egress_burst_size_bytes": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Egress burst size bytes for the %s", labelVcfaVpc),
+			},
+			"phase": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Phase of the %s", labelVcfaVpc),
+			},
+			"ready": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: fmt.Sprintf("Whether the %s is in a ready status or not", labelVcfaVpc),
+			},
+		},
+	}
+}
+
+func datasourceVcfaVpcRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	name := d.Get("name").(string)
+	projectName := d.Get("project_name").(string)
+
+	vpc, err := readVpc(tmClient, projectName, name)
+	if err != nil {
+		return diag.Errorf("error reading %s: %s", labelVcfaVpc, err)
+	}
+	if err := setVpcData(tmClient, d, projectName, name, vpc); err != nil {
+		return diag.Errorf("error setting %s data: %s", labelVcfaVpc, err)
+	}
+
+	return nil
+}
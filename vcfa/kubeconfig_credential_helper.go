@@ -0,0 +1,102 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+// CredentialHelperCommand is the subcommand of the provider binary that acts as a kubectl exec credential plugin
+const CredentialHelperCommand = "kubeconfig-credential"
+
+// execCredentialAPIVersion is the version of the client authentication API used by the credential helper
+const execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+
+// RunCredentialHelper logs into VCFA with an API token file or a Service Account token file and prints an
+// ExecCredential with a fresh session token, as expected by kubectl exec credential plugins. It returns the
+// exit code of the command
+func RunCredentialHelper(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(CredentialHelperCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	vcfaUrl := flags.String("url", "", "VCFA URL, for example https://vcfa.example.com")
+	org := flags.String("org", "", "Organization to log into")
+	apiTokenFile := flags.String("api-token-file", "", "File containing an API token")
+	saTokenFile := flags.String("service-account-token-file", "", "File containing a Service Account token. It is updated after each login")
+	insecure := flags.Bool("insecure", false, "Skip the verification of the VCFA certificate")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *vcfaUrl == "" || *org == "" {
+		fmt.Fprintf(stderr, "%s: 'url' and 'org' are required\n", CredentialHelperCommand)
+		return 2
+	}
+	if (*apiTokenFile == "") == (*saTokenFile == "") {
+		fmt.Fprintf(stderr, "%s: exactly one of 'api-token-file' or 'service-account-token-file' must be set\n", CredentialHelperCommand)
+		return 2
+	}
+
+	config := Config{
+		ApiTokenFile:            *apiTokenFile,
+		AllowApiTokenFile:       true,
+		ServiceAccountTokenFile: *saTokenFile,
+		AllowSATokenFile:        true,
+		SysOrg:                  *org,
+		Org:                     *org,
+		Href:                    *vcfaUrl,
+		InsecureFlag:            *insecure,
+	}
+	tmClient, err := config.Client()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", CredentialHelperCommand, err)
+		return 1
+	}
+
+	credential, err := buildExecCredential(tmClient)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", CredentialHelperCommand, err)
+		return 1
+	}
+	credentialBytes, err := json.MarshalIndent(credential, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: error marshaling ExecCredential: %s\n", CredentialHelperCommand, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(credentialBytes))
+	return 0
+}
+
+// buildExecCredential returns an ExecCredential with the session token of the given client. The expiration
+// timestamp is taken from the token, so kubectl knows when to call the credential helper again
+func buildExecCredential(tmClient *VCDClient) (*clientauthenticationv1.ExecCredential, error) {
+	token, claims, err := parseSessionToken(tmClient)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse expiration time from JWT token claims: %s", err)
+	}
+
+	credential := &clientauthenticationv1.ExecCredential{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "ExecCredential",
+			APIVersion: execCredentialAPIVersion,
+		},
+		Status: &clientauthenticationv1.ExecCredentialStatus{
+			Token: token.Raw,
+		},
+	}
+	if expiresAt != nil {
+		credential.Status.ExpirationTimestamp = &v1.Time{Time: expiresAt.Time}
+	}
+	return credential, nil
+}
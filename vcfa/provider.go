@@ -51,6 +51,7 @@ var globalDataSourceMap = map[string]*schema.Resource{
 	"vcfa_storage_class":                   datasourceVcfaStorageClass(),                // 1.0
 	"vcfa_content_library":                 datasourceVcfaContentLibrary(),              // 1.0
 	"vcfa_content_library_item":            datasourceVcfaContentLibraryItem(),          // 1.0
+	"vcfa_content_library_item_ovf":        datasourceVcfaContentLibraryItemOvf(),       // 1.0
 	"vcfa_tier0_gateway":                   datasourceVcfaTier0Gateway(),                // 1.0
 	"vcfa_provider_gateway":                datasourceVcfaProviderGateway(),             // 1.0
 	"vcfa_edge_cluster":                    datasourceVcfaEdgeCluster(),                 // 1.0
@@ -70,33 +71,44 @@ var globalDataSourceMap = map[string]*schema.Resource{
 	"vcfa_provider_ldap":                   datasourceVcfaLdap(),                        // 1.0
 	"vcfa_kubeconfig":                      datasourceVcfaKubeConfig(),                  // 1.0
 	"vcfa_supervisor_namespace":            datasourceVcfaSupervisorNamespace(),         // 1.0
+	"vcfa_supervisor_namespace_usage":      datasourceVcfaSupervisorNamespaceUsage(),    // 1.0
+	"vcfa_supervisor_namespace_images":     datasourceVcfaSupervisorNamespaceImages(),   // 1.0
+	"vcfa_vpc":                             datasourceVcfaVpc(),                         // 1.0
 }
 
 var globalResourceMap = map[string]*schema.Resource{
-	"vcfa_vcenter":                         resourceVcfaVcenter(),                     // 1.0
-	"vcfa_org":                             resourceVcfaOrg(),                         // 1.0
-	"vcfa_nsx_manager":                     resourceVcfaNsxManager(),                  // 1.0
-	"vcfa_region":                          resourceVcfaRegion(),                      // 1.0
-	"vcfa_ip_space":                        resourceVcfaIpSpace(),                     // 1.0
-	"vcfa_org_region_quota":                resourceVcfaOrgRegionQuota(),              // 1.0
-	"vcfa_content_library":                 resourceVcfaContentLibrary(),              // 1.0
-	"vcfa_content_library_item":            resourceVcfaContentLibraryItem(),          // 1.0
-	"vcfa_provider_gateway":                resourceVcfaProviderGateway(),             // 1.0
-	"vcfa_edge_cluster_qos":                resourceVcfaEdgeClusterQos(),              // 1.0
-	"vcfa_org_networking":                  resourceVcfaOrgNetworking(),               // 1.0
-	"vcfa_org_settings":                    resourceVcfaOrgSettings(),                 // 1.0
-	"vcfa_org_regional_networking":         resourceVcfaOrgRegionalNetworking(),       // 1.0
-	"vcfa_org_regional_networking_vpc_qos": resourceVcfaOrgRegionalNetworkingVpcQos(), // 1.0
-	"vcfa_org_oidc":                        resourceVcfaOrgOidc(),                     // 1.0
-	"vcfa_rights_bundle":                   resourceVcfaRightsBundle(),                // 1.0
-	"vcfa_role":                            resourceVcfaRole(),                        // 1.0
-	"vcfa_global_role":                     resourceVcfaGlobalRole(),                  // 1.0
-	"vcfa_api_token":                       resourceVcfaApiToken(),                    // 1.0
-	"vcfa_certificate":                     resourceVcfaCertificate(),                 // 1.0
-	"vcfa_org_local_user":                  resourceVcfaLocalUser(),                   // 1.0
-	"vcfa_org_ldap":                        resourceVcfaOrgLdap(),                     // 1.0
-	"vcfa_provider_ldap":                   resourceVcfaProviderLdap(),                // 1.0
-	"vcfa_supervisor_namespace":            resourceVcfaSupervisorNamespace(),         // 1.0
+	"vcfa_vcenter":                              resourceVcfaVcenter(),                           // 1.0
+	"vcfa_org":                                  resourceVcfaOrg(),                               // 1.0
+	"vcfa_nsx_manager":                          resourceVcfaNsxManager(),                        // 1.0
+	"vcfa_region":                               resourceVcfaRegion(),                            // 1.0
+	"vcfa_ip_space":                             resourceVcfaIpSpace(),                           // 1.0
+	"vcfa_org_region_quota":                     resourceVcfaOrgRegionQuota(),                    // 1.0
+	"vcfa_content_library":                      resourceVcfaContentLibrary(),                    // 1.0
+	"vcfa_content_library_item":                 resourceVcfaContentLibraryItem(),                // 1.0
+	"vcfa_content_library_item_download":        resourceVcfaContentLibraryItemDownload(),        // 1.0
+	"vcfa_provider_gateway":                     resourceVcfaProviderGateway(),                   // 1.0
+	"vcfa_edge_cluster_qos":                     resourceVcfaEdgeClusterQos(),                    // 1.0
+	"vcfa_org_networking":                       resourceVcfaOrgNetworking(),                     // 1.0
+	"vcfa_org_settings":                         resourceVcfaOrgSettings(),                       // 1.0
+	"vcfa_org_regional_networking":              resourceVcfaOrgRegionalNetworking(),             // 1.0
+	"vcfa_org_regional_networking_vpc_qos":      resourceVcfaOrgRegionalNetworkingVpcQos(),       // 1.0
+	"vcfa_org_oidc":                             resourceVcfaOrgOidc(),                           // 1.0
+	"vcfa_rights_bundle":                        resourceVcfaRightsBundle(),                      // 1.0
+	"vcfa_role":                                 resourceVcfaRole(),                              // 1.0
+	"vcfa_global_role":                          resourceVcfaGlobalRole(),                        // 1.0
+	"vcfa_api_token":                            resourceVcfaApiToken(),                          // 1.0
+	"vcfa_certificate":                          resourceVcfaCertificate(),                       // 1.0
+	"vcfa_org_local_user":                       resourceVcfaLocalUser(),                         // 1.0
+	"vcfa_org_ldap":                             resourceVcfaOrgLdap(),                           // 1.0
+	"vcfa_provider_ldap":                        resourceVcfaProviderLdap(),                      // 1.0
+	"vcfa_supervisor_namespace":                 resourceVcfaSupervisorNamespace(),               // 1.0
+	"vcfa_vpc":                                  resourceVcfaVpc(),                               // 1.0
+	"vcfa_supervisor_namespace_manifest":        resourceVcfaSupervisorNamespaceManifest(),       // 1.0
+	"vcfa_virtual_machine":                      resourceVcfaVirtualMachine(),                    // 1.0
+	"vcfa_kubernetes_cluster":                   resourceVcfaKubernetesCluster(),                 // 1.0
+	"vcfa_kubeconfig_file":                      resourceVcfaKubeconfigFile(),                    // 1.0
+	"vcfa_supervisor_namespace_content_library": resourceVcfaSupervisorNamespaceContentLibrary(), // 1.0
+	"vcfa_content_library_sync":                 resourceVcfaContentLibrarySync(),                // 1.0
 }
 
 // Provider returns a terraform.ResourceProvider.
@@ -217,6 +229,13 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("VCFA_IMPORT_SEPARATOR", "."),
 				Description: "Defines the import separation string to be used with 'terraform import'",
 			},
+			"max_concurrent_uploads": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("VCFA_MAX_CONCURRENT_UPLOADS", 0),
+				Description:  "Maximum number of Content Library Items that are uploaded at the same time. 0 means no limit",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
 		},
 		ResourcesMap:         globalResourceMap,
 		DataSourcesMap:       globalDataSourceMap,
@@ -228,6 +247,8 @@ func Provider() *schema.Provider {
 // meta `meta interface{}` argument. It is being initialized in providerConfigure method
 type ClientContainer struct {
 	tmClient *VCDClient
+	// uploadSlots limits the number of simultaneous Content Library Item uploads. It is nil when there is no limit
+	uploadSlots chan struct{}
 }
 
 func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
@@ -337,6 +358,9 @@ func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{},
 	metaContainer := ClientContainer{
 		tmClient: tmClient,
 	}
+	if maxConcurrentUploads := d.Get("max_concurrent_uploads").(int); maxConcurrentUploads > 0 {
+		metaContainer.uploadSlots = make(chan struct{}, maxConcurrentUploads)
+	}
 
 	return metaContainer, providerDiagnostics
 }
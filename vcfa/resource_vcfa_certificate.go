@@ -7,6 +7,7 @@ package vcfa
 import (
 	"context"
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/vmware/go-vcloud-director/v3/govcd"
@@ -18,11 +19,12 @@ import (
 )
 
 func resourceVcfaCertificate() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		ReadContext:   resourceVcfaCertificateRead,
 		CreateContext: resourceVcfaCertificateCreate,
 		UpdateContext: resourceVcfaCertificateUpdate,
 		DeleteContext: resourceVcfaCertificateDelete,
+		CustomizeDiff: resourceVcfaCertificateCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceVcfaCertificateImport,
 		},
@@ -45,51 +47,76 @@ func resourceVcfaCertificate() *schema.Resource {
 				Description: "Certificate description",
 			},
 			"certificate": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Certificate content",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true, // Taken from 'pkcs12_base64' when it is used
+				ExactlyOneOf:     []string{"certificate", "pkcs12_base64"},
+				DiffSuppressFunc: suppressEquivalentCertificatePem,
+				Description:      "Certificate content. It can be rotated in place, keeping the same ID",
 			},
 			"private_key": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				ForceNew:    true,
-				Sensitive:   true,
-				Description: "Certificate private key",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"pkcs12_base64"},
+				Description:   "Certificate private key",
 			},
 			"private_key_passphrase": {
-				Type:        schema.TypeString,
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"pkcs12_base64"},
+				Description:   "Certificate private passphrase",
+			},
+			"pkcs12_base64": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"certificate", "pkcs12_base64"},
+				Description:  "Base64 encoded PKCS#12 bundle with the certificate, its private key and optionally its chain",
+			},
+			"pkcs12_password": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"pkcs12_base64"},
+				Description:  "Password of the PKCS#12 bundle",
+			},
+			"chain": {
+				Type:        schema.TypeList,
 				Optional:    true,
-				ForceNew:    true,
-				Sensitive:   true,
-				Description: "Certificate private passphrase",
+				Description: "PEM certificates of the chain of the certificate, in any order. They are sent to VCFA ordered from the issuer of the certificate to the root",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
 			},
+			"keep_backup_on_rotation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to keep the previous certificate as a separate entry, with alias '<alias>-backup', when the certificate is rotated. The backup of a previous rotation is replaced",
+			},
+			"backup_certificate_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the entry that contains the certificate that was replaced in the last rotation, when 'keep_backup_on_rotation' is set",
+			},
+			"expiry_warning_days": certificateExpiryWarningDaysSchema,
 		},
 	}
+	maps.Copy(r.Schema, certificateMetadataSchema)
+	return r
 }
 
 // resourceVcfaCertificateCreate covers Create functionality for resource
 func resourceVcfaCertificateCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	tmClient := meta.(ClientContainer).tmClient
 
-	org, err := tmClient.GetTmOrgById(d.Get("org_id").(string))
+	certificateConfig, err := getCertificateConfigurationType(d.Get)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-
-	certificateConfig := getCertificateConfigurationType(d)
-	var createdCertificate *govcd.Certificate
-	if isSystem(org) {
-		createdCertificate, err = tmClient.Client.AddCertificateToLibrary(certificateConfig)
-	} else {
-		// TODO: TM: Implement these methods in TmOrg
-		var adminOrg *govcd.AdminOrg
-		adminOrg, err = tmClient.GetAdminOrgById(org.TmOrg.ID)
-		if err != nil {
-			return diag.FromErr(err)
-		}
-		createdCertificate, err = adminOrg.AddCertificateToLibrary(certificateConfig)
-	}
+	createdCertificate, err := addCertificateToOrgLibrary(tmClient, d.Get("org_id").(string), certificateConfig)
 	if err != nil {
 		return diag.Errorf("error adding certificate library item: %s", err)
 	}
@@ -106,25 +133,139 @@ func resourceVcfaCertificateUpdate(ctx context.Context, d *schema.ResourceData,
 		return diag.Errorf("[certificate library update] : %s", err)
 	}
 
-	certificateConfig := getCertificateConfigurationType(d)
+	certificateConfig, err := getCertificateConfigurationType(d.Get)
+	if err != nil {
+		return diag.Errorf("[certificate library update] : %s", err)
+	}
 	certificate.CertificateLibrary.Alias = certificateConfig.Alias
 	certificate.CertificateLibrary.Description = certificateConfig.Description
-	_, err = certificate.Update()
+
+	rotate := d.HasChanges("certificate", "private_key", "private_key_passphrase", "pkcs12_base64", "pkcs12_password", "chain")
+	if rotate {
+		certificate.CertificateLibrary.Certificate = certificateConfig.Certificate
+		certificate.CertificateLibrary.PrivateKey = certificateConfig.PrivateKey
+		certificate.CertificateLibrary.PrivateKeyPassphrase = certificateConfig.PrivateKeyPassphrase
+	}
+	updatedCertificate, err := certificate.Update()
 	if err != nil {
+		// Keep the previous certificate in state, so the rotation is attempted again in the next apply
+		d.Partial(rotate)
 		return diag.Errorf("[certificate library update] : %s", err)
 	}
+	if rotate {
+		rotated, err := updatedCertificate.SameAs(certificateConfig.Certificate)
+		if err != nil {
+			d.Partial(true)
+			return diag.Errorf("[certificate library update] : %s", err)
+		}
+		if !rotated {
+			d.Partial(true)
+			return diag.Errorf("[certificate library update] VCFA did not replace the content of certificate '%s'", certificateConfig.Alias)
+		}
+		// The backup is only taken once the rotation succeeded, so a failed rotation does not leave any entry behind
+		if d.Get("keep_backup_on_rotation").(bool) {
+			err = backupCertificate(tmClient, d)
+			if err != nil {
+				return diag.Errorf("[certificate library update] certificate '%s' was rotated, but keeping a backup of the previous certificate failed: %s",
+					certificateConfig.Alias, err)
+			}
+		}
+	}
 
 	return resourceVcfaCertificateRead(ctx, d, meta)
 }
 
-func getCertificateConfigurationType(d *schema.ResourceData) *types.CertificateLibraryItem {
-	return &types.CertificateLibraryItem{
-		Alias:                d.Get("alias").(string),
-		Description:          d.Get("description").(string),
-		Certificate:          d.Get("certificate").(string),
-		PrivateKey:           d.Get("private_key").(string),
-		PrivateKeyPassphrase: d.Get("private_key_passphrase").(string),
+// resourceVcfaCertificateCustomizeDiff validates during plan that the private key matches the certificate, and that
+// the chain is consistent, when all of them are known
+func resourceVcfaCertificateCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	for _, key := range []string{"certificate", "private_key", "pkcs12_base64", "pkcs12_password", "chain"} {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
+	_, _, _, err := getCertificateSource(d.Get).build()
+	if err != nil {
+		return err
+	}
+	// The certificate comes from the bundle, so it changes when the bundle does
+	if d.Get("pkcs12_base64").(string) != "" && d.HasChanges("pkcs12_base64", "pkcs12_password", "chain") {
+		return d.SetNewComputed("certificate")
+	}
+	return nil
+}
+
+// addCertificateToOrgLibrary adds the given certificate to the library of the Organization with the given ID
+func addCertificateToOrgLibrary(tmClient *VCDClient, orgId string, certificateConfig *types.CertificateLibraryItem) (*govcd.Certificate, error) {
+	org, err := tmClient.GetTmOrgById(orgId)
+	if err != nil {
+		return nil, err
+	}
+	if isSystem(org) {
+		return tmClient.Client.AddCertificateToLibrary(certificateConfig)
+	}
+	// TODO: TM: Implement these methods in TmOrg
+	adminOrg, err := tmClient.GetAdminOrgById(org.TmOrg.ID)
+	if err != nil {
+		return nil, err
 	}
+	return adminOrg.AddCertificateToLibrary(certificateConfig)
+}
+
+// backupCertificate adds the certificate that was replaced by a rotation to the library, with alias '<alias>-backup',
+// after removing the backup of a previous rotation, which has the same alias
+func backupCertificate(tmClient *VCDClient, d *schema.ResourceData) error {
+	orgId := d.Get("org_id").(string)
+	err := deleteBackupCertificate(tmClient, orgId, d.Get("backup_certificate_id").(string))
+	if err != nil {
+		return err
+	}
+	dSet(d, "backup_certificate_id", "")
+
+	backupConfig, err := getCertificateConfigurationType(func(key string) interface{} {
+		oldValue, _ := d.GetChange(key)
+		return oldValue
+	})
+	if err != nil {
+		return err
+	}
+	backupConfig.Alias += "-backup"
+	backup, err := addCertificateToOrgLibrary(tmClient, orgId, backupConfig)
+	if err != nil {
+		return err
+	}
+	dSet(d, "backup_certificate_id", backup.CertificateLibrary.Id)
+	return nil
+}
+
+// deleteBackupCertificate removes the backup certificate with the given ID, if it exists
+func deleteBackupCertificate(tmClient *VCDClient, orgId, backupId string) error {
+	if backupId == "" {
+		return nil
+	}
+	backup, err := getCertificateType(tmClient, orgId, backupId)
+	if govcd.ContainsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return backup.Delete()
+}
+
+// getCertificateConfigurationType builds the certificate library item from the values returned by the given getter,
+// converting the PKCS#12 bundle and the chain to the format that VCFA expects
+func getCertificateConfigurationType(get func(key string) interface{}) (*types.CertificateLibraryItem, error) {
+	certificate, privateKey, privateKeyPassphrase, err := getCertificateSource(get).build()
+	if err != nil {
+		return nil, err
+	}
+	return &types.CertificateLibraryItem{
+		Alias:                get("alias").(string),
+		Description:          get("description").(string),
+		Certificate:          certificate,
+		PrivateKey:           privateKey,
+		PrivateKeyPassphrase: privateKeyPassphrase,
+	}, nil
 }
 
 func resourceVcfaCertificateRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -139,15 +280,21 @@ func resourceVcfaCertificateRead(_ context.Context, d *schema.ResourceData, meta
 		return diag.Errorf("[certificate library read] : %s", err)
 	}
 
-	setCertificateConfigurationData(certificate.CertificateLibrary, d)
-
-	return nil
+	// VCFA stores the whole chain, but only the leaf certificate is kept in 'certificate' when the chain is given apart
+	if getCertificateSource(d.Get).isBundle() {
+		certificate.CertificateLibrary.Certificate, err = getLeafCertificatePem(certificate.CertificateLibrary.Certificate)
+		if err != nil {
+			return diag.Errorf("[certificate library read] error parsing certificate: %s", err)
+		}
+	}
+	return setCertificateConfigurationData(certificate.CertificateLibrary, d)
 }
 
-func setCertificateConfigurationData(config *types.CertificateLibraryItem, d *schema.ResourceData) {
+func setCertificateConfigurationData(config *types.CertificateLibraryItem, d *schema.ResourceData) diag.Diagnostics {
 	dSet(d, "alias", config.Alias)
 	dSet(d, "description", config.Description)
 	dSet(d, "certificate", config.Certificate)
+	return setCertificateMetadata(d, config.Alias, config.Certificate)
 }
 
 func getCertificateType(tmClient *VCDClient, orgId, certLibId string) (*govcd.Certificate, error) {
@@ -181,7 +328,11 @@ func resourceVcfaCertificateDelete(_ context.Context, d *schema.ResourceData, me
 	}
 	err = certificateToDelete.Delete()
 	if err != nil {
-		diag.Errorf("[certificate library delete] error deleting certificate library %s", err)
+		return diag.Errorf("[certificate library delete] error deleting certificate library %s", err)
+	}
+	err = deleteBackupCertificate(tmClient, d.Get("org_id").(string), d.Get("backup_certificate_id").(string))
+	if err != nil {
+		return diag.Errorf("[certificate library delete] error deleting backup certificate: %s", err)
 	}
 	return nil
 }
@@ -217,7 +368,11 @@ func resourceVcfaCertificateImport(_ context.Context, d *schema.ResourceData, me
 
 	d.SetId(certificate.CertificateLibrary.Id)
 	dSet(d, "org_id", org.TmOrg.ID)
-	setCertificateConfigurationData(certificate.CertificateLibrary, d)
+	dSet(d, "expiry_warning_days", certificateExpiryWarningDaysSchema.Default)
+	dSet(d, "keep_backup_on_rotation", false)
+	if diags := setCertificateConfigurationData(certificate.CertificateLibrary, d); diags.HasError() {
+		return nil, fmt.Errorf("error importing certificate library item: %s", diags[0].Summary)
+	}
 
 	return []*schema.ResourceData{d}, nil
 }
@@ -7,6 +7,8 @@
 package vcfa
 
 import (
+	"fmt"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -69,12 +71,15 @@ func TestAccVcfaCertificateResource(t *testing.T) {
 	resourceAddressSysCert := "vcfa_certificate.sysCertificate"
 	resourceAddressSysPrivateCert := "vcfa_certificate.sysCertificateWithPrivate"
 
+	cachedId := &testCachedFieldValue{}
+
 	resource.Test(t, resource.TestCase{
 		ProviderFactories: testAccProviders,
 		Steps: []resource.TestStep{
 			{
 				Config: configText1,
 				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.cacheTestResourceFieldValue(resourceAddressOrgCert, "id"),
 					resource.TestCheckResourceAttr(resourceAddressOrgCert, "alias", params["Alias"].(string)),
 					resource.TestMatchResourceAttr(resourceAddressOrgCert, "id", regexp.MustCompile(`^\S+`)),
 					resource.TestCheckResourceAttr(resourceAddressOrgCert, "description", params["Description1"].(string)),
@@ -91,11 +96,24 @@ func TestAccVcfaCertificateResource(t *testing.T) {
 					resource.TestMatchResourceAttr(resourceAddressSysPrivateCert, "id", regexp.MustCompile(`^\S+`)),
 					resource.TestCheckResourceAttr(resourceAddressSysPrivateCert, "description", params["Description4"].(string)),
 					resource.TestMatchResourceAttr(resourceAddressSysPrivateCert, "certificate", regexp.MustCompile(`^\S+`)),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "subject", regexp.MustCompile(`\S+=\S+`)),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "issuer", regexp.MustCompile(`\S+=\S+`)),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "serial_number", regexp.MustCompile(`^[0-9A-F]{2}(:[0-9A-F]{2})*$`)),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "not_before", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T`)),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "not_after", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T`)),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "sha1_fingerprint", regexp.MustCompile(`^[0-9A-F]{2}(:[0-9A-F]{2}){19}$`)),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "sha256_fingerprint", regexp.MustCompile(`^[0-9A-F]{2}(:[0-9A-F]{2}){31}$`)),
+					resource.TestCheckResourceAttr(resourceAddressOrgCert, "expiry_warning_days", "30"),
+					resource.TestCheckResourceAttrPair(resourceAddressOrgCert, "sha256_fingerprint", resourceAddressSysCert, "sha256_fingerprint"),
 				),
 			},
 			{
+				// The certificate is rotated in place, keeping the previous one as backup
 				Config: configText2,
 				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.testCheckCachedResourceFieldValue(resourceAddressOrgCert, "id"),
+					resource.TestCheckResourceAttrPair(resourceAddressOrgCert, "sha256_fingerprint", resourceAddressSysPrivateCert, "sha256_fingerprint"),
+					resource.TestMatchResourceAttr(resourceAddressOrgCert, "backup_certificate_id", regexp.MustCompile(`^\S+`)),
 					resource.TestCheckResourceAttr(resourceAddressOrgCert, "alias", params["AliasUpdate"].(string)),
 					resource.TestMatchResourceAttr(resourceAddressOrgCert, "id", regexp.MustCompile(`^\S+`)),
 					resource.TestCheckResourceAttr(resourceAddressOrgCert, "description", params["Description1Update"].(string)),
@@ -109,10 +127,12 @@ func TestAccVcfaCertificateResource(t *testing.T) {
 			{
 				Config: configText3,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resourceFieldsEqual(resourceAddressOrgCert, "data.vcfa_certificate.existing", []string{"%", "private_key", "private_key_passphrase"}),
-					resourceFieldsEqual(resourceAddressOrgCert, "data.vcfa_certificate.existingById", []string{"%", "private_key", "private_key_passphrase"}),
-					resourceFieldsEqual(resourceAddressSysPrivateCert, "data.vcfa_certificate.existingSystem", []string{"%", "private_key", "private_key_passphrase"}),
-					resourceFieldsEqual(resourceAddressSysPrivateCert, "data.vcfa_certificate.existingSystemById", []string{"%", "private_key", "private_key_passphrase"}),
+					resourceFieldsEqual(resourceAddressOrgCert, "data.vcfa_certificate.existing", []string{"%", "private_key", "private_key_passphrase", "keep_backup_on_rotation", "backup_certificate_id"}),
+					resourceFieldsEqual(resourceAddressOrgCert, "data.vcfa_certificate.existingById", []string{"%", "private_key", "private_key_passphrase", "keep_backup_on_rotation", "backup_certificate_id"}),
+					resourceFieldsEqual(resourceAddressSysPrivateCert, "data.vcfa_certificate.existingSystem", []string{"%", "private_key", "private_key_passphrase", "keep_backup_on_rotation", "backup_certificate_id"}),
+					resourceFieldsEqual(resourceAddressSysPrivateCert, "data.vcfa_certificate.existingSystemById", []string{"%", "private_key", "private_key_passphrase", "keep_backup_on_rotation", "backup_certificate_id"}),
+					resourceFieldsEqual(resourceAddressOrgCert, "data.vcfa_certificate.existingByFingerprint", []string{"%", "private_key", "private_key_passphrase", "keep_backup_on_rotation", "backup_certificate_id"}),
+					resourceFieldsEqual(resourceAddressSysPrivateCert, "data.vcfa_certificate.existingSystemBySubject", []string{"%", "private_key", "private_key_passphrase", "keep_backup_on_rotation", "backup_certificate_id"}),
 				),
 			},
 			{
@@ -124,6 +144,8 @@ func TestAccVcfaCertificateResource(t *testing.T) {
 						ImportSeparator +
 						params["AliasUpdate"].(string), nil
 				},
+				// The backup settings only exist in Terraform
+				ImportStateVerifyIgnore: []string{"keep_backup_on_rotation", "backup_certificate_id"},
 			},
 		},
 	})
@@ -185,10 +207,11 @@ data "vcfa_org" "system" {
 }
 
 resource "vcfa_certificate" "orgCertificate" {
-  org_id      = vcfa_org.org1.id
-  alias       = "{{.AliasUpdate}}"
-  description = "{{.Description1Update}}"
-  certificate = file("{{.Certificate1Path}}")
+  org_id                  = vcfa_org.org1.id
+  alias                   = "{{.AliasUpdate}}"
+  description             = "{{.Description1Update}}"
+  certificate             = file("{{.Certificate2Path}}")
+  keep_backup_on_rotation = true
 }
 
 resource "vcfa_certificate" "sysCertificateWithPrivate" {
@@ -221,4 +244,215 @@ data "vcfa_certificate" "existingSystemById" {
   org_id = data.vcfa_org.system.id
   id     = vcfa_certificate.sysCertificateWithPrivate.id
 }
+
+data "vcfa_certificate" "existingByFingerprint" {
+  org_id             = vcfa_org.org1.id
+  sha256_fingerprint = lower(replace(vcfa_certificate.orgCertificate.sha256_fingerprint, ":", ""))
+}
+
+data "vcfa_certificate" "existingSystemBySubject" {
+  org_id  = data.vcfa_org.system.id
+  subject = vcfa_certificate.sysCertificateWithPrivate.subject
+}
+`
+
+// TestAccVcfaCertificateBundle tests certificates that are built from a PEM certificate with a separate chain, and from
+// a PKCS#12 bundle. The bundles 'test-resources/cert.p12' (legacy encryption) and 'test-resources/cert-aes.p12' (AES
+// encryption) contain 'test-resources/cert.pem', its private key and 'test-resources/rootCA.pem', with password 'test'
+func TestAccVcfaCertificateBundle(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+
+	skipIfNotSysAdmin(t)
+
+	if len(testConfig.Tm.Certificates) < 2 || testConfig.Tm.RootCertificatePath == "" {
+		t.Skip("there must be at least two certificates in tm.certificates and a tm.rootCertificatePath in test configuration")
+	}
+	pkcs12Path, err := filepath.Abs("../test-resources/cert.p12")
+	if err != nil {
+		t.Fatalf("error retrieving absolute path of the PKCS#12 bundle: %s", err)
+	}
+	pkcs12AesPath, err := filepath.Abs("../test-resources/cert-aes.p12")
+	if err != nil {
+		t.Fatalf("error retrieving absolute path of the PKCS#12 bundle: %s", err)
+	}
+
+	// The first configuration uses a wrong password and a chain that does not belong to the certificate
+	var params = StringMap{
+		"AliasChain":           t.Name() + "-chain",
+		"AliasPkcs12":          t.Name() + "-pkcs12",
+		"Certificate2":         fmt.Sprintf(`file("%s")`, testConfig.Tm.Certificates[1].Path),
+		"PrivateKey2":          testConfig.Tm.Certificates[1].PrivateKeyPath,
+		"PassPhrase":           testConfig.Tm.Certificates[1].Password,
+		"ChainCertificatePath": testConfig.Tm.Certificates[0].Path,
+		"Pkcs12Path":           pkcs12Path,
+		"Pkcs12Password":       "wrong",
+	}
+	testParamsNotEmpty(t, params)
+
+	configText1 := templateFill(testAccVcfaCertificateBundle, params)
+	debugPrintf("#[DEBUG] CONFIGURATION for step 1: %s", configText1)
+
+	params["FuncName"] = t.Name() + "-step2"
+	params["Pkcs12Password"] = "test"
+	params["ChainCertificatePath"] = testConfig.Tm.RootCertificatePath
+	configText2 := templateFill(testAccVcfaCertificateBundle, params)
+	debugPrintf("#[DEBUG] CONFIGURATION for step 2: %s", configText2)
+
+	// The same bundle with AES encryption, and the chain also appended to the certificate, which must not be a change
+	params["FuncName"] = t.Name() + "-step3"
+	params["Pkcs12Path"] = pkcs12AesPath
+	params["Certificate2"] = fmt.Sprintf(`"${file("%s")}${file("%s")}"`, testConfig.Tm.Certificates[1].Path, testConfig.Tm.RootCertificatePath)
+	configText3 := templateFill(testAccVcfaCertificateBundle, params)
+	debugPrintf("#[DEBUG] CONFIGURATION for step 3: %s", configText3)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	resourceAddressChainCert := "vcfa_certificate.chain"
+	resourceAddressPkcs12Cert := "vcfa_certificate.pkcs12"
+
+	cachedChainCertificate := &testCachedFieldValue{}
+	cachedPkcs12Id := &testCachedFieldValue{}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				// Both resources are rejected during plan
+				Config:      configText1,
+				ExpectError: regexp.MustCompile(`decryption password incorrect|is not part of the chain of`),
+			},
+			{
+				Config: configText2,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr(resourceAddressChainCert, "id", regexp.MustCompile(`^\S+`)),
+					resource.TestMatchResourceAttr(resourceAddressChainCert, "subject", regexp.MustCompile(`CN=cert2`)),
+					resource.TestMatchResourceAttr(resourceAddressChainCert, "certificate", regexp.MustCompile(`^-----BEGIN CERTIFICATE-----`)),
+					resource.TestCheckResourceAttr(resourceAddressChainCert, "chain.#", "1"),
+					resource.TestMatchResourceAttr(resourceAddressPkcs12Cert, "id", regexp.MustCompile(`^\S+`)),
+					resource.TestMatchResourceAttr(resourceAddressPkcs12Cert, "subject", regexp.MustCompile(`CN=cert,`)),
+					resource.TestMatchResourceAttr(resourceAddressPkcs12Cert, "certificate", regexp.MustCompile(`^-----BEGIN CERTIFICATE-----`)),
+					resource.TestCheckResourceAttrPair(resourceAddressChainCert, "issuer", resourceAddressPkcs12Cert, "issuer"),
+					cachedChainCertificate.cacheTestResourceFieldValue(resourceAddressChainCert, "certificate"),
+					cachedPkcs12Id.cacheTestResourceFieldValue(resourceAddressPkcs12Cert, "id"),
+				),
+			},
+			{
+				Config: configText3,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedChainCertificate.testCheckCachedResourceFieldValue(resourceAddressChainCert, "certificate"),
+					cachedPkcs12Id.testCheckCachedResourceFieldValue(resourceAddressPkcs12Cert, "id"),
+					resource.TestMatchResourceAttr(resourceAddressPkcs12Cert, "subject", regexp.MustCompile(`CN=cert,`)),
+				),
+			},
+		},
+	})
+}
+
+const testAccVcfaCertificateBundle = `
+data "vcfa_org" "system" {
+  name = "System"
+}
+
+resource "vcfa_certificate" "chain" {
+  org_id                 = data.vcfa_org.system.id
+  alias                  = "{{.AliasChain}}"
+  certificate            = {{.Certificate2}}
+  private_key            = file("{{.PrivateKey2}}")
+  private_key_passphrase = "{{.PassPhrase}}"
+  chain                  = [file("{{.ChainCertificatePath}}")]
+}
+
+resource "vcfa_certificate" "pkcs12" {
+  org_id          = data.vcfa_org.system.id
+  alias           = "{{.AliasPkcs12}}"
+  pkcs12_base64   = filebase64("{{.Pkcs12Path}}")
+  pkcs12_password = "{{.Pkcs12Password}}"
+}
+`
+
+// TestAccVcfaCertificateEncryptedKey tests that encrypted private keys are checked against the certificate during plan.
+// 'test-resources/key-encrypted.pem' is the private key of 'test-resources/cert.pem', encrypted with passphrase 'test'
+func TestAccVcfaCertificateEncryptedKey(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+
+	skipIfNotSysAdmin(t)
+
+	var paths []string
+	for _, path := range []string{"../test-resources/cert.pem", "../test-resources/cert2.pem", "../test-resources/key-encrypted.pem"} {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatalf("error retrieving absolute path of '%s': %s", path, err)
+		}
+		paths = append(paths, absPath)
+	}
+
+	// The first configuration uses a certificate that does not match the private key
+	var params = StringMap{
+		"Alias":           t.Name(),
+		"CertificatePath": paths[1],
+		"PrivateKeyPath":  paths[2],
+		"PassPhrase":      "test",
+	}
+	testParamsNotEmpty(t, params)
+
+	configText1 := templateFill(testAccVcfaCertificateEncryptedKey, params)
+	debugPrintf("#[DEBUG] CONFIGURATION for step 1: %s", configText1)
+
+	params["FuncName"] = t.Name() + "-step2"
+	params["CertificatePath"] = paths[0]
+	params["PassPhrase"] = "wrong"
+	configText2 := templateFill(testAccVcfaCertificateEncryptedKey, params)
+	debugPrintf("#[DEBUG] CONFIGURATION for step 2: %s", configText2)
+
+	params["FuncName"] = t.Name() + "-step3"
+	params["PassPhrase"] = "test"
+	configText3 := templateFill(testAccVcfaCertificateEncryptedKey, params)
+	debugPrintf("#[DEBUG] CONFIGURATION for step 3: %s", configText3)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	resourceAddress := "vcfa_certificate.encrypted"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      configText1,
+				ExpectError: regexp.MustCompile(`the private key does not match the certificate 'CN=cert2`),
+			},
+			{
+				Config:      configText2,
+				ExpectError: regexp.MustCompile(`could not decrypt the private key with 'private_key_passphrase'`),
+			},
+			{
+				Config: configText3,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr(resourceAddress, "id", regexp.MustCompile(`^\S+`)),
+					resource.TestMatchResourceAttr(resourceAddress, "subject", regexp.MustCompile(`CN=cert,`)),
+				),
+			},
+		},
+	})
+}
+
+const testAccVcfaCertificateEncryptedKey = `
+data "vcfa_org" "system" {
+  name = "System"
+}
+
+resource "vcfa_certificate" "encrypted" {
+  org_id                 = data.vcfa_org.system.id
+  alias                  = "{{.Alias}}"
+  certificate            = file("{{.CertificatePath}}")
+  private_key            = file("{{.PrivateKeyPath}}")
+  private_key_passphrase = "{{.PassPhrase}}"
+}
 `
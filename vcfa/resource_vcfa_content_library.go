@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -49,6 +50,15 @@ func resourceVcfaContentLibrary() *schema.Resource {
 				Optional:    true,
 				Description: fmt.Sprintf("On deletion, deletes the %s, including its %ss, in a single operation", labelVcfaContentLibrary, labelVcfaContentLibraryItem),
 			},
+			"delete_acknowledged_items": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Description: fmt.Sprintf("Names of the %ss that can be removed when the %s is deleted, or '*' to acknowledge all of them. "+
+					"Deleting a %s that contains other %ss fails", labelVcfaContentLibraryItem, labelVcfaContentLibrary, labelVcfaContentLibrary, labelVcfaContentLibraryItem),
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 			"storage_class_ids": {
 				Type:        schema.TypeSet,
 				Required:    true,
@@ -115,6 +125,40 @@ func resourceVcfaContentLibrary() *schema.Resource {
 							Sensitive:   true,
 							Description: "Password to use to authenticate with the publisher",
 						},
+						"need_local_copy": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether to download the contents of all the items from the publisher during sync. Otherwise, the contents are downloaded on demand, when they are used",
+						},
+					},
+				},
+			},
+			"publish_config": {
+				Type:          schema.TypeList,
+				MaxItems:      1,
+				Optional:      true,
+				ConflictsWith: []string{"subscription_config"},
+				Description:   fmt.Sprintf("A block representing publishing settings of a %s, so other libraries can subscribe to it", labelVcfaContentLibrary),
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: fmt.Sprintf("Whether this %s is published", labelVcfaContentLibrary),
+						},
+						"password": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   true,
+							Description: "Password that subscribers must use to authenticate with this publisher",
+						},
+						"subscription_url": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: fmt.Sprintf("URL that other libraries can use to subscribe to this %s", labelVcfaContentLibrary),
+						},
 					},
 				},
 			},
@@ -137,6 +181,13 @@ func resourceVcfaContentLibraryCreate(ctx context.Context, d *schema.ResourceDat
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	d.SetId(cl.ContentLibrary.ID)
+	if publishParams := getContentLibraryPublishParams(d); publishParams.IsPublishedExternally != nil && *publishParams.IsPublishedExternally {
+		err = publishContentLibrary(tmClient, cl, publishParams)
+		if err != nil {
+			return diag.Errorf("error publishing %s '%s': %s", labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+		}
+	}
 	err = setContentLibraryData(tmClient, d, cl, "resource")
 	if err != nil {
 		return diag.FromErr(err)
@@ -171,7 +222,32 @@ func resourceVcfaContentLibraryRead(_ context.Context, d *schema.ResourceData, m
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	return nil
+
+	if !d.Get("delete_recursive").(bool) && !d.Get("delete_force").(bool) {
+		return nil
+	}
+	// Warn about the contents that would be removed by a deletion, so they are visible during plan
+	unacknowledged, subscribers, err := getContentLibraryDeletionImpact(tmClient, d, cl)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(unacknowledged) == 0 && len(subscribers) == 0 {
+		return nil
+	}
+	var details []string
+	if len(unacknowledged) > 0 {
+		details = append(details, fmt.Sprintf("It contains %d %ss that are not in 'delete_acknowledged_items', so the deletion would fail: %s",
+			len(unacknowledged), labelVcfaContentLibraryItem, strings.Join(unacknowledged, ", ")))
+	}
+	if len(subscribers) > 0 {
+		details = append(details, fmt.Sprintf("It is published, so the deletion would leave %d subscribed %ss without publisher: %s",
+			len(subscribers), labelVcfaContentLibrary, strings.Join(subscribers, ", ")))
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("If %s '%s' is deleted, its contents will be removed", labelVcfaContentLibrary, cl.ContentLibrary.Name),
+		Detail:   strings.Join(details, ".\n") + ".",
+	}}
 }
 
 func resourceVcfaContentLibraryUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -184,10 +260,30 @@ func resourceVcfaContentLibraryUpdate(ctx context.Context, d *schema.ResourceDat
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	_, err = cl.Update(getContentLibraryType(d))
+	cl, err = cl.Update(getContentLibraryType(d))
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	if d.HasChange("storage_class_ids") {
+		// VCFA may not apply all the changes, for example, when a Storage Class is still used by some items
+		applied := make([]string, len(cl.ContentLibrary.StorageClasses))
+		for i, sc := range cl.ContentLibrary.StorageClasses {
+			applied[i] = sc.ID
+		}
+		requested := convertTypeListToSliceOfStrings(d.Get("storage_class_ids").(*schema.Set).List())
+		slices.Sort(applied)
+		slices.Sort(requested)
+		if !slices.Equal(applied, requested) {
+			return diag.Errorf("error updating the Storage Classes of %s '%s': requested %v, but VCFA applied %v", labelVcfaContentLibrary,
+				cl.ContentLibrary.Name, requested, applied)
+		}
+	}
+	if d.HasChange("publish_config") {
+		err = publishContentLibrary(tmClient, cl, getContentLibraryPublishParams(d))
+		if err != nil {
+			return diag.Errorf("error updating publishing settings of %s '%s': %s", labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+		}
+	}
 	return resourceVcfaContentLibraryRead(ctx, d, meta)
 }
 
@@ -202,6 +298,15 @@ func resourceVcfaContentLibraryDelete(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
+	unacknowledged, _, err := getContentLibraryDeletionImpact(tmClient, d, cl)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(unacknowledged) > 0 {
+		return diag.Errorf("refusing to delete %s '%s', as it contains %d %ss that are not in 'delete_acknowledged_items': %s",
+			labelVcfaContentLibrary, cl.ContentLibrary.Name, len(unacknowledged), labelVcfaContentLibraryItem, strings.Join(unacknowledged, ", "))
+	}
+
 	deleteForce := d.Get("delete_force").(bool)
 	if cl.ContentLibrary.LibraryType != "PROVIDER" {
 		deleteForce = false // Forcefully deletion is not available for non-PROVIDER Content Libraries
@@ -260,12 +365,74 @@ func getContentLibraryType(d *schema.ResourceData) *types.ContentLibrary {
 		t.SubscriptionConfig = &types.ContentLibrarySubscriptionConfig{
 			SubscriptionUrl: subsConfig["subscription_url"].(string),
 			Password:        subsConfig["password"].(string),
+			NeedLocalCopy:   subsConfig["need_local_copy"].(bool),
 		}
 	}
 	return t
 }
 
-func setContentLibraryData(_ *VCDClient, d *schema.ResourceData, cl *govcd.ContentLibrary, origin string) error {
+// getContentLibraryDeletionImpact returns the names of the items of the given Content Library that are not acknowledged
+// in 'delete_acknowledged_items', and the names of the Content Libraries that are subscribed to it, sorted. The
+// subscribers are only searched when the Content Library is published, as that requires listing all Content Libraries
+func getContentLibraryDeletionImpact(tmClient *VCDClient, d *schema.ResourceData, cl *govcd.ContentLibrary) ([]string, []string, error) {
+	acknowledged := convertTypeListToSliceOfStrings(d.Get("delete_acknowledged_items").(*schema.Set).List())
+	var unacknowledged []string
+	if !slices.Contains(acknowledged, "*") {
+		items, err := cl.GetAllContentLibraryItems(nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error retrieving %ss of %s '%s': %s", labelVcfaContentLibraryItem, labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+		}
+		for _, item := range items {
+			if !slices.Contains(acknowledged, item.ContentLibraryItem.Name) {
+				unacknowledged = append(unacknowledged, item.ContentLibraryItem.Name)
+			}
+		}
+		slices.Sort(unacknowledged)
+	}
+
+	var subscribers []string
+	published, _ := d.Get("publish_config.0.enabled").(bool)
+	publishUrl, _ := d.Get("publish_config.0.subscription_url").(string)
+	if !published || publishUrl == "" {
+		return unacknowledged, subscribers, nil
+	}
+	libraries, err := tmClient.GetAllContentLibraries(nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving the %ss that are subscribed to '%s': %s", labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+	}
+	for _, library := range libraries {
+		if library.ContentLibrary.SubscriptionConfig != nil && library.ContentLibrary.SubscriptionConfig.SubscriptionUrl == publishUrl {
+			subscribers = append(subscribers, library.ContentLibrary.Name)
+		}
+	}
+	slices.Sort(subscribers)
+	return unacknowledged, subscribers, nil
+}
+
+// getContentLibraryPublishParams returns the publishing settings of the Content Library from the 'publish_config' block.
+// When the block is not present, publishing is disabled
+func getContentLibraryPublishParams(d *schema.ResourceData) types.PublishExternalCatalogParams {
+	params := types.PublishExternalCatalogParams{
+		IsPublishedExternally: addrOf(false),
+	}
+	if v, ok := d.GetOk("publish_config"); ok && v.([]interface{})[0] != nil {
+		publishConfig := v.([]interface{})[0].(map[string]interface{})
+		params.IsPublishedExternally = addrOf(publishConfig["enabled"].(bool))
+		params.Password = publishConfig["password"].(string)
+	}
+	return params
+}
+
+// publishContentLibrary applies the given publishing settings to the catalog that backs the given Content Library
+func publishContentLibrary(tmClient *VCDClient, cl *govcd.ContentLibrary, params types.PublishExternalCatalogParams) error {
+	catalog, err := tmClient.Client.GetAdminCatalogById(cl.ContentLibrary.ID)
+	if err != nil {
+		return err
+	}
+	return catalog.PublishToExternalOrganizations(params)
+}
+
+func setContentLibraryData(tmClient *VCDClient, d *schema.ResourceData, cl *govcd.ContentLibrary, origin string) error {
 	if cl == nil || cl.ContentLibrary == nil {
 		return fmt.Errorf("provided %s is nil", labelVcfaContentLibrary)
 	}
@@ -296,6 +463,7 @@ func setContentLibraryData(_ *VCDClient, d *schema.ResourceData, cl *govcd.Conte
 		subscriptionConfig = []interface{}{
 			map[string]interface{}{
 				"subscription_url": cl.ContentLibrary.SubscriptionConfig.SubscriptionUrl,
+				"need_local_copy":  cl.ContentLibrary.SubscriptionConfig.NeedLocalCopy,
 			},
 		}
 		// Password is only available in resource
@@ -312,6 +480,45 @@ func setContentLibraryData(_ *VCDClient, d *schema.ResourceData, cl *govcd.Conte
 		return err
 	}
 
+	// Publishing settings are only available in the catalog that backs the Content Library, which requires
+	// rights that tenant users may not have. Hence, it only fails if the publishing settings are configured
+	publishConfigured := len(d.Get("publish_config").([]interface{})) > 0
+	catalog, err := tmClient.Client.GetAdminCatalogById(cl.ContentLibrary.ID)
+	if err != nil {
+		if publishConfigured {
+			return fmt.Errorf("could not retrieve the publishing settings of %s '%s': %s", labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+		}
+		log.Printf("[DEBUG] could not retrieve the publishing settings of %s '%s', skipping them: %s", labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+		d.SetId(cl.ContentLibrary.ID)
+		return nil
+	}
+	publishConfig := make([]interface{}, 0)
+	publishParams := catalog.AdminCatalog.PublishExternalCatalogParams
+	isPublished := publishParams != nil && publishParams.IsPublishedExternally != nil && *publishParams.IsPublishedExternally
+	// A disabled configuration is kept if it was explicitly set
+	if isPublished || publishConfigured {
+		publishConfig = []interface{}{
+			map[string]interface{}{
+				"enabled":          isPublished,
+				"subscription_url": "",
+			},
+		}
+		if publishParams != nil {
+			publishConfig[0].(map[string]interface{})["subscription_url"] = publishParams.CatalogPublishedUrl
+		}
+		// Password is only available in resource
+		if origin == "resource" {
+			// Password is never returned by backend. We save what we have currently
+			if p := d.Get("publish_config.0.password"); p != "" {
+				publishConfig[0].(map[string]interface{})["password"] = p
+			}
+		}
+	}
+	err = d.Set("publish_config", publishConfig)
+	if err != nil {
+		return err
+	}
+
 	d.SetId(cl.ContentLibrary.ID)
 	return nil
 }
@@ -7,13 +7,18 @@ package vcfa
 import (
 	"context"
 	"fmt"
-	"path/filepath"
+	"os"
+	"reflect"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vmware/go-vcloud-director/v3/govcd"
 	"github.com/vmware/go-vcloud-director/v3/types/v56"
+	"github.com/vmware/go-vcloud-director/v3/util"
 )
 
 const labelVcfaContentLibraryItem = "Content Library Item"
@@ -24,6 +29,7 @@ func resourceVcfaContentLibraryItem() *schema.Resource {
 		ReadContext:   resourceVcfaContentLibraryItemRead,
 		UpdateContext: resourceVcfaContentLibraryItemUpdate,
 		DeleteContext: resourceVcfaContentLibraryItemDelete,
+		CustomizeDiff: resourceVcfaContentLibraryItemCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceVcfaContentLibraryItemImport,
 		},
@@ -46,20 +52,102 @@ func resourceVcfaContentLibraryItem() *schema.Resource {
 				Description: fmt.Sprintf("ID of the %s that this %s belongs to", labelVcfaContentLibrary, labelVcfaContentLibraryItem),
 			},
 			"file_paths": {
-				Type:        schema.TypeSet,
-				Optional:    true, // Not needed when Importing
-				ForceNew:    true,
-				Description: fmt.Sprintf("A single path to an OVA/ISO, or multiple paths for an OVF and its referenced files, to create the %s", labelVcfaContentLibraryItem),
+				Type:          schema.TypeSet,
+				Optional:      true, // Not needed when Importing
+				Description:   fmt.Sprintf("A single path to an OVA/ISO, or the path to an OVF descriptor and optionally its referenced files, to create the %s. When the content of the files changes, it is uploaded as a new version of the %s", labelVcfaContentLibraryItem, labelVcfaContentLibraryItem),
+				ConflictsWith: []string{"source_url"},
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"file_checksums": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "SHA-256 checksums, in hexadecimal format, of the files in 'file_paths', keyed by file name",
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
 			},
+			"file_stats": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Sizes and modification times of the files in 'file_paths', keyed by file name. The checksums are only computed again when they change",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"source_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  fmt.Sprintf("HTTP(S) URL of an OVA, ISO or OVF file to create the %s. The file is streamed to VCFA without storing it locally", labelVcfaContentLibraryItem),
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"source_checksum": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"source_url"},
+				Description:  "SHA-256 checksum, in hexadecimal format, that the file in 'source_url' must match. It can be prefixed with 'sha256:'",
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{64}$`), "must be a SHA-256 checksum in hexadecimal format"),
+			},
+			"source_auth_header": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"source_url"},
+				Description:  "Value of the 'Authorization' header that is sent to the host of 'source_url', like 'Bearer <token>'",
+			},
 			"upload_piece_size": {
 				Type:        schema.TypeInt,
 				Optional:    true,
 				Default:     1,
 				Description: fmt.Sprintf("When uploading the %s, this argument defines the size of the file chunks in which it is split on every upload request. It can possibly impact upload performance. Default 1 MB", labelVcfaContentLibraryItem),
 			},
+			"upload_concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				Description:  fmt.Sprintf("When uploading the %s, this argument defines how many file chunks are uploaded at the same time. Default 1", labelVcfaContentLibraryItem),
+				ValidateFunc: validation.IntBetween(1, 16),
+			},
+			"upload_progress_interval": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				Description:  fmt.Sprintf("Interval, in seconds, between the logs that report the upload and processing progress of the %s. 0 disables them. Default 30", labelVcfaContentLibraryItem),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"quarantine_approval": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Description: fmt.Sprintf("Decision to take when the %s is quarantined after uploading its files, when the Organization "+
+					"has 'quarantine_content_library_items' enabled. Without it, the upload waits until someone else approves the %s",
+					labelVcfaContentLibraryItem, labelVcfaContentLibraryItem),
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"approve": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: fmt.Sprintf("Whether to approve the quarantined %s. Otherwise, it is rejected and removed", labelVcfaContentLibraryItem),
+						},
+						"sha256": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Description: "SHA-256 checksum, in hexadecimal format, that the ISO file, the OVA archive or the OVF manifest must match to be approved. " +
+								"For OVF descriptors, the descriptor and its referenced files must also match the manifest. Otherwise, it is rejected. It can be prefixed with 'sha256:'",
+							ValidateFunc: validation.StringMatch(regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{64}$`), "must be a SHA-256 checksum in hexadecimal format"),
+						},
+						"message": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Message that is recorded when approving or rejecting",
+						},
+					},
+				},
+			},
 			"creation_date": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -118,45 +206,40 @@ func resourceVcfaContentLibraryItemCreate(ctx context.Context, d *schema.Resourc
 		return diag.Errorf("could not retrieve %s with ID '%s': %s", labelVcfaContentLibrary, clId, err)
 	}
 
-	if _, ok := d.GetOk("file_paths"); !ok {
-		return diag.Errorf("the argument 'file_paths' is required during creation")
-	}
-
-	uploadArgs := govcd.ContentLibraryItemUploadArguments{
-		UploadPieceSize: int64(d.Get("upload_piece_size").(int)) * 1024 * 1024,
-	}
-
-	filePaths := d.Get("file_paths").(*schema.Set).List()
-	if len(filePaths) == 1 {
-		p := filepath.Clean(filePaths[0].(string))
-		if filepath.Ext(p) != ".iso" && filepath.Ext(p) != ".ova" {
-			return diag.Errorf("when uploading a single file, only ISO/OVA is supported. OVF requires multiple files")
-		}
-		// ISO/OVA
-		uploadArgs.FilePath = p
-	} else {
-		// OVF. We have to search for the descriptor.ovf inside the TypeSet.
-		ovfFound := false
-		for _, p := range filePaths {
-			cleanedPath := filepath.Clean(p.(string))
-			if filepath.Ext(cleanedPath) == ".ovf" {
-				uploadArgs.FilePath = cleanedPath
-				ovfFound = true
-			} else {
-				uploadArgs.OvfFilesPaths = append(uploadArgs.OvfFilesPaths, cleanedPath)
+	var source *contentLibraryItemSource
+	if sourceUrl, ok := d.GetOk("source_url"); ok {
+		source, err = getContentLibraryItemUrlSource(newContentLibraryItemSourceHttpClient(tmClient), sourceUrl.(string), d.Get("source_auth_header").(string))
+	} else if filePaths, ok := d.GetOk("file_paths"); ok {
+		source, err = getContentLibraryItemLocalSource(convertSchemaSetToSliceOfStrings(filePaths.(*schema.Set)))
+		// The checksums are unknown during plan if the files did not exist yet
+		if _, ok := d.GetOk("file_checksums"); !ok && err == nil {
+			var checksums, stats map[string]string
+			stats, err = getContentLibraryItemFileStats(source.files)
+			if err == nil {
+				checksums, err = getContentLibraryItemFileChecksums(source.files)
+			}
+			if err == nil {
+				err = d.Set("file_checksums", checksums)
+			}
+			if err == nil {
+				err = d.Set("file_stats", stats)
 			}
 		}
-		if !ovfFound {
-			return diag.Errorf("could not find the 'descriptor.ovf' file in any of the provided paths: %v", filePaths)
-		}
+	} else {
+		return diag.Errorf("one of the arguments 'file_paths' or 'source_url' is required during creation")
 	}
+	if err != nil {
+		return diag.Errorf("error reading %s source: %s", labelVcfaContentLibraryItem, err)
+	}
+
+	args := getContentLibraryItemUploadArguments(d, meta)
 
 	c := crudConfig[*govcd.ContentLibraryItem, types.ContentLibraryItem]{
 		entityLabel:    labelVcfaContentLibraryItem,
 		getTypeFunc:    getContentLibraryItemType,
 		stateStoreFunc: setContentLibraryItemData,
 		createFunc: func(config *types.ContentLibraryItem) (*govcd.ContentLibraryItem, error) {
-			return cl.CreateContentLibraryItem(config, uploadArgs)
+			return uploadContentLibraryItem(ctx, tmClient, cl, config, source, args)
 		},
 		resourceReadFunc: resourceVcfaContentLibraryItemRead,
 	}
@@ -172,6 +255,26 @@ func resourceVcfaContentLibraryItemUpdate(ctx context.Context, d *schema.Resourc
 		return diag.Errorf("could not retrieve Content Library with ID '%s': %s", clId, err)
 	}
 
+	// Items that were imported, or created before the checksums were computed, only need to save them, as their
+	// content cannot be compared
+	oldChecksums, _ := d.GetChange("file_checksums")
+	if d.HasChange("file_checksums") && len(oldChecksums.(map[string]interface{})) > 0 {
+		cli, err := cl.GetContentLibraryItemById(d.Id())
+		if err != nil {
+			return diag.Errorf("error getting %s for update: %s", labelVcfaContentLibraryItem, err)
+		}
+		source, err := getContentLibraryItemLocalSource(convertSchemaSetToSliceOfStrings(d.Get("file_paths").(*schema.Set)))
+		if err != nil {
+			return diag.Errorf("error reading %s source: %s", labelVcfaContentLibraryItem, err)
+		}
+		_, err = uploadContentLibraryItemVersion(ctx, tmClient, cl, cli, source, getContentLibraryItemUploadArguments(d, meta))
+		if err != nil {
+			// Keep the previous checksums, so the upload is attempted again in the next apply
+			d.Partial(true)
+			return diag.FromErr(err)
+		}
+	}
+
 	c := crudConfig[*govcd.ContentLibraryItem, types.ContentLibraryItem]{
 		entityLabel:      labelVcfaContentLibraryItem,
 		getTypeFunc:      getContentLibraryItemType,
@@ -182,6 +285,95 @@ func resourceVcfaContentLibraryItemUpdate(ctx context.Context, d *schema.Resourc
 	return updateResource(ctx, d, meta, c)
 }
 
+// resourceVcfaContentLibraryItemCustomizeDiff computes the checksums of the local files, so a new version of the
+// Content Library Item is uploaded when their content changes. Changing the type of the item requires a new one.
+// As the files can be big, their checksums are only computed again when their sizes or modification times change.
+func resourceVcfaContentLibraryItemCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("file_paths") {
+		if d.Id() != "" {
+			if err := d.SetNewComputed("version"); err != nil {
+				return err
+			}
+		}
+		if err := d.SetNewComputed("file_stats"); err != nil {
+			return err
+		}
+		return d.SetNewComputed("file_checksums")
+	}
+
+	filePaths := convertSchemaSetToSliceOfStrings(d.Get("file_paths").(*schema.Set))
+	if len(filePaths) == 0 {
+		return nil
+	}
+	for _, filePath := range filePaths {
+		if _, err := os.Stat(filePath); err != nil {
+			if d.Id() == "" {
+				// The files may be created during apply
+				if err := d.SetNewComputed("file_stats"); err != nil {
+					return err
+				}
+				return d.SetNewComputed("file_checksums")
+			}
+			// The files that were used to create the item may not be present anymore, which is not a reason to upload it again
+			util.Logger.Printf("[DEBUG] could not read the files of %s '%s': %s", labelVcfaContentLibraryItem, d.Id(), err)
+			return nil
+		}
+	}
+
+	// When the files of the previous upload did not change, they are not validated again, as that reads big files
+	// more than once. If their sizes and modification times are the same, they are not read at all
+	oldChecksums, _ := d.GetChange("file_checksums")
+	oldStats, _ := d.GetChange("file_stats")
+	if d.Id() != "" && len(oldChecksums.(map[string]interface{})) > 0 {
+		previousChecksums := convertToStringMap(oldChecksums.(map[string]interface{}))
+		if previousFiles, ok := findContentLibraryItemLocalFiles(filePaths, previousChecksums); ok {
+			stats, err := getContentLibraryItemFileStats(previousFiles)
+			if err == nil && reflect.DeepEqual(convertToStringMap(oldStats.(map[string]interface{})), stats) {
+				return nil
+			}
+			checksums, err := getContentLibraryItemFileChecksums(previousFiles)
+			if err == nil && reflect.DeepEqual(previousChecksums, checksums) {
+				// The files were touched without changing their content
+				return d.SetNew("file_stats", stats)
+			}
+		}
+	}
+
+	// The files are validated here, so any problem is reported before the upload starts
+	source, err := getContentLibraryItemLocalSource(filePaths)
+	if err != nil {
+		return err
+	}
+	_ = source.close()
+	stats, err := getContentLibraryItemFileStats(source.files)
+	if err != nil {
+		return err
+	}
+	checksums, err := getContentLibraryItemFileChecksums(source.files)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(convertToStringMap(oldStats.(map[string]interface{})), stats) {
+		if err := d.SetNew("file_stats", stats); err != nil {
+			return err
+		}
+	}
+	if reflect.DeepEqual(convertToStringMap(oldChecksums.(map[string]interface{})), checksums) {
+		return nil
+	}
+	if err := d.SetNew("file_checksums", checksums); err != nil {
+		return err
+	}
+	if d.Id() == "" || len(oldChecksums.(map[string]interface{})) == 0 {
+		return nil
+	}
+
+	if source.itemType != d.Get("item_type").(string) {
+		return d.ForceNew("file_checksums")
+	}
+	return d.SetNewComputed("version")
+}
+
 func resourceVcfaContentLibraryItemRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	tmClient := meta.(ClientContainer).tmClient
 
@@ -260,6 +452,33 @@ func resourceVcfaContentLibraryItemImport(_ context.Context, d *schema.ResourceD
 	return []*schema.ResourceData{d}, nil
 }
 
+// getContentLibraryItemUploadArguments returns the arguments that tune the upload of the Content Library Item files
+func getContentLibraryItemUploadArguments(d *schema.ResourceData, meta interface{}) contentLibraryItemUploadArguments {
+	return contentLibraryItemUploadArguments{
+		pieceSize:        int64(d.Get("upload_piece_size").(int)) * 1024 * 1024,
+		concurrency:      d.Get("upload_concurrency").(int),
+		expectedChecksum: strings.TrimPrefix(d.Get("source_checksum").(string), "sha256:"),
+		uploadSlots:      meta.(ClientContainer).uploadSlots,
+		progressInterval: time.Duration(d.Get("upload_progress_interval").(int)) * time.Second,
+		approval:         getContentLibraryItemQuarantineApproval(d),
+	}
+}
+
+// getContentLibraryItemQuarantineApproval returns the decision to take when the Content Library Item is quarantined, or
+// nil if the 'quarantine_approval' block is not set
+func getContentLibraryItemQuarantineApproval(d *schema.ResourceData) *contentLibraryItemQuarantineApproval {
+	blocks := d.Get("quarantine_approval").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+	return &contentLibraryItemQuarantineApproval{
+		approve:          block["approve"].(bool),
+		expectedChecksum: strings.TrimPrefix(block["sha256"].(string), "sha256:"),
+		message:          block["message"].(string),
+	}
+}
+
 func getContentLibraryItemType(_ *VCDClient, d *schema.ResourceData) (*types.ContentLibraryItem, error) {
 	t := &types.ContentLibraryItem{
 		Name:        d.Get("name").(string),
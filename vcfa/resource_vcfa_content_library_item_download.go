@@ -0,0 +1,222 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceVcfaContentLibraryItemDownload() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaContentLibraryItemDownloadCreate,
+		ReadContext:   resourceVcfaContentLibraryItemDownloadRead,
+		UpdateContext: resourceVcfaContentLibraryItemDownloadUpdate,
+		DeleteContext: resourceVcfaContentLibraryItemDownloadDelete,
+		CustomizeDiff: resourceVcfaContentLibraryItemDownloadCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"content_library_item_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("ID of the %s to download", labelVcfaContentLibraryItem),
+			},
+			"download_path": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Local directory where the files are downloaded. It is created if it does not exist",
+			},
+			"overwrite": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Whether to replace the files that already exist in 'download_path'",
+			},
+			"expected_checksums": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "SHA-256 checksums, in hexadecimal format, that the downloaded files must have, keyed by file name",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Arbitrary map of values that, when changed, download the files again",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"item_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Name of the downloaded %s", labelVcfaContentLibraryItem),
+			},
+			"item_type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Type of the downloaded %s, either ISO or TEMPLATE", labelVcfaContentLibraryItem),
+			},
+			"item_version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: fmt.Sprintf("Version of the %s when it was downloaded", labelVcfaContentLibraryItem),
+			},
+			"file_paths": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: fmt.Sprintf("Paths of the downloaded files, that can be used as 'file_paths' of another %s", labelVcfaContentLibraryItem),
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"file_checksums": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "SHA-256 checksums, in hexadecimal format, of the downloaded files, keyed by file name",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func resourceVcfaContentLibraryItemDownloadCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return downloadContentLibraryItem(ctx, d, meta, nil)
+}
+
+// resourceVcfaContentLibraryItemDownloadUpdate downloads the files again, replacing the ones of the previous download
+func resourceVcfaContentLibraryItemDownloadUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	oldFilePaths, _ := d.GetChange("file_paths")
+	return downloadContentLibraryItem(ctx, d, meta, convertTypeListToSliceOfStrings(oldFilePaths.([]interface{})))
+}
+
+// downloadContentLibraryItem downloads and verifies the files of the Content Library Item. The files in 'ownedPaths'
+// belong to a previous download of this resource, so they can be replaced even if 'overwrite' is not set
+func downloadContentLibraryItem(ctx context.Context, d *schema.ResourceData, meta interface{}, ownedPaths []string) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+
+	id := d.Get("content_library_item_id").(string)
+	cli, err := tmClient.GetContentLibraryItemById(id)
+	if err != nil {
+		return diag.Errorf("could not retrieve %s with ID '%s': %s", labelVcfaContentLibraryItem, id, err)
+	}
+
+	filePaths, err := downloadContentLibraryItemFiles(ctx, tmClient, cli, d.Get("download_path").(string), d.Get("overwrite").(bool), ownedPaths)
+	if err != nil {
+		return diag.Errorf("error downloading %s '%s': %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name, err)
+	}
+
+	// The downloaded files must be valid to be uploaded again, so they are validated the same way. When they are not,
+	// they are removed, as they would make the next attempt fail
+	source, err := getContentLibraryItemLocalSource(filePaths)
+	if err != nil {
+		removeContentLibraryItemDownloadedFiles(filePaths)
+		return diag.Errorf("the downloaded files of %s '%s' are not valid: %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name, err)
+	}
+	checksums, err := getContentLibraryItemFileChecksums(source.files)
+	if err != nil {
+		removeContentLibraryItemDownloadedFiles(filePaths)
+		return diag.Errorf("error calculating the checksums of the downloaded files of %s '%s': %s", labelVcfaContentLibraryItem, cli.ContentLibraryItem.Name, err)
+	}
+	if problems := compareContentLibraryItemChecksums(convertToStringMap(d.Get("expected_checksums").(map[string]interface{})), checksums); len(problems) > 0 {
+		removeContentLibraryItemDownloadedFiles(filePaths)
+		return diag.Errorf("the checksums of the downloaded files of %s '%s' do not match:\n  - %s", labelVcfaContentLibraryItem,
+			cli.ContentLibraryItem.Name, strings.Join(problems, "\n  - "))
+	}
+
+	d.SetId(cli.ContentLibraryItem.ID)
+	dSet(d, "item_name", cli.ContentLibraryItem.Name)
+	dSet(d, "item_type", cli.ContentLibraryItem.ItemType)
+	dSet(d, "item_version", cli.ContentLibraryItem.Version)
+	err = d.Set("file_paths", source.files)
+	if err != nil {
+		return diag.Errorf("error setting file_paths: %s", err)
+	}
+	err = d.Set("file_checksums", checksums)
+	if err != nil {
+		return diag.Errorf("error setting file_checksums: %s", err)
+	}
+	return resourceVcfaContentLibraryItemDownloadRead(ctx, d, meta)
+}
+
+// resourceVcfaContentLibraryItemDownloadRead does not modify the state, as the downloaded files are local. When they
+// are removed or modified, the plan downloads them again, see resourceVcfaContentLibraryItemDownloadCustomizeDiff
+func resourceVcfaContentLibraryItemDownloadRead(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	if isContentLibraryItemDownloadModified(d.Get("file_paths").([]interface{}), d.Get("file_checksums").(map[string]interface{})) {
+		log.Printf("[DEBUG] downloaded files of %s '%s' were removed or modified", labelVcfaContentLibraryItem, d.Get("item_name"))
+	}
+	return nil
+}
+
+// resourceVcfaContentLibraryItemDownloadCustomizeDiff plans a new download when any of the downloaded files was removed
+// or modified locally. The resource is kept in state, so the new download can replace its own files
+func resourceVcfaContentLibraryItemDownloadCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+	if d.HasChange("triggers") || d.HasChange("expected_checksums") ||
+		isContentLibraryItemDownloadModified(d.Get("file_paths").([]interface{}), d.Get("file_checksums").(map[string]interface{})) {
+		for _, key := range []string{"item_name", "item_type", "item_version", "file_paths", "file_checksums"} {
+			if err := d.SetNewComputed(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isContentLibraryItemDownloadModified returns whether any of the given downloaded files was removed, or does not have
+// the given checksum anymore
+func isContentLibraryItemDownloadModified(rawFilePaths []interface{}, rawChecksums map[string]interface{}) bool {
+	filePaths := convertTypeListToSliceOfStrings(rawFilePaths)
+	for _, filePath := range filePaths {
+		if _, err := os.Stat(filePath); err != nil {
+			return true
+		}
+	}
+	checksums, err := getContentLibraryItemFileChecksums(filePaths)
+	if err != nil {
+		log.Printf("[DEBUG] error calculating the checksums of the downloaded files: %s", err)
+		return true
+	}
+	return !reflect.DeepEqual(checksums, convertToStringMap(rawChecksums))
+}
+
+func resourceVcfaContentLibraryItemDownloadDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	// The downloaded files are kept, as they are usually backups or inputs of other resources
+	d.SetId("")
+	return nil
+}
+
+// compareContentLibraryItemChecksums returns the differences between the expected and the actual checksums, sorted by
+// file name. Only the files with an expected checksum are compared
+func compareContentLibraryItemChecksums(expected, actual map[string]string) []string {
+	var problems []string
+	for name, checksum := range expected {
+		actualChecksum, ok := actual[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("'%s' was not downloaded", name))
+			continue
+		}
+		if !strings.EqualFold(checksum, actualChecksum) {
+			problems = append(problems, fmt.Sprintf("'%s' has SHA-256 checksum %s, but %s was expected", name, actualChecksum, checksum))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
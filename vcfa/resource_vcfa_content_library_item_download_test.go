@@ -0,0 +1,158 @@
+//go:build tm || contentlibrary || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// TestAccVcfaContentLibraryItemDownload tests that a Content Library Item can be downloaded and uploaded again as
+// a different Content Library Item
+func TestAccVcfaContentLibraryItemDownload(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfNotSysAdmin(t)
+
+	nsxManagerHcl, nsxManagerHclRef := getNsxManagerHcl(t)
+	vCenterHcl, vCenterHclRef := getVCenterHcl(t, nsxManagerHclRef)
+	regionHcl, regionHclRef := getRegionHcl(t, vCenterHclRef, nsxManagerHclRef)
+	contentLibraryHcl, contentLibraryHclRef := getContentLibraryHcl(t, regionHclRef, "")
+
+	isoContents, err := os.ReadFile("../test-resources/test.iso")
+	if err != nil {
+		t.Fatalf("error reading ISO file: %s", err)
+	}
+	isoChecksum := sha256.Sum256(isoContents)
+
+	var params = StringMap{
+		"Name":              t.Name(),
+		"ContentLibraryRef": fmt.Sprintf("%s.id", contentLibraryHclRef),
+		"IsoPath":           getTestingResourcesAbsolutePaths(t, []string{"../test-resources/test.iso"})[0],
+		"DownloadPath":      t.TempDir(),
+		"Tags":              "tm contentlibrary",
+	}
+	testParamsNotEmpty(t, params)
+
+	preRequisites := vCenterHcl + nsxManagerHcl + regionHcl + contentLibraryHcl
+
+	configText1 := templateFill(preRequisites+testAccVcfaContentLibraryItemDownloadStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	configText2 := templateFill(preRequisites+testAccVcfaContentLibraryItemDownloadStep2, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	download := "vcfa_content_library_item_download.download"
+	cliCopy := "vcfa_content_library_item.cli_copy"
+	cachedFilePath := &testCachedFieldValue{}
+
+	checkDownloadedChecksums := func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[download]
+		if !ok {
+			return fmt.Errorf("resource not found: %s", download)
+		}
+		for key, value := range rs.Primary.Attributes {
+			if regexp.MustCompile(`^file_checksums\.[^%]`).MatchString(key) && value != hex.EncodeToString(isoChecksum[:]) {
+				return fmt.Errorf("%s has checksum %s, but %s was expected", key, value, hex.EncodeToString(isoChecksum[:]))
+			}
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair(download, "id", "vcfa_content_library_item.cli", "id"),
+					resource.TestCheckResourceAttr(download, "item_name", t.Name()),
+					resource.TestCheckResourceAttr(download, "item_type", "ISO"),
+					resource.TestCheckResourceAttr(download, "item_version", "1"),
+					resource.TestCheckResourceAttr(download, "file_paths.#", "1"),
+					resource.TestMatchResourceAttr(download, "file_paths.0", regexp.MustCompile("^"+regexp.QuoteMeta(params["DownloadPath"].(string)))),
+					resource.TestCheckResourceAttr(download, "file_checksums.%", "1"),
+					checkDownloadedChecksums,
+					cachedFilePath.cacheTestResourceFieldValue(download, "file_paths.0"),
+
+					// The downloaded files are uploaded again
+					resource.TestCheckResourceAttr(cliCopy, "item_type", "ISO"),
+					resource.TestCheckResourceAttr(cliCopy, "status", "READY"),
+					resource.TestCheckResourceAttrPair(cliCopy, "file_checksums.%", download, "file_checksums.%"),
+				),
+			},
+			{
+				// A modified file is downloaded again, replacing the one of the previous download even if
+				// 'overwrite' is not set
+				PreConfig: func() {
+					err := os.WriteFile(cachedFilePath.fieldValue, []byte("modified"), 0600)
+					if err != nil {
+						t.Fatalf("error modifying the downloaded file: %s", err)
+					}
+				},
+				Config: configText2,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair(download, "id", "vcfa_content_library_item.cli", "id"),
+					cachedFilePath.testCheckCachedResourceFieldValue(download, "file_paths.0"),
+					resource.TestCheckResourceAttr(download, "file_checksums.%", "1"),
+					checkDownloadedChecksums,
+				),
+			},
+		},
+	})
+}
+
+const testAccVcfaContentLibraryItemDownloadStep1 = `
+# skip-binary-test: Requires a temporary download directory
+
+resource "vcfa_content_library_item" "cli" {
+  name               = "{{.Name}}"
+  description        = "{{.Name}}"
+  content_library_id = {{.ContentLibraryRef}}
+  file_paths         = ["{{.IsoPath}}"]
+}
+
+resource "vcfa_content_library_item_download" "download" {
+  content_library_item_id = vcfa_content_library_item.cli.id
+  download_path           = "{{.DownloadPath}}"
+}
+
+resource "vcfa_content_library_item" "cli_copy" {
+  name               = "{{.Name}}Copy"
+  description        = "{{.Name}}Copy"
+  content_library_id = {{.ContentLibraryRef}}
+  file_paths         = vcfa_content_library_item_download.download.file_paths
+}
+`
+
+const testAccVcfaContentLibraryItemDownloadStep2 = `
+# skip-binary-test: Requires a temporary download directory
+
+resource "vcfa_content_library_item" "cli" {
+  name               = "{{.Name}}"
+  description        = "{{.Name}}"
+  content_library_id = {{.ContentLibraryRef}}
+  file_paths         = ["{{.IsoPath}}"]
+}
+
+resource "vcfa_content_library_item_download" "download" {
+  content_library_item_id = vcfa_content_library_item.cli.id
+  download_path           = "{{.DownloadPath}}"
+}
+`
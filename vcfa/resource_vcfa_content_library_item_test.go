@@ -7,10 +7,18 @@
 package vcfa
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -108,6 +116,8 @@ func TestAccVcfaContentLibraryItemProvider(t *testing.T) {
 					resource.TestCheckResourceAttr(cli2, "is_published", "false"),
 					resource.TestCheckResourceAttrSet(cli2, "image_identifier"),
 					resource.TestCheckResourceAttr(cli2, "item_type", "ISO"),
+					resource.TestCheckResourceAttr(cli2, "file_checksums.%", "1"),
+					resource.TestCheckResourceAttrSet(cli2, "file_checksums.test.iso"),
 					resource.TestCheckResourceAttrPair(cli2, "owner_org_id", "data.vcfa_org.system", "id"),
 					resource.TestCheckResourceAttr(cli2, "status", "READY"),
 					resource.TestCheckResourceAttr(cli2, "last_successful_sync", ""),
@@ -147,10 +157,28 @@ func TestAccVcfaContentLibraryItemProvider(t *testing.T) {
 			{
 				Config: configText3,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					// file_paths and upload_piece_size cannot be obtained during reads, that's why it does not appear in data source schema
-					resourceFieldsEqual(cli1, "data.vcfa_content_library_item.cli1_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}),
-					resourceFieldsEqual(cli2, "data.vcfa_content_library_item.cli2_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}),
-					resourceFieldsEqual(cli3, "data.vcfa_content_library_item.cli3_ds", []string{"file_paths.#", "file_paths.0", "file_paths.1", "upload_piece_size", "%"}),
+					// file_paths, file_checksums and the upload arguments cannot be obtained during reads, that's why they do not appear in data source schema
+					resourceFieldsEqualCustom(cli1, "data.vcfa_content_library_item.cli1_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
+					resourceFieldsEqualCustom(cli2, "data.vcfa_content_library_item.cli2_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
+					resourceFieldsEqualCustom(cli3, "data.vcfa_content_library_item.cli3_ds", []string{"file_paths.#", "file_paths.0", "file_paths.1", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
+
+					// OVF metadata of the OVA
+					resource.TestCheckResourceAttrPair("data.vcfa_content_library_item_ovf.cli1_ovf", "id", cli1, "id"),
+					resource.TestMatchResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "descriptor_name", regexp.MustCompile(`\.ovf$`)),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "networks.#", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "networks.0.name", "none"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.#", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.name", "testvm1"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.guest_os_type", "windows9Server64Guest"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.cpu_count", "4"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.cores_per_socket", "4"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.memory_mb", "4"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.disks.#", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.disks.0.size_mb", "40"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.disks.0.controller_type", "lsilogicsas"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.network_adapters.#", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "virtual_systems.0.network_adapters.0.adapter_type", "E1000E"),
+					resource.TestCheckResourceAttr("data.vcfa_content_library_item_ovf.cli1_ovf", "properties.#", "0"),
 				),
 			},
 			{
@@ -158,7 +186,7 @@ func TestAccVcfaContentLibraryItemProvider(t *testing.T) {
 				ImportState:             true,
 				ImportStateVerify:       true,
 				ImportStateId:           fmt.Sprintf("System%s%s%s%s", ImportSeparator, testConfig.Tm.ContentLibrary, ImportSeparator, params["Name"].(string)+"1"),
-				ImportStateVerifyIgnore: []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}, // file_paths and upload_piece_size cannot be obtained during imports, that's why it's Optional
+				ImportStateVerifyIgnore: []string{"file_paths.#", "file_paths.0", "file_checksums", "file_stats", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, // file_paths, file_checksums and the upload arguments cannot be obtained during imports
 			},
 		},
 	})
@@ -170,6 +198,9 @@ resource "vcfa_content_library_item" "cli1" {
   description        = "{{.Name}}1"
   content_library_id = {{.ContentLibraryRef}}
   file_paths         = ["{{.OvaPath}}"]
+  upload_piece_size        = 1
+  upload_concurrency       = 4
+  upload_progress_interval = 5
 }
 
 resource "vcfa_content_library_item" "cli2" {
@@ -200,6 +231,9 @@ data "vcfa_content_library_item" "cli3_ds" {
   name               = vcfa_content_library_item.cli3.name
   content_library_id = vcfa_content_library_item.cli3.content_library_id
 }
+data "vcfa_content_library_item_ovf" "cli1_ovf" {
+  content_library_item_id = vcfa_content_library_item.cli1.id
+}
 `
 
 // TestAccVcfaContentLibraryItemTenant tests Content Library Items in a "TENANT" type Content Library
@@ -357,10 +391,10 @@ func TestAccVcfaContentLibraryItemTenant(t *testing.T) {
 				ProviderFactories: testAccProviders,
 				Config:            configText3,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					// file_paths and upload_piece_size cannot be obtained during reads, that's why it does not appear in data source schema
-					resourceFieldsEqual(cli1, "data.vcfa_content_library_item.cli1_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}),
-					resourceFieldsEqual(cli2, "data.vcfa_content_library_item.cli2_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}),
-					resourceFieldsEqual(cli3, "data.vcfa_content_library_item.cli3_ds", []string{"file_paths.#", "file_paths.0", "file_paths.1", "upload_piece_size", "%"}),
+					// file_paths, file_checksums and the upload arguments cannot be obtained during reads, that's why they do not appear in data source schema
+					resourceFieldsEqualCustom(cli1, "data.vcfa_content_library_item.cli1_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
+					resourceFieldsEqualCustom(cli2, "data.vcfa_content_library_item.cli2_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
+					resourceFieldsEqualCustom(cli3, "data.vcfa_content_library_item.cli3_ds", []string{"file_paths.#", "file_paths.0", "file_paths.1", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
 				),
 			},
 			{
@@ -369,7 +403,7 @@ func TestAccVcfaContentLibraryItemTenant(t *testing.T) {
 				ImportState:             true,
 				ImportStateVerify:       true,
 				ImportStateId:           fmt.Sprintf("%s%s%s%s%s", testConfig.Tm.Org, ImportSeparator, testConfig.Tm.ContentLibrary, ImportSeparator, t.Name()+"Updated1"),
-				ImportStateVerifyIgnore: []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}, // file_paths and upload_piece_size cannot be obtained during imports, that's why it's Optional
+				ImportStateVerifyIgnore: []string{"file_paths.#", "file_paths.0", "file_checksums", "file_stats", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, // file_paths, file_checksums and the upload arguments cannot be obtained during imports
 			},
 			{
 				ProviderFactories: multipleFactories(),
@@ -434,10 +468,10 @@ func TestAccVcfaContentLibraryItemTenant(t *testing.T) {
 				ProviderFactories: multipleFactories(),
 				Config:            configText6,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					// file_paths and upload_piece_size cannot be obtained during reads, that's why it does not appear in data source schema
-					resourceFieldsEqual(cli4, "data.vcfa_content_library_item.cli4_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}),
-					resourceFieldsEqual(cli5, "data.vcfa_content_library_item.cli5_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "%"}),
-					resourceFieldsEqual(cli6, "data.vcfa_content_library_item.cli6_ds", []string{"file_paths.#", "file_paths.0", "file_paths.1", "upload_piece_size", "%"}),
+					// file_paths, file_checksums and the upload arguments cannot be obtained during reads, that's why they do not appear in data source schema
+					resourceFieldsEqualCustom(cli4, "data.vcfa_content_library_item.cli4_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
+					resourceFieldsEqualCustom(cli5, "data.vcfa_content_library_item.cli5_ds", []string{"file_paths.#", "file_paths.0", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
+					resourceFieldsEqualCustom(cli6, "data.vcfa_content_library_item.cli6_ds", []string{"file_paths.#", "file_paths.0", "file_paths.1", "upload_piece_size", "upload_concurrency", "upload_progress_interval", "%"}, isContentLibraryItemUploadField),
 				),
 			},
 		},
@@ -491,3 +525,406 @@ data "vcfa_content_library_item" "cli6_ds" {
   content_library_id = vcfa_content_library_item.cli6.content_library_id
 }
 `
+
+// TestAccVcfaContentLibraryItemSourceUrl tests Content Library Items that are streamed from an HTTP server, which serves
+// the testing resources and requires an authorization header
+func TestAccVcfaContentLibraryItemSourceUrl(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfNotSysAdmin(t)
+
+	nsxManagerHcl, nsxManagerHclRef := getNsxManagerHcl(t)
+	vCenterHcl, vCenterHclRef := getVCenterHcl(t, nsxManagerHclRef)
+	regionHcl, regionHclRef := getRegionHcl(t, vCenterHclRef, nsxManagerHclRef)
+	contentLibraryHcl, contentLibraryHclRef := getContentLibraryHcl(t, regionHclRef, "")
+
+	authHeader := "Bearer " + t.Name()
+	fileServer := http.FileServer(http.Dir("../test-resources"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authHeader {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	isoContents, err := os.ReadFile("../test-resources/test.iso")
+	if err != nil {
+		t.Fatalf("error reading ISO file: %s", err)
+	}
+	isoChecksum := sha256.Sum256(isoContents)
+
+	var params = StringMap{
+		"Name":              t.Name(),
+		"ContentLibraryRef": fmt.Sprintf("%s.id", contentLibraryHclRef),
+		"OvaUrl":            server.URL + "/test_vapp_template.ova",
+		"IsoUrl":            server.URL + "/test.iso",
+		"IsoChecksum":       hex.EncodeToString(isoChecksum[:]),
+		"OvfUrl":            server.URL + "/test_vapp_template_ovf/descriptor.ovf",
+		"AuthHeader":        authHeader,
+		"Tags":              "tm contentlibrary",
+	}
+	testParamsNotEmpty(t, params)
+
+	preRequisites := vCenterHcl + nsxManagerHcl + regionHcl + contentLibraryHcl
+
+	configText1 := templateFill(preRequisites+testAccVcfaContentLibraryItemSourceUrlStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	params["IsoChecksum"] = strings.Repeat("0", 64)
+	configText2 := templateFill(preRequisites+testAccVcfaContentLibraryItemSourceUrlStep2, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	cli1 := "vcfa_content_library_item.cli1"
+	cli2 := "vcfa_content_library_item.cli2"
+	cli3 := "vcfa_content_library_item.cli3"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					// CLI 1: OVA
+					resource.TestCheckResourceAttr(cli1, "name", t.Name()+"1"),
+					resource.TestCheckResourceAttr(cli1, "item_type", "TEMPLATE"),
+					resource.TestCheckResourceAttr(cli1, "status", "READY"),
+					resource.TestCheckResourceAttrSet(cli1, "image_identifier"),
+
+					// CLI 2: ISO
+					resource.TestCheckResourceAttr(cli2, "name", t.Name()+"2"),
+					resource.TestCheckResourceAttr(cli2, "item_type", "ISO"),
+					resource.TestCheckResourceAttr(cli2, "status", "READY"),
+					resource.TestCheckResourceAttrSet(cli2, "image_identifier"),
+
+					// CLI 3: OVF
+					resource.TestCheckResourceAttr(cli3, "name", t.Name()+"3"),
+					resource.TestCheckResourceAttr(cli3, "item_type", "TEMPLATE"),
+					resource.TestCheckResourceAttr(cli3, "status", "READY"),
+					resource.TestCheckResourceAttrSet(cli3, "image_identifier"),
+				),
+			},
+			{
+				Config:      configText2,
+				ExpectError: regexp.MustCompile(`SHA-256 checksum of the source`),
+			},
+		},
+	})
+}
+
+const testAccVcfaContentLibraryItemSourceUrlStep1 = `
+# skip-binary-test: Requires an HTTP server that is started by the test
+
+resource "vcfa_content_library_item" "cli1" {
+  name               = "{{.Name}}1"
+  description        = "{{.Name}}1"
+  content_library_id = {{.ContentLibraryRef}}
+  source_url         = "{{.OvaUrl}}"
+  source_auth_header = "{{.AuthHeader}}"
+}
+
+resource "vcfa_content_library_item" "cli2" {
+  name               = "{{.Name}}2"
+  description        = "{{.Name}}2"
+  content_library_id = {{.ContentLibraryRef}}
+  source_url         = "{{.IsoUrl}}"
+  source_checksum    = "sha256:{{.IsoChecksum}}"
+  source_auth_header = "{{.AuthHeader}}"
+}
+
+resource "vcfa_content_library_item" "cli3" {
+  name               = "{{.Name}}3"
+  description        = "{{.Name}}3"
+  content_library_id = {{.ContentLibraryRef}}
+  source_url         = "{{.OvfUrl}}"
+  source_auth_header = "{{.AuthHeader}}"
+}
+`
+
+const testAccVcfaContentLibraryItemSourceUrlStep2 = `
+# skip-binary-test: Requires an HTTP server that is started by the test
+
+resource "vcfa_content_library_item" "cli2" {
+  name               = "{{.Name}}2"
+  description        = "{{.Name}}2"
+  content_library_id = {{.ContentLibraryRef}}
+  source_url         = "{{.IsoUrl}}"
+  source_checksum    = "{{.IsoChecksum}}"
+  source_auth_header = "{{.AuthHeader}}"
+}
+`
+
+func TestAccVcfaContentLibraryItemNewVersion(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfNotSysAdmin(t)
+
+	nsxManagerHcl, nsxManagerHclRef := getNsxManagerHcl(t)
+	vCenterHcl, vCenterHclRef := getVCenterHcl(t, nsxManagerHclRef)
+	regionHcl, regionHclRef := getRegionHcl(t, vCenterHclRef, nsxManagerHclRef)
+	contentLibraryHcl, contentLibraryHclRef := getContentLibraryHcl(t, regionHclRef, "")
+
+	// The ISO is copied, so its content can be modified between steps
+	isoContents, err := os.ReadFile("../test-resources/test.iso")
+	if err != nil {
+		t.Fatalf("error reading ISO file: %s", err)
+	}
+	isoPath := filepath.Join(t.TempDir(), "test.iso")
+	err = os.WriteFile(isoPath, isoContents, 0600)
+	if err != nil {
+		t.Fatalf("error copying ISO file: %s", err)
+	}
+	isoChecksum := sha256.Sum256(isoContents)
+	modifiedIsoContents := append(slices.Clone(isoContents), make([]byte, 2048)...)
+	modifiedIsoChecksum := sha256.Sum256(modifiedIsoContents)
+
+	var params = StringMap{
+		"Name":              t.Name(),
+		"ContentLibraryRef": fmt.Sprintf("%s.id", contentLibraryHclRef),
+		"IsoPath":           isoPath,
+		"Tags":              "tm contentlibrary",
+	}
+	testParamsNotEmpty(t, params)
+
+	preRequisites := vCenterHcl + nsxManagerHcl + regionHcl + contentLibraryHcl
+
+	configText1 := templateFill(preRequisites+testAccVcfaContentLibraryItemNewVersionStep1, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	cli := "vcfa_content_library_item.cli"
+	cachedId := &testCachedFieldValue{}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.cacheTestResourceFieldValue(cli, "id"),
+					resource.TestCheckResourceAttr(cli, "item_type", "ISO"),
+					resource.TestCheckResourceAttr(cli, "status", "READY"),
+					resource.TestCheckResourceAttr(cli, "version", "1"),
+					resource.TestCheckResourceAttr(cli, "file_checksums.test.iso", hex.EncodeToString(isoChecksum[:])),
+				),
+			},
+			{
+				// The same configuration with a different file content uploads a new version of the same item
+				PreConfig: func() {
+					err := os.WriteFile(isoPath, modifiedIsoContents, 0600)
+					if err != nil {
+						t.Fatalf("error modifying ISO file: %s", err)
+					}
+				},
+				Config: configText1,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.testCheckCachedResourceFieldValue(cli, "id"),
+					resource.TestCheckResourceAttr(cli, "status", "READY"),
+					resource.TestCheckResourceAttr(cli, "version", "2"),
+					resource.TestCheckResourceAttr(cli, "file_checksums.test.iso", hex.EncodeToString(modifiedIsoChecksum[:])),
+				),
+			},
+			{
+				// Changing the modification time without changing the content only updates the stats of the file
+				PreConfig: func() {
+					modificationTime := time.Now().Add(time.Hour)
+					err := os.Chtimes(isoPath, modificationTime, modificationTime)
+					if err != nil {
+						t.Fatalf("error changing the modification time of the ISO file: %s", err)
+					}
+				},
+				Config: configText1,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.testCheckCachedResourceFieldValue(cli, "id"),
+					resource.TestCheckResourceAttr(cli, "version", "2"),
+					resource.TestCheckResourceAttr(cli, "file_checksums.test.iso", hex.EncodeToString(modifiedIsoChecksum[:])),
+					resource.TestMatchResourceAttr(cli, "file_stats.test.iso", regexp.MustCompile(fmt.Sprintf(`^%d:\d+$`, len(modifiedIsoContents)))),
+				),
+			},
+		},
+	})
+}
+
+const testAccVcfaContentLibraryItemNewVersionStep1 = `
+# skip-binary-test: Requires a file that is modified by the test
+
+resource "vcfa_content_library_item" "cli" {
+  name               = "{{.Name}}"
+  description        = "{{.Name}}"
+  content_library_id = {{.ContentLibraryRef}}
+  file_paths         = ["{{.IsoPath}}"]
+}
+`
+
+func TestAccVcfaContentLibraryItemInvalidOvf(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfNotSysAdmin(t)
+
+	nsxManagerHcl, nsxManagerHclRef := getNsxManagerHcl(t)
+	vCenterHcl, vCenterHclRef := getVCenterHcl(t, nsxManagerHclRef)
+	regionHcl, regionHclRef := getRegionHcl(t, vCenterHclRef, nsxManagerHclRef)
+	contentLibraryHcl, contentLibraryHclRef := getContentLibraryHcl(t, regionHclRef, "")
+
+	// The OVF descriptor is copied without its disk, and with a manifest that doesn't match its contents
+	descriptor, err := os.ReadFile("../test-resources/test_vapp_template_ovf/descriptor.ovf")
+	if err != nil {
+		t.Fatalf("error reading OVF descriptor: %s", err)
+	}
+	ovfDirectory := t.TempDir()
+	err = os.WriteFile(filepath.Join(ovfDirectory, "descriptor.ovf"), descriptor, 0600)
+	if err != nil {
+		t.Fatalf("error copying OVF descriptor: %s", err)
+	}
+	err = os.WriteFile(filepath.Join(ovfDirectory, "descriptor.mf"), []byte(fmt.Sprintf("SHA256(descriptor.ovf)= %s\n", strings.Repeat("0", 64))), 0600)
+	if err != nil {
+		t.Fatalf("error writing OVF manifest: %s", err)
+	}
+
+	var params = StringMap{
+		"Name":              t.Name(),
+		"ContentLibraryRef": fmt.Sprintf("%s.id", contentLibraryHclRef),
+		"OvfPath":           filepath.Join(ovfDirectory, "descriptor.ovf"),
+		"Tags":              "tm contentlibrary",
+	}
+	testParamsNotEmpty(t, params)
+
+	preRequisites := vCenterHcl + nsxManagerHcl + regionHcl + contentLibraryHcl
+
+	configText1 := templateFill(preRequisites+testAccVcfaContentLibraryItemInvalidOvfStep1, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      configText1,
+				ExpectError: regexp.MustCompile(`(?s)is referenced by the OVF descriptor, but it is not in 'file_paths'.*checksum of 'descriptor.ovf'`),
+			},
+		},
+	})
+}
+
+const testAccVcfaContentLibraryItemInvalidOvfStep1 = `
+# skip-binary-test: Requires files that are created by the test
+
+resource "vcfa_content_library_item" "cli" {
+  name               = "{{.Name}}"
+  content_library_id = {{.ContentLibraryRef}}
+  file_paths         = ["{{.OvfPath}}"]
+}
+`
+
+// isContentLibraryItemUploadField returns true if the given field is in the list, or if it is one of the file checksums or
+// stats, whose keys depend on the uploaded files
+func isContentLibraryItemUploadField(list []string, field string) bool {
+	return slices.Contains(list, field) || strings.HasPrefix(field, "file_checksums.") || strings.HasPrefix(field, "file_stats.") ||
+		strings.HasPrefix(field, "quarantine_approval.")
+}
+
+// TestAccVcfaContentLibraryItemQuarantine tests that Content Library Items are approved or rejected when they are
+// quarantined in an Organization that has 'quarantine_content_library_items' enabled
+func TestAccVcfaContentLibraryItemQuarantine(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfNotSysAdmin(t)
+
+	nsxManagerHcl, nsxManagerHclRef := getNsxManagerHcl(t)
+	vCenterHcl, vCenterHclRef := getVCenterHcl(t, nsxManagerHclRef)
+	regionHcl, regionHclRef := getRegionHcl(t, vCenterHclRef, nsxManagerHclRef)
+	vmClassesHcl, vmClassesRefs := getRegionVmClassesHcl(t, regionHclRef)
+	contentLibraryHcl, contentLibraryHclRef := getContentLibraryHcl(t, regionHclRef, "vcfa_org_region_quota.test.org_id")
+
+	itemPaths := getTestingResourcesAbsolutePaths(t, contentLibraryItemTestingResourcePaths)
+	isoChecksums, err := getContentLibraryItemFileChecksums(itemPaths[1:2])
+	if err != nil {
+		t.Fatalf("error calculating ISO checksum: %s", err)
+	}
+	var params = StringMap{
+		"Org":                testConfig.Tm.Org,
+		"Username":           "test-user",
+		"Password":           "long-change-ME1",
+		"Name":               t.Name(),
+		"RegionId":           fmt.Sprintf("%s.id", regionHclRef),
+		"SupervisorName":     testConfig.Tm.VcenterSupervisor,
+		"SupervisorZoneName": testConfig.Tm.VcenterSupervisorZone,
+		"StorageClass":       testConfig.Tm.StorageClass,
+		"VcenterRef":         vCenterHclRef,
+		"RegionVmClassRefs":  strings.Join(vmClassesRefs, ".id,\n    ") + ".id",
+		"ContentLibraryRef":  fmt.Sprintf("%s.id", contentLibraryHclRef),
+		"IsoPath":            itemPaths[1],
+		"IsoChecksum":        strings.Repeat("0", 64),
+		"Tags":               "tm contentlibrary",
+	}
+	testParamsNotEmpty(t, params)
+
+	// The Organization of the shared prerequisites does not quarantine items, so it is changed for this test
+	tenantPrerequisites := strings.Replace(testAccVcfaContentLibraryTenantPrerequisites,
+		"quarantine_content_library_items = false", "quarantine_content_library_items = true", 1)
+	preRequisites := vCenterHcl + nsxManagerHcl + regionHcl + vmClassesHcl + contentLibraryHcl + tenantPrerequisites
+
+	configText1 := templateFill(preRequisites+testAccVcfaContentLibraryItemQuarantineStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	params["IsoChecksum"] = isoChecksums["test.iso"]
+	configText2 := templateFill(preRequisites+testAccVcfaContentLibraryItemQuarantineStep1, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	cli := "vcfa_content_library_item.cli"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				// The checksum does not match, so the item is rejected
+				Config:      configText1,
+				ExpectError: regexp.MustCompile(`rejected from quarantine`),
+			},
+			{
+				Config: configText2,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(cli, "item_type", "ISO"),
+					resource.TestCheckResourceAttr(cli, "status", "READY"),
+					resource.TestCheckResourceAttr(cli, "quarantine_approval.0.approve", "true"),
+					resource.TestCheckResourceAttr(cli, "quarantine_approval.0.sha256", isoChecksums["test.iso"]),
+				),
+			},
+		},
+	})
+}
+
+const testAccVcfaContentLibraryItemQuarantineStep1 = `
+resource "vcfa_content_library_item" "cli" {
+  name               = "{{.Name}}"
+  description        = "{{.Name}}"
+  content_library_id = {{.ContentLibraryRef}}
+  file_paths         = ["{{.IsoPath}}"]
+  quarantine_approval {
+    sha256  = "{{.IsoChecksum}}"
+    message = "Approved by Terraform"
+  }
+
+  # Items are only quarantined once the Organization settings are applied
+  depends_on = [vcfa_org_settings.allow]
+}
+`
@@ -0,0 +1,291 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"github.com/vmware/go-vcloud-director/v3/types/v56"
+)
+
+var contentLibrarySyncItemSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("ID of the %s", labelVcfaContentLibraryItem),
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("Name of the %s", labelVcfaContentLibraryItem),
+		},
+		"status": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("Status of the %s", labelVcfaContentLibraryItem),
+		},
+		"last_successful_sync": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("The ISO-8601 timestamp representing when the %s was last synced", labelVcfaContentLibraryItem),
+		},
+		"synced": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: fmt.Sprintf("Whether the %s was synced successfully by the last sync", labelVcfaContentLibraryItem),
+		},
+		"error": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("Error of the last task of the %s that failed after the last sync started, if any", labelVcfaContentLibraryItem),
+		},
+	},
+}
+
+func resourceVcfaContentLibrarySync() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaContentLibrarySyncCreate,
+		ReadContext:   resourceVcfaContentLibrarySyncRead,
+		UpdateContext: resourceVcfaContentLibrarySyncRead,
+		DeleteContext: resourceVcfaContentLibrarySyncDelete,
+		Schema: map[string]*schema.Schema{
+			"content_library_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("ID of the subscribed %s to sync", labelVcfaContentLibrary),
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("Arbitrary map of values that, when changed, start a new sync of the %s", labelVcfaContentLibrary),
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"fail_on_item_errors": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: fmt.Sprintf("Whether the sync fails when any %s was not synced successfully", labelVcfaContentLibraryItem),
+			},
+			"last_sync_date": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ISO-8601 timestamp representing when the last sync started",
+			},
+			"synced_item_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: fmt.Sprintf("Number of %ss that were synced successfully by the last sync", labelVcfaContentLibraryItem),
+			},
+			"failed_item_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: fmt.Sprintf("Number of %ss that were not synced successfully by the last sync", labelVcfaContentLibraryItem),
+			},
+			"items": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: fmt.Sprintf("Sync state of every %s of the %s, sorted by name", labelVcfaContentLibraryItem, labelVcfaContentLibrary),
+				Elem:        contentLibrarySyncItemSchema,
+			},
+		},
+	}
+}
+
+func resourceVcfaContentLibrarySyncCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+
+	clId := d.Get("content_library_id").(string)
+	cl, err := tmClient.GetContentLibraryById(clId, nil)
+	if err != nil {
+		return diag.Errorf("could not retrieve %s with ID '%s': %s", labelVcfaContentLibrary, clId, err)
+	}
+
+	syncDate, err := syncContentLibrary(ctx, tmClient, cl)
+	if err != nil {
+		return diag.Errorf("error syncing %s '%s': %s", labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+	}
+	d.SetId(cl.ContentLibrary.ID)
+	dSet(d, "last_sync_date", syncDate)
+
+	diags := resourceVcfaContentLibrarySyncRead(ctx, d, meta)
+	if diags.HasError() || !d.Get("fail_on_item_errors").(bool) {
+		return diags
+	}
+
+	// The resource is saved in state, so it is tainted and the sync is started again in the next apply
+	var failures []string
+	for _, item := range d.Get("items").([]interface{}) {
+		itemState := item.(map[string]interface{})
+		if itemState["synced"].(bool) {
+			continue
+		}
+		failure := fmt.Sprintf("'%s' (status %s)", itemState["name"], itemState["status"])
+		if itemState["error"].(string) != "" {
+			failure += ": " + itemState["error"].(string)
+		}
+		failures = append(failures, failure)
+	}
+	if len(failures) > 0 {
+		return diag.Errorf("%d %ss of %s '%s' were not synced:\n  - %s", len(failures), labelVcfaContentLibraryItem,
+			labelVcfaContentLibrary, cl.ContentLibrary.Name, strings.Join(failures, "\n  - "))
+	}
+	return nil
+}
+
+func resourceVcfaContentLibrarySyncRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+
+	cl, err := tmClient.GetContentLibraryById(d.Id(), nil)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("could not retrieve %s with ID '%s': %s", labelVcfaContentLibrary, d.Id(), err)
+	}
+
+	items, err := getContentLibrarySyncItemStates(tmClient, cl, d.Get("last_sync_date").(string))
+	if err != nil {
+		return diag.Errorf("error retrieving the sync state of the %ss of %s '%s': %s", labelVcfaContentLibraryItem,
+			labelVcfaContentLibrary, cl.ContentLibrary.Name, err)
+	}
+	synced := 0
+	for _, item := range items {
+		if item["synced"].(bool) {
+			synced++
+		}
+	}
+	dSet(d, "synced_item_count", synced)
+	dSet(d, "failed_item_count", len(items)-synced)
+	if err := d.Set("items", items); err != nil {
+		return diag.Errorf("error setting items: %s", err)
+	}
+	return nil
+}
+
+func resourceVcfaContentLibrarySyncDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	// A sync can't be undone, so it is only removed from state
+	d.SetId("")
+	return nil
+}
+
+// syncContentLibrary starts a sync of the given subscribed Content Library with its publisher, and waits for it to finish.
+// It returns the ISO-8601 timestamp representing when the sync started
+func syncContentLibrary(ctx context.Context, tmClient *VCDClient, cl *govcd.ContentLibrary) (string, error) {
+	if !cl.ContentLibrary.IsSubscribed {
+		return "", fmt.Errorf("the %s is not subscribed to a publisher library", labelVcfaContentLibrary)
+	}
+
+	startTime := time.Now().UTC().Format(time.RFC3339)
+	urlRef, err := tmClient.Client.OpenApiBuildEndpoint(types.OpenApiPathVcf+types.OpenApiEndpointContentLibraries, cl.ContentLibrary.ID, "/sync")
+	if err != nil {
+		return "", err
+	}
+	task, err := tmClient.Client.OpenApiPostItemAsyncWithHeaders(tmClient.Client.APIVersion, urlRef, nil, struct{}{}, getContentLibraryTenantContextHeader(cl))
+	if err != nil {
+		return "", err
+	}
+	err = task.WaitInspectTaskCompletion(func(task *types.Task, howManyTimes int, elapsed time.Duration, _, _ bool) {
+		// Every 10 refreshes, which is 30 seconds
+		if howManyTimes%10 == 0 {
+			tflog.Info(ctx, fmt.Sprintf("Syncing %s '%s': task is %s (%d%%)", labelVcfaContentLibrary, cl.ContentLibrary.Name, task.Status, task.Progress), map[string]interface{}{
+				"content_library": cl.ContentLibrary.Name,
+				"task_status":     task.Status,
+				"task_progress":   task.Progress,
+				"elapsed":         elapsed.Round(time.Second).String(),
+			})
+		}
+	}, 3*time.Second)
+	if err != nil {
+		return "", err
+	}
+	if task.Task.StartTime != "" {
+		startTime = task.Task.StartTime
+	}
+	return startTime, nil
+}
+
+// getContentLibrarySyncItemStates returns the sync state of every item of the given Content Library, sorted by name.
+// An item is synced if its last successful sync happened after the given sync date
+func getContentLibrarySyncItemStates(tmClient *VCDClient, cl *govcd.ContentLibrary, syncDate string) ([]map[string]interface{}, error) {
+	syncTime, err := time.Parse(time.RFC3339, syncDate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing last sync date '%s': %s", syncDate, err)
+	}
+
+	items, err := cl.GetAllContentLibraryItems(nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ContentLibraryItem.Name < items[j].ContentLibraryItem.Name
+	})
+
+	states := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		lastSync, err := time.Parse(time.RFC3339, item.ContentLibraryItem.LastSuccessfulSync)
+		synced := err == nil && !lastSync.Before(syncTime)
+		itemError := ""
+		if !synced {
+			itemError, err = getContentLibraryItemSyncError(tmClient, item, syncTime)
+			if err != nil {
+				return nil, err
+			}
+		}
+		states = append(states, map[string]interface{}{
+			"id":                   item.ContentLibraryItem.ID,
+			"name":                 item.ContentLibraryItem.Name,
+			"status":               item.ContentLibraryItem.Status,
+			"last_successful_sync": item.ContentLibraryItem.LastSuccessfulSync,
+			"synced":               synced,
+			"error":                itemError,
+		})
+	}
+	return states, nil
+}
+
+// getContentLibraryItemSyncError returns the message of the latest task of the given Content Library Item that failed
+// after the given time, or an empty string if there is none
+func getContentLibraryItemSyncError(tmClient *VCDClient, cli *govcd.ContentLibraryItem, since time.Time) (string, error) {
+	taskRecords, err := tmClient.Client.QueryTaskList(map[string]string{
+		"status":     "error",
+		"objectType": "contentLibraryItem",
+		"objectName": cli.ContentLibraryItem.Name,
+	})
+	if err != nil {
+		return "", err
+	}
+	uuid := cli.ContentLibraryItem.ID[strings.LastIndex(cli.ContentLibraryItem.ID, ":")+1:]
+	message := ""
+	var latest time.Time
+	for _, taskRecord := range taskRecords {
+		if !strings.Contains(taskRecord.Object, uuid) {
+			continue
+		}
+		startDate, err := time.Parse(time.RFC3339, taskRecord.StartDate)
+		if err != nil || startDate.Before(since) || startDate.Before(latest) {
+			continue
+		}
+		latest = startDate
+		message = taskRecord.Message
+		if message == "" {
+			message = fmt.Sprintf("task '%s' failed", taskRecord.OperationFull)
+		}
+	}
+	return message, nil
+}
@@ -0,0 +1,135 @@
+//go:build tm || contentlibrary || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// TestAccVcfaContentLibrarySync tests the sync of a subscribed Content Library of type PROVIDER
+func TestAccVcfaContentLibrarySync(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfNotSysAdmin(t)
+
+	nsxManagerHcl, nsxManagerHclRef := getNsxManagerHcl(t)
+	vCenterHcl, vCenterHclRef := getVCenterHcl(t, nsxManagerHclRef)
+	regionHcl, regionHclRef := getRegionHcl(t, vCenterHclRef, nsxManagerHclRef)
+
+	var params = StringMap{
+		"Name":                t.Name(),
+		"RegionId":            fmt.Sprintf("%s.id", regionHclRef),
+		"RegionStoragePolicy": testConfig.Tm.StorageClass,
+		"VsphereUsername":     testConfig.Tm.VcenterUsername,
+		"VspherePassword":     testConfig.Tm.VcenterPassword,
+		"VsphereUrl":          strings.Split(testConfig.Tm.VcenterUrl, "://")[1],
+		"VsphereDatacenter":   testConfig.Tm.VcenterDatacenter,
+		"VsphereDatastore":    testConfig.Tm.VcenterDatastore,
+		"Trigger":             "1",
+		"Tags":                "tm contentlibrary",
+	}
+	testParamsNotEmpty(t, params)
+
+	preRequisites := vCenterHcl + nsxManagerHcl + regionHcl
+
+	params["FuncName"] = t.Name() + "-step1"
+	configText1 := templateFill(preRequisites+testAccVcfaContentLibrarySyncStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	params["Trigger"] = "2"
+	configText2 := templateFill(preRequisites+testAccVcfaContentLibrarySyncStep1, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	resourceName := "vcfa_content_library_sync.sync"
+	cachedSyncDate := &testCachedFieldValue{}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		ExternalProviders: map[string]resource.ExternalProvider{
+			"vsphere": {
+				VersionConstraint: vsphereProviderVersion,
+				Source:            "hashicorp/vsphere",
+			},
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedSyncDate.cacheTestResourceFieldValue(resourceName, "last_sync_date"),
+					resource.TestCheckResourceAttrPair(resourceName, "id", "vcfa_content_library.cl_subscribed", "id"),
+					resource.TestCheckResourceAttrSet(resourceName, "last_sync_date"),
+					resource.TestCheckResourceAttr(resourceName, "failed_item_count", "0"),
+					resource.TestCheckResourceAttr("vcfa_content_library.cl_subscribed", "subscription_config.0.need_local_copy", "true"),
+				),
+			},
+			{
+				// Changing the triggers starts a new sync
+				Config: configText2,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					func(s *terraform.State) error {
+						rs, ok := s.RootModule().Resources[resourceName]
+						if !ok {
+							return fmt.Errorf("resource not found: %s", resourceName)
+						}
+						if rs.Primary.Attributes["last_sync_date"] == cachedSyncDate.String() {
+							return fmt.Errorf("expected a new sync, but last_sync_date is still %s", cachedSyncDate)
+						}
+						return nil
+					},
+					resource.TestMatchResourceAttr(resourceName, "last_sync_date", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T`)),
+					resource.TestCheckResourceAttr(resourceName, "failed_item_count", "0"),
+				),
+			},
+		},
+	})
+}
+
+const testAccVcfaContentLibrarySyncStep1 = testAccVcfaContentLibraryPrerequisites + `
+data "vcfa_org" "system" {
+  name = "System"
+}
+
+data "vcfa_storage_class" "sc" {
+  region_id = {{.RegionId}}
+  name      = "{{.RegionStoragePolicy}}"
+}
+
+resource "vcfa_content_library" "cl_subscribed" {
+  org_id = data.vcfa_org.system.id
+  name   = "{{.Name}}Subscribed"
+  storage_class_ids = [
+    data.vcfa_storage_class.sc.id
+  ]
+  subscription_config {
+    password         = local.vsphere_content_library_password
+    subscription_url = vsphere_content_library.publisher_content_library.publication[0].publish_url
+    need_local_copy  = true
+  }
+  delete_force              = true
+  delete_recursive          = true
+  delete_acknowledged_items = ["*"] # Items come from the publisher
+}
+
+resource "vcfa_content_library_sync" "sync" {
+  content_library_id  = vcfa_content_library.cl_subscribed.id
+  fail_on_item_errors = true
+  triggers = {
+    version = "{{.Trigger}}"
+  }
+}
+`
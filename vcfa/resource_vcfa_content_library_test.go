@@ -7,15 +7,19 @@
 package vcfa
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"github.com/vmware/go-vcloud-director/v3/types/v56"
 )
 
 // TestAccVcfaContentLibraryProvider tests CRUD of a Content Library of type PROVIDER.
@@ -38,6 +42,7 @@ func TestAccVcfaContentLibraryProvider(t *testing.T) {
 		"VsphereUrl":          strings.Split(testConfig.Tm.VcenterUrl, "://")[1],
 		"VsphereDatacenter":   testConfig.Tm.VcenterDatacenter,
 		"VsphereDatastore":    testConfig.Tm.VcenterDatastore,
+		"PublishEnabled":      "true",
 		"Tags":                "tm contentlibrary",
 	}
 	testParamsNotEmpty(t, params)
@@ -54,8 +59,10 @@ func TestAccVcfaContentLibraryProvider(t *testing.T) {
 	configText1 := templateFill(preRequisites+testAccVcfaContentLibraryProviderStep1, params)
 	params["FuncName"] = t.Name() + "-step2"
 	params["Name"] = t.Name() + "Updated"
+	params["PublishEnabled"] = "false"
 	configText2 := templateFill(preRequisites+testAccVcfaContentLibraryProviderStep1, params)
 	params["FuncName"] = t.Name() + "-step3"
+	params["PublishEnabled"] = "true"
 	configText3 := templateFill(preRequisites+testAccVcfaContentLibraryProviderStep3, params)
 
 	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
@@ -115,6 +122,10 @@ func TestAccVcfaContentLibraryProvider(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "is_subscribed", "false"),
 					resource.TestCheckResourceAttr(resourceName, "library_type", "PROVIDER"),
 					resource.TestCheckResourceAttr(resourceName, "subscription_config.#", "0"),
+					resource.TestCheckResourceAttr(resourceName, "publish_config.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "publish_config.0.enabled", "true"),
+					resource.TestCheckResourceAttr(resourceName, "publish_config.0.password", "publisher-password"),
+					resource.TestMatchResourceAttr(resourceName, "publish_config.0.subscription_url", regexp.MustCompile(`^https://.+`)),
 					resource.TestMatchResourceAttr(resourceName, "version_number", regexp.MustCompile("[0-9]")),
 
 					// Subscribed Content Library
@@ -130,6 +141,7 @@ func TestAccVcfaContentLibraryProvider(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceNameSubscribed, "subscription_config.#", "1"),
 					resource.TestCheckResourceAttrPair(resourceNameSubscribed, "subscription_config.0.subscription_url", "vsphere_content_library.publisher_content_library", "publication.0.publish_url"),
 					resource.TestCheckResourceAttr(resourceNameSubscribed, "subscription_config.0.password", "password"),
+					resource.TestCheckResourceAttr(resourceNameSubscribed, "publish_config.#", "0"),
 					resource.TestMatchResourceAttr(resourceNameSubscribed, "version_number", regexp.MustCompile("[0-9]")),
 				),
 			},
@@ -140,6 +152,7 @@ func TestAccVcfaContentLibraryProvider(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "name", t.Name()+"Updated"),
 					resource.TestCheckResourceAttr(resourceName, "description", t.Name()+"Updated"),
 					resource.TestCheckResourceAttr(resourceNameSubscribed, "name", t.Name()+"UpdatedSubscribed"),
+					resource.TestCheckResourceAttr(resourceName, "publish_config.0.enabled", "false"),
 				),
 			},
 			{
@@ -151,11 +164,15 @@ func TestAccVcfaContentLibraryProvider(t *testing.T) {
 						"delete_force",
 						"subscription_config.0.%", // Does not have password
 						"subscription_config.0.password",
+						"publish_config.0.%", // Does not have password
+						"publish_config.0.password",
 					}),
 					resourceFieldsEqual(resourceNameSubscribed, "data.vcfa_content_library.cl_subscribed_ds", []string{
-						"%", // Does not have delete_recursive, delete_force
+						"%", // Does not have delete_recursive, delete_force, delete_acknowledged_items
 						"delete_recursive",
 						"delete_force",
+						"delete_acknowledged_items.#",
+						"delete_acknowledged_items.0",
 						"subscription_config.0.%", // Does not have password
 						"subscription_config.0.password",
 					}),
@@ -169,6 +186,7 @@ func TestAccVcfaContentLibraryProvider(t *testing.T) {
 				ImportStateVerifyIgnore: []string{
 					"delete_recursive",
 					"delete_force",
+					"publish_config.0.password", // Password is never returned
 				},
 			},
 		},
@@ -231,6 +249,10 @@ resource "vcfa_content_library" "cl" {
   storage_class_ids = [
     data.vcfa_storage_class.sc.id
   ]
+  publish_config {
+    enabled  = {{.PublishEnabled}}
+    password = "publisher-password"
+  }
   delete_force = true
   delete_recursive = true
 }
@@ -247,6 +269,7 @@ resource "vcfa_content_library" "cl_subscribed" {
   }
   delete_force = true
   delete_recursive = true
+  delete_acknowledged_items = ["*"] # Items come from the publisher
 }
 `
 
@@ -479,6 +502,8 @@ func TestAccVcfaContentLibraryTenant(t *testing.T) {
 						"%",
 						"delete_recursive",
 						"delete_force",
+						"delete_acknowledged_items.#",
+						"delete_acknowledged_items.0",
 						"subscription_config.0.%", // Does not have password
 						"subscription_config.0.password",
 					}),
@@ -640,6 +665,7 @@ resource "vcfa_content_library" "cl_subscribed" {
   }
   delete_force = true
   delete_recursive = true
+  delete_acknowledged_items = ["*"] # Items come from the publisher
 }
 `
 
@@ -705,3 +731,229 @@ data "vcfa_content_library" "cl_subscribed_ds" {
   name     = vcfa_content_library.cl_subscribed.name
 }
 `
+
+// TestAccVcfaContentLibraryDeletion tests that a Content Library is not deleted when it contains items that are not
+// in 'delete_acknowledged_items', that those items are warned about during plan, and the in place update of
+// 'storage_class_ids' when a second Storage Class is configured
+func TestAccVcfaContentLibraryDeletion(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfNotSysAdmin(t)
+
+	nsxManagerHcl, nsxManagerHclRef := getNsxManagerHcl(t)
+	vCenterHcl, vCenterHclRef := getVCenterHcl(t, nsxManagerHclRef)
+	regionHcl, regionHclRef := getRegionHcl(t, vCenterHclRef, nsxManagerHclRef)
+
+	var params = StringMap{
+		"Name":         t.Name(),
+		"RegionId":     fmt.Sprintf("%s.id", regionHclRef),
+		"StorageClass": testConfig.Tm.StorageClass,
+		"IsoPath":      getTestingResourcesAbsolutePaths(t, []string{"../test-resources/test.iso"})[0],
+		"Tags":         "tm contentlibrary",
+	}
+	testParamsNotEmpty(t, params)
+	params["StorageClass2"] = testConfig.Tm.StorageClass2
+	params["AcknowledgedItems"] = ""
+
+	preRequisites := vCenterHcl + nsxManagerHcl + regionHcl
+
+	skipBinaryTest := "# skip-binary-test: prerequisite buildup for acceptance tests"
+	configText0 := templateFill(vCenterHcl+nsxManagerHcl+skipBinaryTest, params)
+	params["FuncName"] = t.Name() + "-step1"
+	configText1 := templateFill(preRequisites+testAccVcfaContentLibraryDeletionStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	configText2 := templateFill(preRequisites+testAccVcfaContentLibraryDeletionStep2, params)
+	params["FuncName"] = t.Name() + "-step3"
+	configText3 := templateFill(preRequisites+testAccVcfaContentLibraryDeletionStep3, params)
+	params["FuncName"] = t.Name() + "-step4"
+	configText4 := templateFill(preRequisites+testAccVcfaContentLibraryDeletionStep4, params)
+	params["FuncName"] = t.Name() + "-step5"
+	params["AcknowledgedItems"] = fmt.Sprintf(`"%sUnmanaged"`, t.Name())
+	configText5 := templateFill(preRequisites+testAccVcfaContentLibraryDeletionStep3, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+	debugPrintf("#[DEBUG] CONFIGURATION step3: %s\n", configText3)
+	debugPrintf("#[DEBUG] CONFIGURATION step4: %s\n", configText4)
+	debugPrintf("#[DEBUG] CONFIGURATION step5: %s\n", configText5)
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	resourceName := "vcfa_content_library.cl"
+	cachedId := &testCachedFieldValue{}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText0,
+			},
+			{
+				Config: configText1,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.cacheTestResourceFieldValue(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "storage_class_ids.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "delete_acknowledged_items.#", "0"),
+					testAccCheckContentLibraryDeletionWarning(resourceName, t.Name()+"Managed"),
+				),
+			},
+			{
+				// Storage Classes are updated in place
+				SkipFunc: func() (bool, error) { return testConfig.Tm.StorageClass2 == "", nil },
+				Config:   configText2,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.testCheckCachedResourceFieldValue(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "storage_class_ids.#", "2"),
+				),
+			},
+			{
+				// The managed item is removed, and another one is created outside of Terraform
+				PreConfig: func() {
+					tmClient := createTemporaryVCFAConnection(false)
+					cl, err := tmClient.GetContentLibraryById(cachedId.fieldValue, nil)
+					if err != nil {
+						t.Fatalf("error retrieving %s: %s", labelVcfaContentLibrary, err)
+					}
+					_, err = cl.CreateContentLibraryItem(&types.ContentLibraryItem{Name: t.Name() + "Unmanaged"},
+						govcd.ContentLibraryItemUploadArguments{FilePath: params["IsoPath"].(string)})
+					if err != nil {
+						t.Fatalf("error creating unmanaged %s: %s", labelVcfaContentLibraryItem, err)
+					}
+				},
+				Config: configText3,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.testCheckCachedResourceFieldValue(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "storage_class_ids.#", "1"),
+					testAccCheckContentLibraryDeletionWarning(resourceName, t.Name()+"Unmanaged"),
+				),
+			},
+			{
+				// Deleting the Content Library fails, as the unmanaged item is not acknowledged
+				Config:      configText4,
+				ExpectError: regexp.MustCompile(fmt.Sprintf(`refusing to delete .* 'delete_acknowledged_items': %sUnmanaged`, t.Name())),
+			},
+			{
+				// Once acknowledged, there are no more warnings and the Content Library is deleted at the end of the test
+				Config: configText5,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					cachedId.testCheckCachedResourceFieldValue(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "delete_acknowledged_items.#", "1"),
+					testAccCheckContentLibraryDeletionWarning(resourceName),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckContentLibraryDeletionWarning reads the given Content Library with its state, and checks that the
+// warning about its deletion lists the given items, or that there is no warning when no items are given
+func testAccCheckContentLibraryDeletionWarning(resourceName string, expectedItems ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("resource not found: %s", resourceName)
+		}
+
+		var acknowledged []interface{}
+		for key, value := range rs.Primary.Attributes {
+			if strings.HasPrefix(key, "delete_acknowledged_items.") && key != "delete_acknowledged_items.#" {
+				acknowledged = append(acknowledged, value)
+			}
+		}
+		d := resourceVcfaContentLibrary().TestResourceData()
+		d.SetId(rs.Primary.ID)
+		dSet(d, "org_id", rs.Primary.Attributes["org_id"])
+		dSet(d, "delete_recursive", rs.Primary.Attributes["delete_recursive"] == "true")
+		dSet(d, "delete_force", rs.Primary.Attributes["delete_force"] == "true")
+		err := d.Set("delete_acknowledged_items", acknowledged)
+		if err != nil {
+			return err
+		}
+
+		diags := resourceVcfaContentLibraryRead(context.Background(), d, testAccProvider.Meta())
+		if diags.HasError() {
+			return fmt.Errorf("error reading %s: %v", resourceName, diags)
+		}
+		if len(expectedItems) == 0 {
+			if len(diags) > 0 {
+				return fmt.Errorf("expected no warnings for %s, got: %v", resourceName, diags)
+			}
+			return nil
+		}
+		if len(diags) != 1 || diags[0].Severity != diag.Warning {
+			return fmt.Errorf("expected one warning for %s, got: %v", resourceName, diags)
+		}
+		for _, item := range expectedItems {
+			if !strings.Contains(diags[0].Detail, item) {
+				return fmt.Errorf("expected the warning of %s to mention '%s', got: %s", resourceName, item, diags[0].Detail)
+			}
+		}
+		return nil
+	}
+}
+
+const testAccVcfaContentLibraryDeletionPrerequisites = `
+data "vcfa_org" "system" {
+  name = "System"
+}
+
+data "vcfa_storage_class" "sc" {
+  region_id = {{.RegionId}}
+  name      = "{{.StorageClass}}"
+}
+`
+
+const testAccVcfaContentLibraryDeletionStep1 = testAccVcfaContentLibraryDeletionPrerequisites + `
+resource "vcfa_content_library" "cl" {
+  org_id            = data.vcfa_org.system.id
+  name              = "{{.Name}}"
+  storage_class_ids = [data.vcfa_storage_class.sc.id]
+  delete_recursive  = true
+}
+
+resource "vcfa_content_library_item" "cli" {
+  name               = "{{.Name}}Managed"
+  content_library_id = vcfa_content_library.cl.id
+  file_paths         = ["{{.IsoPath}}"]
+}
+`
+
+const testAccVcfaContentLibraryDeletionStep2 = testAccVcfaContentLibraryDeletionPrerequisites + `
+# skip-binary-test: requires a second Storage Class in tm.storageClass2
+
+data "vcfa_storage_class" "sc2" {
+  region_id = {{.RegionId}}
+  name      = "{{.StorageClass2}}"
+}
+
+resource "vcfa_content_library" "cl" {
+  org_id            = data.vcfa_org.system.id
+  name              = "{{.Name}}"
+  storage_class_ids = [data.vcfa_storage_class.sc.id, data.vcfa_storage_class.sc2.id]
+  delete_recursive  = true
+}
+
+resource "vcfa_content_library_item" "cli" {
+  name               = "{{.Name}}Managed"
+  content_library_id = vcfa_content_library.cl.id
+  file_paths         = ["{{.IsoPath}}"]
+}
+`
+
+const testAccVcfaContentLibraryDeletionStep3 = testAccVcfaContentLibraryDeletionPrerequisites + `
+# skip-binary-test: the Content Library contains an item that is created by the test
+
+resource "vcfa_content_library" "cl" {
+  org_id                    = data.vcfa_org.system.id
+  name                      = "{{.Name}}"
+  storage_class_ids         = [data.vcfa_storage_class.sc.id]
+  delete_recursive          = true
+  delete_acknowledged_items = [{{.AcknowledgedItems}}]
+}
+`
+
+const testAccVcfaContentLibraryDeletionStep4 = testAccVcfaContentLibraryDeletionPrerequisites + `
+# skip-binary-test: the deletion of the Content Library is expected to fail
+`
@@ -0,0 +1,367 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const labelKubeconfigFile = "Kubeconfig File"
+
+// kubeconfigFileMode is the file mode of the kubeconfig files managed by the provider, as they contain credentials
+const kubeconfigFileMode fs.FileMode = 0600
+
+func resourceVcfaKubeconfigFile() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaKubeconfigFileCreate,
+		ReadContext:   resourceVcfaKubeconfigFileRead,
+		UpdateContext: resourceVcfaKubeconfigFileUpdate,
+		DeleteContext: resourceVcfaKubeconfigFileDelete,
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("Path of the %s. It is created if it does not exist. A leading '~/' is expanded to the home directory", labelKubeconfigFile),
+			},
+			"kube_config_raw": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+				Description: "Kubeconfig, in JSON or YAML format, whose clusters, contexts and users are merged into the file",
+			},
+			"set_current_context": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to set the current context of the file to the current context of 'kube_config_raw'",
+			},
+			"cluster_names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Names of the clusters managed by this resource",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"context_names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Names of the contexts managed by this resource",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"user_names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Names of the users managed by this resource",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+// kubeconfigFileEntries contains the names of the entries of a kubeconfig file that are managed by a resource
+type kubeconfigFileEntries struct {
+	clusters []string
+	contexts []string
+	users    []string
+}
+
+func resourceVcfaKubeconfigFileCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+
+	path, err := getKubeconfigFilePath(d.Get("path").(string))
+	if err != nil {
+		return diag.Errorf("[%s create] %s", labelKubeconfigFile, err)
+	}
+	source, err := parseKubeconfig([]byte(d.Get("kube_config_raw").(string)))
+	if err != nil {
+		return diag.Errorf("[%s create] error parsing 'kube_config_raw': %s", labelKubeconfigFile, err)
+	}
+
+	unlock := tmClient.lockById(path)
+	defer unlock()
+
+	err = updateKubeconfigFile(path, func(kubeconfig *clientcmdapi.Config) error {
+		return mergeKubeconfig(kubeconfig, source, d.Get("set_current_context").(bool))
+	})
+	if err != nil {
+		return diag.Errorf("[%s create] error writing %s '%s': %s", labelKubeconfigFile, labelKubeconfigFile, path, err)
+	}
+
+	d.SetId(path)
+	if diags := setKubeconfigFileEntries(d, getKubeconfigEntries(source)); diags != nil {
+		return diags
+	}
+
+	return resourceVcfaKubeconfigFileRead(ctx, d, meta)
+}
+
+func resourceVcfaKubeconfigFileRead(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	kubeconfig, err := readKubeconfigFile(d.Id())
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("[DEBUG] %s '%s' no longer exists. Removing from tfstate", labelKubeconfigFile, d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.Errorf("[%s read] error reading %s '%s': %s", labelKubeconfigFile, labelKubeconfigFile, d.Id(), err)
+	}
+
+	// If the entries of the file were modified outside Terraform, the stored kubeconfig is cleared so
+	// the next plan writes them again
+	source, err := parseKubeconfig([]byte(d.Get("kube_config_raw").(string)))
+	if err != nil || !isKubeconfigMerged(kubeconfig, source) {
+		log.Printf("[DEBUG] %s '%s' entries have changed outside Terraform", labelKubeconfigFile, d.Id())
+		dSet(d, "kube_config_raw", "")
+	}
+
+	return nil
+}
+
+func resourceVcfaKubeconfigFileUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+
+	source, err := parseKubeconfig([]byte(d.Get("kube_config_raw").(string)))
+	if err != nil {
+		return diag.Errorf("[%s update] error parsing 'kube_config_raw': %s", labelKubeconfigFile, err)
+	}
+
+	unlock := tmClient.lockById(d.Id())
+	defer unlock()
+
+	previousEntries := getKubeconfigFileEntries(d)
+	err = updateKubeconfigFile(d.Id(), func(kubeconfig *clientcmdapi.Config) error {
+		removeKubeconfigEntries(kubeconfig, previousEntries)
+		return mergeKubeconfig(kubeconfig, source, d.Get("set_current_context").(bool))
+	})
+	if err != nil {
+		return diag.Errorf("[%s update] error writing %s '%s': %s", labelKubeconfigFile, labelKubeconfigFile, d.Id(), err)
+	}
+
+	if diags := setKubeconfigFileEntries(d, getKubeconfigEntries(source)); diags != nil {
+		return diags
+	}
+
+	return resourceVcfaKubeconfigFileRead(ctx, d, meta)
+}
+
+func resourceVcfaKubeconfigFileDelete(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+
+	unlock := tmClient.lockById(d.Id())
+	defer unlock()
+
+	if _, err := os.Stat(d.Id()); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	// Only the entries created by this resource are removed, the file and the rest of its contents are kept
+	entries := getKubeconfigFileEntries(d)
+	err := updateKubeconfigFile(d.Id(), func(kubeconfig *clientcmdapi.Config) error {
+		removeKubeconfigEntries(kubeconfig, entries)
+		return nil
+	})
+	if err != nil {
+		return diag.Errorf("[%s delete] error writing %s '%s': %s", labelKubeconfigFile, labelKubeconfigFile, d.Id(), err)
+	}
+
+	return nil
+}
+
+// getKubeconfigFilePath returns the absolute path of the given kubeconfig file path, expanding a leading '~/'
+// to the home directory of the current user
+func getKubeconfigFilePath(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not expand '%s': %s", path, err)
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("could not get absolute path of '%s': %s", path, err)
+	}
+	return absolutePath, nil
+}
+
+// parseKubeconfig parses a kubeconfig in JSON or YAML format
+func parseKubeconfig(raw []byte) (*clientcmdapi.Config, error) {
+	kubeconfig := &clientcmdapi.Config{}
+	if err := yaml.Unmarshal(raw, kubeconfig); err != nil {
+		return nil, err
+	}
+	return kubeconfig, nil
+}
+
+// readKubeconfigFile reads the kubeconfig file of the given path. It returns an error wrapping fs.ErrNotExist
+// if the file does not exist
+func readKubeconfigFile(path string) (*clientcmdapi.Config, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	return parseKubeconfig(raw)
+}
+
+// updateKubeconfigFile reads the kubeconfig file of the given path, or starts an empty kubeconfig if it does not
+// exist, modifies it with the given function and writes it back in YAML format with mode 0600. The file is not
+// written if the function fails
+func updateKubeconfigFile(path string, modify func(*clientcmdapi.Config) error) error {
+	kubeconfig, err := readKubeconfigFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		kubeconfig = &clientcmdapi.Config{
+			Kind:       "Config",
+			APIVersion: clientcmdapi.SchemeGroupVersion.Version,
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if err := modify(kubeconfig); err != nil {
+		return err
+	}
+
+	kubeconfigBytes, err := yaml.Marshal(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("error marshaling kubeconfig: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, kubeconfigBytes, kubeconfigFileMode); err != nil {
+		return err
+	}
+	// os.WriteFile does not change the mode of existing files
+	return os.Chmod(path, kubeconfigFileMode)
+}
+
+// mergeKubeconfig adds the clusters, contexts and users of source to the kubeconfig. It fails if the kubeconfig
+// already has entries with the same names, as they are not managed by the resource and would be lost when it is
+// destroyed
+func mergeKubeconfig(kubeconfig, source *clientcmdapi.Config, setCurrentContext bool) error {
+	existing := getKubeconfigEntries(kubeconfig)
+	var conflicts []string
+	for _, cluster := range source.Clusters {
+		if contains(existing.clusters, cluster.Name) {
+			conflicts = append(conflicts, fmt.Sprintf("cluster '%s'", cluster.Name))
+		}
+	}
+	for _, kubeContext := range source.Contexts {
+		if contains(existing.contexts, kubeContext.Name) {
+			conflicts = append(conflicts, fmt.Sprintf("context '%s'", kubeContext.Name))
+		}
+	}
+	for _, user := range source.AuthInfos {
+		if contains(existing.users, user.Name) {
+			conflicts = append(conflicts, fmt.Sprintf("user '%s'", user.Name))
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("the file already contains entries that are not managed by this resource: %s. Remove or rename them "+
+			"before applying", strings.Join(conflicts, ", "))
+	}
+
+	kubeconfig.Clusters = append(kubeconfig.Clusters, source.Clusters...)
+	kubeconfig.Contexts = append(kubeconfig.Contexts, source.Contexts...)
+	kubeconfig.AuthInfos = append(kubeconfig.AuthInfos, source.AuthInfos...)
+	if setCurrentContext && source.CurrentContext != "" {
+		kubeconfig.CurrentContext = source.CurrentContext
+	}
+	return nil
+}
+
+// removeKubeconfigEntries removes the given clusters, contexts and users from the kubeconfig. If the current
+// context is one of the removed contexts, it is cleared
+func removeKubeconfigEntries(kubeconfig *clientcmdapi.Config, entries kubeconfigFileEntries) {
+	kubeconfig.Clusters = slices.DeleteFunc(kubeconfig.Clusters, func(c clientcmdapi.NamedCluster) bool { return contains(entries.clusters, c.Name) })
+	kubeconfig.Contexts = slices.DeleteFunc(kubeconfig.Contexts, func(c clientcmdapi.NamedContext) bool { return contains(entries.contexts, c.Name) })
+	kubeconfig.AuthInfos = slices.DeleteFunc(kubeconfig.AuthInfos, func(u clientcmdapi.NamedAuthInfo) bool { return contains(entries.users, u.Name) })
+	if contains(entries.contexts, kubeconfig.CurrentContext) {
+		kubeconfig.CurrentContext = ""
+	}
+}
+
+// isKubeconfigMerged returns whether all the clusters, contexts and users of source are present, unchanged,
+// in the kubeconfig
+func isKubeconfigMerged(kubeconfig, source *clientcmdapi.Config) bool {
+	for _, cluster := range source.Clusters {
+		if !slices.ContainsFunc(kubeconfig.Clusters, func(c clientcmdapi.NamedCluster) bool { return reflect.DeepEqual(c, cluster) }) {
+			return false
+		}
+	}
+	for _, kubeContext := range source.Contexts {
+		if !slices.ContainsFunc(kubeconfig.Contexts, func(c clientcmdapi.NamedContext) bool { return reflect.DeepEqual(c, kubeContext) }) {
+			return false
+		}
+	}
+	for _, user := range source.AuthInfos {
+		if !slices.ContainsFunc(kubeconfig.AuthInfos, func(u clientcmdapi.NamedAuthInfo) bool { return reflect.DeepEqual(u, user) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// getKubeconfigEntries returns the names of the clusters, contexts and users of the given kubeconfig
+func getKubeconfigEntries(kubeconfig *clientcmdapi.Config) kubeconfigFileEntries {
+	entries := kubeconfigFileEntries{}
+	for _, cluster := range kubeconfig.Clusters {
+		entries.clusters = append(entries.clusters, cluster.Name)
+	}
+	for _, kubeContext := range kubeconfig.Contexts {
+		entries.contexts = append(entries.contexts, kubeContext.Name)
+	}
+	for _, user := range kubeconfig.AuthInfos {
+		entries.users = append(entries.users, user.Name)
+	}
+	return entries
+}
+
+// getKubeconfigFileEntries returns the names of the entries managed by the resource, as stored in state
+func getKubeconfigFileEntries(d *schema.ResourceData) kubeconfigFileEntries {
+	// The stored names are needed, as the configured kubeconfig may have changed already
+	clusters, _ := d.GetChange("cluster_names")
+	contexts, _ := d.GetChange("context_names")
+	users, _ := d.GetChange("user_names")
+	return kubeconfigFileEntries{
+		clusters: convertTypeListToSliceOfStrings(clusters.([]interface{})),
+		contexts: convertTypeListToSliceOfStrings(contexts.([]interface{})),
+		users:    convertTypeListToSliceOfStrings(users.([]interface{})),
+	}
+}
+
+func setKubeconfigFileEntries(d *schema.ResourceData, entries kubeconfigFileEntries) diag.Diagnostics {
+	if err := d.Set("cluster_names", entries.clusters); err != nil {
+		return diag.Errorf("error setting cluster_names: %s", err)
+	}
+	if err := d.Set("context_names", entries.contexts); err != nil {
+		return diag.Errorf("error setting context_names: %s", err)
+	}
+	if err := d.Set("user_names", entries.users); err != nil {
+		return diag.Errorf("error setting user_names: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+//go:build cci || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// testAccKubeconfigFileExisting is a kubeconfig with entries that are not managed by Terraform
+const testAccKubeconfigFileExisting = `apiVersion: v1
+kind: Config
+clusters:
+- name: existing-cluster
+  cluster:
+    server: https://existing.example.com
+contexts:
+- name: existing-context
+  context:
+    cluster: existing-cluster
+    user: existing-user
+current-context: existing-context
+users:
+- name: existing-user
+  user:
+    token: existing-token
+`
+
+func TestAccVcfaKubeconfigFile(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+
+	kubeconfigPath := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(kubeconfigPath, []byte(testAccKubeconfigFileExisting), 0644)
+	if err != nil {
+		t.Fatalf("error writing kubeconfig file: %s", err)
+	}
+
+	var params = StringMap{
+		"Testname": t.Name(),
+		"Path":     kubeconfigPath,
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	configText1 := templateFill(testAccVcfaKubeconfigFileStep1, params)
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		CheckDestroy:      testAccCheckKubeconfigFileDestroy(kubeconfigPath, testConfig.Org.Name),
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcfa_kubeconfig_file.test", "id", kubeconfigPath),
+					resource.TestCheckResourceAttr("vcfa_kubeconfig_file.test", "context_names.#", "1"),
+					resource.TestCheckResourceAttr("vcfa_kubeconfig_file.test", "context_names.0", testConfig.Org.Name),
+					resource.TestCheckResourceAttrPair("vcfa_kubeconfig_file.test", "user_names.0", "data.vcfa_kubeconfig.test", "user"),
+					testAccCheckKubeconfigFile(kubeconfigPath, testConfig.Org.Name),
+				),
+			},
+		},
+	})
+}
+
+// TestAccVcfaKubeconfigFileConflict checks that the entries of the file that are not managed by Terraform are not
+// replaced when the kubeconfig has entries with the same name
+func TestAccVcfaKubeconfigFileConflict(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+
+	// The existing context has the same name as the context of the kubeconfig
+	existing := strings.ReplaceAll(testAccKubeconfigFileExisting, "existing-context", testConfig.Org.Name)
+	kubeconfigPath := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(kubeconfigPath, []byte(existing), 0644)
+	if err != nil {
+		t.Fatalf("error writing kubeconfig file: %s", err)
+	}
+
+	var params = StringMap{
+		"Testname": t.Name(),
+		"Path":     kubeconfigPath,
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	configText1 := templateFill(testAccVcfaKubeconfigFileStep1, params)
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		CheckDestroy: func(s *terraform.State) error {
+			contents, err := os.ReadFile(filepath.Clean(kubeconfigPath))
+			if err != nil {
+				return err
+			}
+			if string(contents) != existing {
+				return fmt.Errorf("the kubeconfig file was modified:\n%s", contents)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config:      configText1,
+				ExpectError: regexp.MustCompile(fmt.Sprintf(`context\s+'%s'`, regexp.QuoteMeta(testConfig.Org.Name))),
+			},
+		},
+	})
+}
+
+// testAccCheckKubeconfigFile checks that the kubeconfig file has mode 0600 and contains both the existing
+// entries and the context managed by Terraform, which is the current context
+func testAccCheckKubeconfigFile(path, contextName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm() != kubeconfigFileMode {
+			return fmt.Errorf("expected kubeconfig file mode %s, got %s", kubeconfigFileMode, info.Mode().Perm())
+		}
+		kubeconfig, err := readKubeconfigFile(path)
+		if err != nil {
+			return err
+		}
+		names := getKubeconfigEntries(kubeconfig)
+		if !contains(names.contexts, "existing-context") || !contains(names.contexts, contextName) {
+			return fmt.Errorf("expected contexts 'existing-context' and '%s', got %v", contextName, names.contexts)
+		}
+		if !contains(names.users, "existing-user") || !contains(names.clusters, "existing-cluster") {
+			return fmt.Errorf("existing user or cluster were removed from the kubeconfig file")
+		}
+		if kubeconfig.CurrentContext != contextName {
+			return fmt.Errorf("expected current context '%s', got '%s'", contextName, kubeconfig.CurrentContext)
+		}
+		return nil
+	}
+}
+
+// testAccCheckKubeconfigFileDestroy checks that only the context managed by Terraform was removed from the
+// kubeconfig file
+func testAccCheckKubeconfigFileDestroy(path, contextName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		kubeconfig, err := readKubeconfigFile(path)
+		if err != nil {
+			return err
+		}
+		names := getKubeconfigEntries(kubeconfig)
+		if contains(names.contexts, contextName) {
+			return fmt.Errorf("context '%s' was not removed from the kubeconfig file", contextName)
+		}
+		if len(names.contexts) != 1 || len(names.users) != 1 || len(names.clusters) != 1 {
+			return fmt.Errorf("expected only the existing entries in the kubeconfig file, got %v", names)
+		}
+		return nil
+	}
+}
+
+const testAccVcfaKubeconfigFileStep1 = `
+data "vcfa_kubeconfig" "test" {}
+
+resource "vcfa_kubeconfig_file" "test" {
+  path                = "{{.Path}}"
+  kube_config_raw     = data.vcfa_kubeconfig.test.kube_config_raw_yaml
+  set_current_context = true
+}
+`
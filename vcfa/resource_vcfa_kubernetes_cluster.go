@@ -0,0 +1,592 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const labelKubernetesCluster = "Kubernetes Cluster"
+
+var kubernetesClusterControlPlaneSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"replicas": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      1,
+			Description:  "Number of control plane nodes. One of 1, 3 or 5",
+			ValidateFunc: validation.IntInSlice([]int{1, 3, 5}),
+		},
+		"vm_class": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: fmt.Sprintf("VM Class of the control plane nodes. It must be one of the VM Classes of the %s", labelSupervisorNamespace),
+		},
+		"storage_class": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: fmt.Sprintf("Storage Class of the control plane nodes. It must be one of the Storage Classes of the %s", labelSupervisorNamespace),
+		},
+	},
+}
+
+var kubernetesClusterWorkerPoolSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Name of the worker pool",
+		},
+		"class": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "node-pool",
+			Description: "Machine Deployment class of the Cluster Class used by the worker pool",
+		},
+		"replicas": {
+			Type:         schema.TypeInt,
+			Required:     true,
+			Description:  "Number of worker nodes in the pool",
+			ValidateFunc: validation.IntAtLeast(0),
+		},
+		"vm_class": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: fmt.Sprintf("VM Class of the worker nodes. It must be one of the VM Classes of the %s", labelSupervisorNamespace),
+		},
+		"storage_class": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: fmt.Sprintf("Storage Class of the worker nodes. It must be one of the Storage Classes of the %s", labelSupervisorNamespace),
+		},
+	},
+}
+
+func resourceVcfaKubernetesCluster() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaKubernetesClusterCreateUpdate,
+		ReadContext:   resourceVcfaKubernetesClusterRead,
+		UpdateContext: resourceVcfaKubernetesClusterCreateUpdate,
+		DeleteContext: resourceVcfaKubernetesClusterDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceVcfaKubernetesClusterImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  fmt.Sprintf("Name of the %s", labelKubernetesCluster),
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the Project where the %s belongs to", labelSupervisorNamespace),
+			},
+			"supervisor_namespace_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the %s where the %s is created", labelSupervisorNamespace, labelKubernetesCluster),
+			},
+			"cluster_class": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("Name of the Cluster Class used to create the %s, for example 'builtin-generic-v3.1.0'", labelKubernetesCluster),
+			},
+			"kubernetes_version": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("Kubernetes release of the %s. Changing it upgrades the %s in place", labelKubernetesCluster, labelKubernetesCluster),
+			},
+			"control_plane": {
+				Type:        schema.TypeList,
+				Required:    true,
+				MaxItems:    1,
+				Description: fmt.Sprintf("Control plane configuration of the %s", labelKubernetesCluster),
+				Elem:        kubernetesClusterControlPlaneSchema,
+			},
+			"worker_pool": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: fmt.Sprintf("Worker pools of the %s", labelKubernetesCluster),
+				Elem:        kubernetesClusterWorkerPoolSchema,
+			},
+			"services_cidr_blocks": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "CIDR blocks used for the Kubernetes services",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsCIDR,
+				},
+			},
+			"pods_cidr_blocks": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "CIDR blocks used for the Kubernetes pods",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsCIDR,
+				},
+			},
+			"phase": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Phase of the %s", labelKubernetesCluster),
+			},
+			"ready": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: fmt.Sprintf("Whether the %s is in a ready status or not", labelKubernetesCluster),
+			},
+			"control_plane_endpoint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("URL of the Kubernetes API of the %s", labelKubernetesCluster),
+			},
+			"kube_config_raw": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: fmt.Sprintf("Raw admin kubeconfig of the %s", labelKubernetesCluster),
+			},
+		},
+	}
+}
+
+func resourceVcfaKubernetesClusterCreateUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	name := d.Get("name").(string)
+	projectName := d.Get("project_name").(string)
+	supervisorNamespaceName := d.Get("supervisor_namespace_name").(string)
+
+	// VM Classes and Storage Classes of all the nodes are validated before sending anything
+	controlPlane := d.Get("control_plane").([]interface{})[0].(map[string]interface{})
+	endpoint, err := validateVirtualMachineClasses(tmClient, projectName, supervisorNamespaceName, controlPlane["vm_class"].(string), controlPlane["storage_class"].(string))
+	if err != nil {
+		return diag.Errorf("error validating control plane of %s %s: %s", labelKubernetesCluster, name, err)
+	}
+	for _, wp := range d.Get("worker_pool").([]interface{}) {
+		workerPool := wp.(map[string]interface{})
+		if _, err := validateVirtualMachineClasses(tmClient, projectName, supervisorNamespaceName, workerPool["vm_class"].(string), workerPool["storage_class"].(string)); err != nil {
+			return diag.Errorf("error validating worker pool %s of %s %s: %s", workerPool["name"], labelKubernetesCluster, name, err)
+		}
+	}
+
+	clusterURL, err := buildKubernetesClusterURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelKubernetesCluster, err)
+	}
+
+	// Server-side apply allows to scale and upgrade the cluster by sending only the fields managed by Terraform
+	cluster, err := applyKubernetesObject(tmClient, clusterURL, defaultFieldManager, true, getKubernetesClusterObject(d))
+	if err != nil {
+		return diag.Errorf("error applying %s %s in %s %s: %s", labelKubernetesCluster, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	// The ID is set before waiting, so a Kubernetes Cluster that fails to become ready is tainted instead of lost
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+
+	timeout := d.Timeout(schema.TimeoutCreate)
+	if !d.IsNewResource() {
+		timeout = d.Timeout(schema.TimeoutUpdate)
+	}
+	// Waiting for the observed generation guarantees that the Ready condition refers to the latest changes
+	fields := map[string]string{}
+	if generation, ok := getKubernetesObjectField(cluster, "metadata.generation"); ok {
+		fields["status.observedGeneration"] = fmt.Sprintf("%v", generation)
+	}
+	conditions := []kubernetesObjectCondition{{conditionType: "Ready", status: "True"}}
+	if _, err := waitForKubernetesObject(ctx, tmClient, clusterURL, conditions, fields, timeout); err != nil {
+		return diag.Errorf("error waiting for %s %s in %s %s to be ready: %s", labelKubernetesCluster, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	return resourceVcfaKubernetesClusterRead(ctx, d, meta)
+}
+
+func resourceVcfaKubernetesClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, supervisorNamespaceName, name, err := parseSupervisorNamespaceObjectId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelKubernetesCluster, d.Id(), err)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists. Removing %s %s from tfstate", labelSupervisorNamespace, supervisorNamespaceName, labelKubernetesCluster, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	clusterURL, err := buildKubernetesClusterURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelKubernetesCluster, err)
+	}
+	cluster, err := readKubernetesObject(tmClient, clusterURL)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists. Removing from tfstate", labelKubernetesCluster, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading %s %s: %s", labelKubernetesCluster, name, err)
+	}
+
+	if err := setKubernetesClusterData(d, projectName, supervisorNamespaceName, name, cluster); err != nil {
+		return diag.Errorf("error setting %s data: %s", labelKubernetesCluster, err)
+	}
+
+	// The admin kubeconfig is only available once the control plane is initialized
+	kubeconfig := ""
+	if d.Get("ready").(bool) {
+		kubeconfig, err = getKubernetesClusterKubeconfig(tmClient, endpoint, supervisorNamespaceName, name)
+		if err != nil {
+			return diag.Errorf("error retrieving kubeconfig of %s %s: %s", labelKubernetesCluster, name, err)
+		}
+	}
+	dSet(d, "kube_config_raw", kubeconfig)
+
+	return nil
+}
+
+func resourceVcfaKubernetesClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, supervisorNamespaceName, name, err := parseSupervisorNamespaceObjectId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelKubernetesCluster, d.Id(), err)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		// The Kubernetes Cluster is removed together with its Supervisor Namespace
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists, so %s %s is already deleted", labelSupervisorNamespace, supervisorNamespaceName, labelKubernetesCluster, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	clusterURL, err := buildKubernetesClusterURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelKubernetesCluster, err)
+	}
+	if err := deleteKubernetesObject(tmClient, clusterURL); err != nil && !govcd.ContainsNotFound(err) {
+		return diag.Errorf("error deleting %s %s in %s %s: %s", labelKubernetesCluster, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+	if err := waitForKubernetesObjectDeletion(ctx, tmClient, clusterURL, d.Timeout(schema.TimeoutDelete)); err != nil {
+		return diag.Errorf("error waiting for %s %s in %s %s to be deleted: %s", labelKubernetesCluster, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func resourceVcfaKubernetesClusterImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	tmClient := meta.(ClientContainer).tmClient
+	idSlice := strings.Split(d.Id(), ImportSeparator)
+	if len(idSlice) != 3 {
+		return nil, fmt.Errorf("expected import ID to be <project_name>%s<supervisor_namespace_name>%s<cluster_name>", ImportSeparator, ImportSeparator)
+	}
+	projectName := idSlice[0]
+	supervisorNamespaceName := idSlice[1]
+	name := idSlice[2]
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return nil, err
+	}
+	clusterURL, err := buildKubernetesClusterURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return nil, fmt.Errorf("error building %s URL: %s", labelKubernetesCluster, err)
+	}
+	if _, err := readKubernetesObject(tmClient, clusterURL); err != nil {
+		return nil, fmt.Errorf("error reading %s %s: %s", labelKubernetesCluster, name, err)
+	}
+
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+	dSet(d, "project_name", projectName)
+	dSet(d, "supervisor_namespace_name", supervisorNamespaceName)
+	dSet(d, "name", name)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func buildKubernetesClusterURL(endpoint, supervisorNamespaceName, name string) (*url.URL, error) {
+	return buildKubernetesObjectURL(endpoint, cciClusterAPI+"/"+cciClusterVersion, &v1.APIResource{Name: cciClusterResource, Namespaced: true}, supervisorNamespaceName, name)
+}
+
+// getKubernetesClusterObject builds a Cluster API Cluster that uses a managed topology, where the
+// VM Class and Storage Class of the nodes are set with the 'vmClass' and 'storageClass' variables
+func getKubernetesClusterObject(d *schema.ResourceData) map[string]interface{} {
+	controlPlane := d.Get("control_plane").([]interface{})[0].(map[string]interface{})
+
+	machineDeployments := []interface{}{}
+	for _, wp := range d.Get("worker_pool").([]interface{}) {
+		workerPool := wp.(map[string]interface{})
+		machineDeployments = append(machineDeployments, map[string]interface{}{
+			"class":    workerPool["class"].(string),
+			"name":     workerPool["name"].(string),
+			"replicas": workerPool["replicas"].(int),
+			"variables": map[string]interface{}{
+				"overrides": getKubernetesClusterClassVariables(workerPool["vm_class"].(string), workerPool["storage_class"].(string)),
+			},
+		})
+	}
+
+	spec := map[string]interface{}{
+		"topology": map[string]interface{}{
+			"class":   d.Get("cluster_class").(string),
+			"version": d.Get("kubernetes_version").(string),
+			"controlPlane": map[string]interface{}{
+				"replicas": controlPlane["replicas"].(int),
+			},
+			"workers": map[string]interface{}{
+				"machineDeployments": machineDeployments,
+			},
+			"variables": getKubernetesClusterClassVariables(controlPlane["vm_class"].(string), controlPlane["storage_class"].(string)),
+		},
+	}
+
+	clusterNetwork := map[string]interface{}{}
+	if services := d.Get("services_cidr_blocks").([]interface{}); len(services) > 0 {
+		clusterNetwork["services"] = map[string]interface{}{"cidrBlocks": services}
+	}
+	if pods := d.Get("pods_cidr_blocks").([]interface{}); len(pods) > 0 {
+		clusterNetwork["pods"] = map[string]interface{}{"cidrBlocks": pods}
+	}
+	if len(clusterNetwork) > 0 {
+		spec["clusterNetwork"] = clusterNetwork
+	}
+
+	return map[string]interface{}{
+		"apiVersion": cciClusterAPI + "/" + cciClusterVersion,
+		"kind":       cciClusterKind,
+		"metadata": map[string]interface{}{
+			"name":      d.Get("name").(string),
+			"namespace": d.Get("supervisor_namespace_name").(string),
+		},
+		"spec": spec,
+	}
+}
+
+func getKubernetesClusterClassVariables(vmClass, storageClass string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{"name": "vmClass", "value": vmClass},
+		map[string]interface{}{"name": "storageClass", "value": storageClass},
+	}
+}
+
+// getKubernetesClusterClassVariable returns the value of the Cluster Class variable with the given name
+func getKubernetesClusterClassVariable(variables interface{}, name string) string {
+	variableList, ok := variables.([]interface{})
+	if !ok {
+		return ""
+	}
+	for _, v := range variableList {
+		variable, ok := v.(map[string]interface{})
+		if !ok || variable["name"] != name {
+			continue
+		}
+		if value, ok := variable["value"].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// getKubernetesObjectInt returns the integer value of the given field of a Kubernetes object, or 0 if
+// it is not present. Numbers are decoded as float64 from JSON
+func getKubernetesObjectInt(object map[string]interface{}, path string) int {
+	value, ok := getKubernetesObjectField(object, path)
+	if !ok {
+		return 0
+	}
+	if number, ok := value.(float64); ok {
+		return int(number)
+	}
+	return 0
+}
+
+func getKubernetesObjectString(object map[string]interface{}, path string) string {
+	value, ok := getKubernetesObjectField(object, path)
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func setKubernetesClusterData(d *schema.ResourceData, projectName, supervisorNamespaceName, name string, cluster map[string]interface{}) error {
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+	dSet(d, "name", name)
+	dSet(d, "project_name", projectName)
+	dSet(d, "supervisor_namespace_name", supervisorNamespaceName)
+	dSet(d, "cluster_class", getKubernetesObjectString(cluster, "spec.topology.class"))
+	dSet(d, "kubernetes_version", getKubernetesObjectString(cluster, "spec.topology.version"))
+
+	variables, _ := getKubernetesObjectField(cluster, "spec.topology.variables")
+	controlPlane := []interface{}{
+		map[string]interface{}{
+			"replicas":      getKubernetesObjectInt(cluster, "spec.topology.controlPlane.replicas"),
+			"vm_class":      getKubernetesClusterClassVariable(variables, "vmClass"),
+			"storage_class": getKubernetesClusterClassVariable(variables, "storageClass"),
+		},
+	}
+	if err := d.Set("control_plane", controlPlane); err != nil {
+		return err
+	}
+
+	workerPools := []interface{}{}
+	if machineDeployments, ok := getKubernetesObjectField(cluster, "spec.topology.workers.machineDeployments"); ok {
+		for _, md := range machineDeployments.([]interface{}) {
+			machineDeployment, ok := md.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Worker pools inherit the cluster variables unless they override them
+			overrides, _ := getKubernetesObjectField(machineDeployment, "variables.overrides")
+			vmClass := getKubernetesClusterClassVariable(overrides, "vmClass")
+			if vmClass == "" {
+				vmClass = getKubernetesClusterClassVariable(variables, "vmClass")
+			}
+			storageClass := getKubernetesClusterClassVariable(overrides, "storageClass")
+			if storageClass == "" {
+				storageClass = getKubernetesClusterClassVariable(variables, "storageClass")
+			}
+			workerPools = append(workerPools, map[string]interface{}{
+				"name":          getKubernetesObjectString(machineDeployment, "name"),
+				"class":         getKubernetesObjectString(machineDeployment, "class"),
+				"replicas":      getKubernetesObjectInt(machineDeployment, "replicas"),
+				"vm_class":      vmClass,
+				"storage_class": storageClass,
+			})
+		}
+	}
+	if err := d.Set("worker_pool", workerPools); err != nil {
+		return err
+	}
+
+	servicesCidrBlocks, _ := getKubernetesObjectField(cluster, "spec.clusterNetwork.services.cidrBlocks")
+	if err := d.Set("services_cidr_blocks", servicesCidrBlocks); err != nil {
+		return err
+	}
+	podsCidrBlocks, _ := getKubernetesObjectField(cluster, "spec.clusterNetwork.pods.cidrBlocks")
+	if err := d.Set("pods_cidr_blocks", podsCidrBlocks); err != nil {
+		return err
+	}
+
+	dSet(d, "phase", getKubernetesObjectString(cluster, "status.phase"))
+
+	ready := false
+	if rawConditions, ok := getKubernetesObjectField(cluster, "status.conditions"); ok {
+		conditions := []cciStatusCondition{}
+		conditionsJson, err := json.Marshal(rawConditions)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(conditionsJson, &conditions); err != nil {
+			return fmt.Errorf("error reading status conditions: %s", err)
+		}
+		ready = isCciConditionTrue(conditions, "ready")
+	}
+	dSet(d, "ready", ready)
+
+	controlPlaneEndpoint := ""
+	if host := getKubernetesObjectString(cluster, "spec.controlPlaneEndpoint.host"); host != "" {
+		controlPlaneEndpoint = fmt.Sprintf("https://%s:%d", host, getKubernetesObjectInt(cluster, "spec.controlPlaneEndpoint.port"))
+	}
+	dSet(d, "control_plane_endpoint", controlPlaneEndpoint)
+
+	return nil
+}
+
+// getKubernetesClusterKubeconfig builds an admin kubeconfig for the given Kubernetes Cluster, using the
+// credentials that Cluster API stores in the '<cluster name>-kubeconfig' Secret
+func getKubernetesClusterKubeconfig(tmClient *VCDClient, endpoint, supervisorNamespaceName, name string) (string, error) {
+	secretURL, err := buildKubernetesObjectURL(endpoint, "v1", &v1.APIResource{Name: "secrets", Namespaced: true}, supervisorNamespaceName, name+"-kubeconfig")
+	if err != nil {
+		return "", fmt.Errorf("error building kubeconfig Secret URL: %s", err)
+	}
+	secret, err := readKubernetesObject(tmClient, secretURL)
+	if err != nil {
+		return "", fmt.Errorf("error reading kubeconfig Secret: %s", err)
+	}
+	encodedValue := getKubernetesObjectString(secret, "data.value")
+	if encodedValue == "" {
+		return "", fmt.Errorf("kubeconfig Secret of %s %s is empty", labelKubernetesCluster, name)
+	}
+	value, err := base64.StdEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return "", fmt.Errorf("error decoding kubeconfig Secret: %s", err)
+	}
+
+	generatedKubeconfig := &clientcmdapi.Config{}
+	if err := yaml.Unmarshal(value, generatedKubeconfig); err != nil {
+		return "", fmt.Errorf("error parsing kubeconfig Secret: %s", err)
+	}
+	if len(generatedKubeconfig.Clusters) == 0 || len(generatedKubeconfig.AuthInfos) == 0 {
+		return "", fmt.Errorf("kubeconfig Secret of %s %s does not contain clusters or users", labelKubernetesCluster, name)
+	}
+
+	username := fmt.Sprintf("%s-admin", name)
+	contextName := fmt.Sprintf("%s@%s", username, name)
+	kubeconfig := &clientcmdapi.Config{
+		Kind:       "Config",
+		APIVersion: clientcmdapi.SchemeGroupVersion.Version,
+		Clusters: []clientcmdapi.NamedCluster{{
+			Name:    name,
+			Cluster: generatedKubeconfig.Clusters[0].Cluster,
+		}},
+		Contexts: []clientcmdapi.NamedContext{
+			{
+				Name: contextName,
+				Context: clientcmdapi.Context{
+					Cluster:  name,
+					AuthInfo: username,
+				},
+			},
+		},
+		AuthInfos: []clientcmdapi.NamedAuthInfo{
+			{
+				Name:     username,
+				AuthInfo: generatedKubeconfig.AuthInfos[0].AuthInfo,
+			},
+		},
+		CurrentContext: contextName,
+	}
+
+	kubeconfigBytes, err := json.MarshalIndent(kubeconfig, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling kubeconfig: %s", err)
+	}
+	return string(kubeconfigBytes), nil
+}
@@ -0,0 +1,118 @@
+//go:build cci || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccVcfaKubernetesCluster(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfSysAdmin(t)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	var params = StringMap{
+		"Testname":           t.Name(),
+		"ProjectName":        "tf-project-cluster",
+		"ClusterName":        "tf-cluster",
+		"RegionName":         testConfig.Cci.Region,
+		"VpcName":            testConfig.Cci.Vpc,
+		"StorageClassName":   testConfig.Cci.StoragePolicy,
+		"SupervisorZoneName": testConfig.Cci.SupervisorZone,
+		"VmClass":            testConfig.Cci.VmClass,
+		"ClusterClass":       testConfig.Cci.ClusterClass,
+		"KubernetesVersion":  testConfig.Cci.KubernetesVersion,
+		"WorkerReplicas":     "1",
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	// Setup project and defer cleanup
+	cleanup := setupProject(t, params["ProjectName"].(string))
+	defer cleanup()
+
+	configText1 := templateFill(testAccVcfaKubernetesClusterStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	params["WorkerReplicas"] = "2"
+	configText2 := templateFill(testAccVcfaKubernetesClusterStep1, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("vcfa_kubernetes_cluster.test", "id", regexp.MustCompile(fmt.Sprintf(`^%s:terraform-test.*:%s$`, params["ProjectName"].(string), params["ClusterName"].(string)))),
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "cluster_class", params["ClusterClass"].(string)),
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "kubernetes_version", params["KubernetesVersion"].(string)),
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "control_plane.0.replicas", "1"),
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "worker_pool.#", "1"),
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "worker_pool.0.replicas", "1"),
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "ready", "true"),
+					resource.TestCheckResourceAttrSet("vcfa_kubernetes_cluster.test", "control_plane_endpoint"),
+					resource.TestCheckResourceAttrSet("vcfa_kubernetes_cluster.test", "kube_config_raw"),
+				),
+			},
+			{
+				Config: configText2,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "worker_pool.0.replicas", "2"),
+					resource.TestCheckResourceAttr("vcfa_kubernetes_cluster.test", "ready", "true"),
+				),
+			},
+			{
+				ResourceName:      "vcfa_kubernetes_cluster.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					rs, ok := s.RootModule().Resources["vcfa_kubernetes_cluster.test"]
+					if !ok {
+						return "", fmt.Errorf("resource vcfa_kubernetes_cluster.test not found")
+					}
+					return params["ProjectName"].(string) + ImportSeparator + rs.Primary.Attributes["supervisor_namespace_name"] + ImportSeparator + params["ClusterName"].(string), nil
+				},
+			},
+		},
+	})
+}
+
+const testAccVcfaKubernetesClusterStep1 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+resource "vcfa_kubernetes_cluster" "test" {
+  name                      = "{{.ClusterName}}"
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  cluster_class             = "{{.ClusterClass}}"
+  kubernetes_version        = "{{.KubernetesVersion}}"
+
+  control_plane {
+    replicas      = 1
+    vm_class      = "{{.VmClass}}"
+    storage_class = "{{.StorageClassName}}"
+  }
+
+  worker_pool {
+    name          = "pool-1"
+    replicas      = {{.WorkerReplicas}}
+    vm_class      = "{{.VmClass}}"
+    storage_class = "{{.StorageClassName}}"
+  }
+}
+`
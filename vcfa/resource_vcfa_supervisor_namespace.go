@@ -430,6 +430,18 @@ func readSupervisorNamespace(tmClient *VCDClient, projectName string, supervisor
 	return supervisorNamespace, nil
 }
 
+func listSupervisorNamespaces(tmClient *VCDClient, projectName string) ([]ccitypes.SupervisorNamespace, error) {
+	var supervisorNamespaces cciSupervisorNamespaceList
+	supervisorNamespaceURL, err := buildSupervisorNamespaceURL(tmClient, projectName, "")
+	if err != nil {
+		return nil, fmt.Errorf("error building %s URL: %s", labelSupervisorNamespace, err)
+	}
+	if err := tmClient.VCDClient.Client.GetEntity(supervisorNamespaceURL, nil, &supervisorNamespaces, nil); err != nil {
+		return nil, fmt.Errorf("error listing %ss in Project %s: %s", labelSupervisorNamespace, projectName, err)
+	}
+	return supervisorNamespaces.Items, nil
+}
+
 func deleteSupervisorNamespace(tmClient *VCDClient, projectName string, supervisorNamespaceName string) error {
 	supervisorNamespaceURL, err := buildSupervisorNamespaceURL(tmClient, projectName, supervisorNamespaceName)
 	if err != nil {
@@ -441,6 +453,35 @@ func deleteSupervisorNamespace(tmClient *VCDClient, projectName string, supervis
 	return nil
 }
 
+// isSupervisorNamespaceReady returns true if the given Supervisor Namespace has a Ready condition with status True
+func isSupervisorNamespaceReady(supervisorNamespace ccitypes.SupervisorNamespace) bool {
+	if supervisorNamespace.Status == nil {
+		return false
+	}
+	for _, condition := range supervisorNamespace.Status.Conditions {
+		if strings.ToLower(condition.Type) == "ready" {
+			return strings.ToLower(condition.Status) == "true"
+		}
+	}
+	return false
+}
+
+// getSupervisorNamespaceEndpoint retrieves the Kubernetes API endpoint of the given Supervisor Namespace. The
+// Supervisor Namespace must be ready, otherwise an error is returned
+func getSupervisorNamespaceEndpoint(tmClient *VCDClient, projectName string, supervisorNamespaceName string) (string, error) {
+	supervisorNamespace, err := readSupervisorNamespace(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %s", labelSupervisorNamespace, err)
+	}
+	if !isSupervisorNamespaceReady(supervisorNamespace) {
+		return "", fmt.Errorf("%s %s is not in a ready status", labelSupervisorNamespace, supervisorNamespaceName)
+	}
+	if supervisorNamespace.Status.NamespaceEndpointURL == "" {
+		return "", fmt.Errorf("unable to retrieve the endpoint URL for %s %s", labelSupervisorNamespace, supervisorNamespaceName)
+	}
+	return supervisorNamespace.Status.NamespaceEndpointURL, nil
+}
+
 func buildSupervisorNamespaceURL(tmClient *VCDClient, projectName string, supervisorNamespaceName string) (*url.URL, error) {
 	supervisorNamespaceRawURL := fmt.Sprintf(ccitypes.SupervisorNamespacesURL, projectName)
 	if supervisorNamespaceName != "" {
@@ -462,6 +503,19 @@ func parseResourceId(id string) (string, string, error) {
 	return idParts[0], idParts[1], nil
 }
 
+// buildSupervisorNamespaceObjectId builds the ID of resources that live inside a Supervisor Namespace
+func buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name string) string {
+	return fmt.Sprintf("%s:%s:%s", projectName, supervisorNamespaceName, name)
+}
+
+func parseSupervisorNamespaceObjectId(id string) (string, string, string, error) {
+	idParts := strings.Split(id, ":")
+	if len(idParts) != 3 {
+		return "", "", "", fmt.Errorf("id %s does not contain three parts", id)
+	}
+	return idParts[0], idParts[1], idParts[2], nil
+}
+
 func setSupervisorNamespaceData(_ *VCDClient, d *schema.ResourceData, projectName string, supervisorNamespaceName string, supervisorNamespace ccitypes.SupervisorNamespace) error {
 	d.SetId(buildResourceId(projectName, supervisorNamespaceName))
 	dSet(d, "name", supervisorNamespaceName)
@@ -0,0 +1,367 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const labelSupervisorNamespaceContentLibrary = "Supervisor Namespace Content Library"
+
+// contentLibraryUrnPrefix is the prefix of the IDs of Content Libraries
+const contentLibraryUrnPrefix = "urn:vcloud:contentLibrary:"
+
+func resourceVcfaSupervisorNamespaceContentLibrary() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaSupervisorNamespaceContentLibraryCreate,
+		ReadContext:   resourceVcfaSupervisorNamespaceContentLibraryRead,
+		DeleteContext: resourceVcfaSupervisorNamespaceContentLibraryDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceVcfaSupervisorNamespaceContentLibraryImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the Project the %s belongs to", labelSupervisorNamespace),
+			},
+			"supervisor_namespace_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the %s to attach the %s to", labelSupervisorNamespace, labelVcfaContentLibrary),
+			},
+			"content_library_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("ID of the %s to attach", labelVcfaContentLibrary),
+			},
+			"wait_for_images": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: fmt.Sprintf("Whether to wait until all the items of the %s are available as ready Virtual Machine Images in the %s", labelVcfaContentLibrary, labelSupervisorNamespace),
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Name of the Kubernetes object that attaches the %s to the %s", labelVcfaContentLibrary, labelSupervisorNamespace),
+			},
+			"ready": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: fmt.Sprintf("Whether the %s is ready in the %s", labelVcfaContentLibrary, labelSupervisorNamespace),
+			},
+			"image_names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: fmt.Sprintf("Names of the Virtual Machine Images of the %s items that are available in the %s", labelVcfaContentLibrary, labelSupervisorNamespace),
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func resourceVcfaSupervisorNamespaceContentLibraryCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName := d.Get("project_name").(string)
+	supervisorNamespaceName := d.Get("supervisor_namespace_name").(string)
+	contentLibraryId := d.Get("content_library_id").(string)
+
+	contentLibrary, err := tmClient.GetContentLibraryById(contentLibraryId, nil)
+	if err != nil {
+		return diag.Errorf("error retrieving %s %s: %s", labelVcfaContentLibrary, contentLibraryId, err)
+	}
+	contentLibraryUuid, err := getContentLibraryUuid(contentLibrary.ContentLibrary.ID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := getSupervisorNamespaceContentLibraryName(contentLibraryUuid)
+	attachment := cciContentLibrary{
+		TypeMeta: v1.TypeMeta{
+			Kind:       cciContentLibraryKind,
+			APIVersion: cciContentLibraryAPI + "/" + cciContentLibraryVersion,
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: supervisorNamespaceName,
+		},
+		Spec: cciContentLibrarySpec{
+			UUID: contentLibraryUuid,
+		},
+	}
+
+	attachmentURL, err := buildSupervisorNamespaceContentLibraryURL(endpoint, supervisorNamespaceName, "")
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelSupervisorNamespaceContentLibrary, err)
+	}
+	if err := createCciEntity(tmClient, attachmentURL, &attachment, &cciContentLibrary{}); err != nil {
+		return diag.Errorf("error attaching %s %s to %s %s: %s", labelVcfaContentLibrary, contentLibrary.ContentLibrary.Name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	// The ID is set before waiting, so an attachment that never syncs is tainted instead of lost
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+
+	attachmentURL, err = buildSupervisorNamespaceContentLibraryURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelSupervisorNamespaceContentLibrary, err)
+	}
+	_, err = waitForKubernetesObject(ctx, tmClient, attachmentURL, []kubernetesObjectCondition{{conditionType: "Ready", status: "True"}}, nil, d.Timeout(schema.TimeoutCreate))
+	if err != nil {
+		return diag.Errorf("error waiting for %s %s to be ready in %s %s: %s", labelVcfaContentLibrary, contentLibrary.ContentLibrary.Name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	if d.Get("wait_for_images").(bool) {
+		if err := waitForContentLibraryImages(ctx, tmClient, contentLibrary, endpoint, supervisorNamespaceName, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return diag.Errorf("error waiting for the items of %s %s to be synced to %s %s: %s", labelVcfaContentLibrary, contentLibrary.ContentLibrary.Name, labelSupervisorNamespace, supervisorNamespaceName, err)
+		}
+	}
+
+	return resourceVcfaSupervisorNamespaceContentLibraryRead(ctx, d, meta)
+}
+
+func resourceVcfaSupervisorNamespaceContentLibraryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, supervisorNamespaceName, name, err := parseSupervisorNamespaceObjectId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelSupervisorNamespaceContentLibrary, d.Id(), err)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists. Removing %s %s from tfstate", labelSupervisorNamespace, supervisorNamespaceName, labelSupervisorNamespaceContentLibrary, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	attachment, err := readSupervisorNamespaceContentLibrary(tmClient, endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists. Removing from tfstate", labelSupervisorNamespaceContentLibrary, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading %s: %s", labelSupervisorNamespaceContentLibrary, err)
+	}
+
+	contentLibraryId, err := govcd.BuildUrnWithUuid(contentLibraryUrnPrefix, attachment.Spec.UUID)
+	if err != nil {
+		return diag.Errorf("error building %s ID: %s", labelVcfaContentLibrary, err)
+	}
+
+	imageNames := []string{}
+	contentLibrary, err := tmClient.GetContentLibraryById(contentLibraryId, nil)
+	if err != nil && !govcd.ContainsNotFound(err) {
+		return diag.Errorf("error retrieving %s %s: %s", labelVcfaContentLibrary, contentLibraryId, err)
+	}
+	if err == nil {
+		imageNames, _, err = getContentLibraryImageNames(tmClient, contentLibrary, endpoint, supervisorNamespaceName)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+	dSet(d, "project_name", projectName)
+	dSet(d, "supervisor_namespace_name", supervisorNamespaceName)
+	dSet(d, "content_library_id", contentLibraryId)
+	dSet(d, "name", name)
+	dSet(d, "ready", attachment.Status != nil && isCciConditionTrue(attachment.Status.Conditions, "Ready"))
+	if err := d.Set("image_names", imageNames); err != nil {
+		return diag.Errorf("error setting image_names: %s", err)
+	}
+
+	return nil
+}
+
+func resourceVcfaSupervisorNamespaceContentLibraryDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, supervisorNamespaceName, name, err := parseSupervisorNamespaceObjectId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelSupervisorNamespaceContentLibrary, d.Id(), err)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		// The attachment is removed together with its Supervisor Namespace
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists, so %s %s is already detached", labelSupervisorNamespace, supervisorNamespaceName, labelVcfaContentLibrary, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	attachmentURL, err := buildSupervisorNamespaceContentLibraryURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelSupervisorNamespaceContentLibrary, err)
+	}
+	if err := deleteKubernetesObject(tmClient, attachmentURL); err != nil && !govcd.ContainsNotFound(err) {
+		return diag.Errorf("error detaching %s %s from %s %s: %s", labelVcfaContentLibrary, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+	if err := waitForKubernetesObjectDeletion(ctx, tmClient, attachmentURL, d.Timeout(schema.TimeoutDelete)); err != nil {
+		return diag.Errorf("error waiting for %s %s to be detached from %s %s: %s", labelVcfaContentLibrary, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func resourceVcfaSupervisorNamespaceContentLibraryImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	tmClient := meta.(ClientContainer).tmClient
+	idSlice := strings.Split(d.Id(), ImportSeparator)
+	if len(idSlice) != 3 {
+		return nil, fmt.Errorf("expected import ID to be <project_name>%s<supervisor_namespace_name>%s<content_library_name>", ImportSeparator, ImportSeparator)
+	}
+	projectName := idSlice[0]
+	supervisorNamespaceName := idSlice[1]
+	contentLibraryName := idSlice[2]
+
+	contentLibrary, err := tmClient.GetContentLibraryByName(contentLibraryName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving %s %s: %s", labelVcfaContentLibrary, contentLibraryName, err)
+	}
+	contentLibraryUuid, err := getContentLibraryUuid(contentLibrary.ContentLibrary.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return nil, err
+	}
+	name := getSupervisorNamespaceContentLibraryName(contentLibraryUuid)
+	if _, err := readSupervisorNamespaceContentLibrary(tmClient, endpoint, supervisorNamespaceName, name); err != nil {
+		return nil, fmt.Errorf("error reading %s %s in %s %s: %s", labelSupervisorNamespaceContentLibrary, contentLibraryName, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+	dSet(d, "project_name", projectName)
+	dSet(d, "supervisor_namespace_name", supervisorNamespaceName)
+	dSet(d, "content_library_id", contentLibrary.ContentLibrary.ID)
+	dSet(d, "wait_for_images", true)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// getContentLibraryUuid returns the UUID of the given Content Library ID, which is also the UUID of the vCenter
+// Content Library that backs it
+func getContentLibraryUuid(contentLibraryId string) (string, error) {
+	contentLibraryUuid := contentLibraryId[strings.LastIndex(contentLibraryId, ":")+1:]
+	if !govcd.IsUuid(contentLibraryUuid) {
+		return "", fmt.Errorf("could not extract the UUID of %s ID '%s'", labelVcfaContentLibrary, contentLibraryId)
+	}
+	return contentLibraryUuid, nil
+}
+
+// getSupervisorNamespaceContentLibraryName returns the name of the Kubernetes object that attaches the Content
+// Library with the given UUID to a Supervisor Namespace, so the same library can't be attached twice
+func getSupervisorNamespaceContentLibraryName(contentLibraryUuid string) string {
+	return "cl-" + strings.ToLower(contentLibraryUuid)
+}
+
+func buildSupervisorNamespaceContentLibraryURL(endpoint, supervisorNamespaceName, name string) (*url.URL, error) {
+	attachmentRawURL := strings.TrimSuffix(endpoint, "/") + fmt.Sprintf(cciContentLibrariesPath, url.PathEscape(supervisorNamespaceName))
+	if name != "" {
+		attachmentRawURL = attachmentRawURL + "/" + url.PathEscape(name)
+	}
+	return url.ParseRequestURI(attachmentRawURL)
+}
+
+func readSupervisorNamespaceContentLibrary(tmClient *VCDClient, endpoint, supervisorNamespaceName, name string) (cciContentLibrary, error) {
+	var attachment cciContentLibrary
+	attachmentURL, err := buildSupervisorNamespaceContentLibraryURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return attachment, fmt.Errorf("error building %s URL: %s", labelSupervisorNamespaceContentLibrary, err)
+	}
+	if err := performCciRequest(tmClient, http.MethodGet, attachmentURL, "application/json", nil, &attachment); err != nil {
+		return attachment, err
+	}
+	return attachment, nil
+}
+
+// getContentLibraryImageNames returns the names of the Virtual Machine Images of the Supervisor Namespace that
+// come from the items of the given Content Library, and the names of the items that are not available yet
+func getContentLibraryImageNames(tmClient *VCDClient, contentLibrary *govcd.ContentLibrary, endpoint, supervisorNamespaceName string) ([]string, []string, error) {
+	items, err := contentLibrary.GetAllContentLibraryItems(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving items of %s %s: %s", labelVcfaContentLibrary, contentLibrary.ContentLibrary.Name, err)
+	}
+	images, err := listVirtualMachineImages(tmClient, endpoint, supervisorNamespaceName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing %ss in %s %s: %s", labelVirtualMachineImage, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+	readyImages := map[string]bool{}
+	for _, image := range images {
+		readyImages[image.Name] = isCciConditionTrue(image.Status.Conditions, "Ready")
+	}
+
+	imageNames := []string{}
+	var pendingItems []string
+	for _, item := range items {
+		imageIdentifier := item.ContentLibraryItem.ImageIdentifier
+		if imageIdentifier != "" && readyImages[imageIdentifier] {
+			imageNames = append(imageNames, imageIdentifier)
+			continue
+		}
+		pendingItems = append(pendingItems, item.ContentLibraryItem.Name)
+	}
+	sort.Strings(imageNames)
+	return imageNames, pendingItems, nil
+}
+
+// waitForContentLibraryImages waits until all the items of the given Content Library are available as ready
+// Virtual Machine Images in the Supervisor Namespace
+func waitForContentLibraryImages(ctx context.Context, tmClient *VCDClient, contentLibrary *govcd.ContentLibrary, endpoint, supervisorNamespaceName string, timeout time.Duration) error {
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"SYNCING"},
+		Target:  []string{"SYNCED"},
+		Refresh: func() (any, string, error) {
+			imageNames, pendingItems, err := getContentLibraryImageNames(tmClient, contentLibrary, endpoint, supervisorNamespaceName)
+			if err != nil {
+				return nil, "", err
+			}
+			if len(pendingItems) > 0 {
+				log.Printf("[DEBUG] waiting for %d items of %s %s to be available in %s %s: %s", len(pendingItems), labelVcfaContentLibrary,
+					contentLibrary.ContentLibrary.Name, labelSupervisorNamespace, supervisorNamespaceName, strings.Join(pendingItems, ", "))
+				return imageNames, "SYNCING", nil
+			}
+			return imageNames, "SYNCED", nil
+		},
+		Timeout:    timeout,
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	_, err := stateChangeFunc.WaitForStateContext(ctx)
+	return err
+}
@@ -0,0 +1,93 @@
+//go:build cci || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccVcfaSupervisorNamespaceContentLibrary(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfSysAdmin(t)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	var params = StringMap{
+		"Testname":           t.Name(),
+		"ProjectName":        "tf-project-cl",
+		"Org":                testConfig.Org.Name,
+		"RegionName":         testConfig.Cci.Region,
+		"VpcName":            testConfig.Cci.Vpc,
+		"StorageClassName":   testConfig.Cci.StoragePolicy,
+		"SupervisorZoneName": testConfig.Cci.SupervisorZone,
+		"ContentLibrary":     testConfig.Cci.ContentLibrary,
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	// Setup project and defer cleanup
+	cleanup := setupProject(t, params["ProjectName"].(string))
+	defer cleanup()
+
+	configText1 := templateFill(testAccVcfaSupervisorNamespaceContentLibraryStep1, params)
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("vcfa_supervisor_namespace_content_library.test", "id", regexp.MustCompile(fmt.Sprintf(`^%s:terraform-test.*:cl-`, params["ProjectName"].(string)))),
+					resource.TestCheckResourceAttrPair("vcfa_supervisor_namespace_content_library.test", "content_library_id", "data.vcfa_content_library.test", "id"),
+					resource.TestCheckResourceAttr("vcfa_supervisor_namespace_content_library.test", "ready", "true"),
+					resource.TestCheckResourceAttrSet("vcfa_supervisor_namespace_content_library.test", "name"),
+					resource.TestCheckResourceAttrSet("vcfa_supervisor_namespace_content_library.test", "image_names.#"),
+				),
+			},
+			{
+				ResourceName:      "vcfa_supervisor_namespace_content_library.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					rs, ok := s.RootModule().Resources["vcfa_supervisor_namespace_content_library.test"]
+					if !ok {
+						return "", fmt.Errorf("resource vcfa_supervisor_namespace_content_library.test not found")
+					}
+					return params["ProjectName"].(string) + ImportSeparator + rs.Primary.Attributes["supervisor_namespace_name"] + ImportSeparator + params["ContentLibrary"].(string), nil
+				},
+			},
+		},
+	})
+}
+
+const testAccVcfaSupervisorNamespaceContentLibraryStep1 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+data "vcfa_org" "test" {
+  name = "{{.Org}}"
+}
+
+data "vcfa_content_library" "test" {
+  name   = "{{.ContentLibrary}}"
+  org_id = data.vcfa_org.test.id
+}
+
+resource "vcfa_supervisor_namespace_content_library" "test" {
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  content_library_id        = data.vcfa_content_library.test.id
+}
+`
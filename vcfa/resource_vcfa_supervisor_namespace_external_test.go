@@ -39,6 +39,7 @@ func TestAccVcfaSupervisorNamespaceExternal(t *testing.T) {
 		"VpcName":            testConfig.Cci.Vpc,
 		"StorageClassName":   testConfig.Cci.StoragePolicy,
 		"SupervisorZoneName": testConfig.Cci.SupervisorZone,
+		"Org":                testConfig.Org.Name,
 
 		"Tags": "cci",
 	}
@@ -53,10 +54,16 @@ func TestAccVcfaSupervisorNamespaceExternal(t *testing.T) {
 	configText2 := templateFill(testAccVcfaSupervisorNamespaceExternalStep2DS, params)
 	params["FuncName"] = t.Name() + "-step4"
 	configText4 := templateFill(testAccVcfaSupervisorNamespaceExternalStep4KubeConfig, params)
+	params["FuncName"] = t.Name() + "-step5"
+	configText5 := templateFill(testAccVcfaSupervisorNamespaceExternalStep5MultiKubeConfig, params)
+	params["FuncName"] = t.Name() + "-step6"
+	configText6 := templateFill(testAccVcfaSupervisorNamespaceExternalStep6Usage, params)
 
 	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
 	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
 	debugPrintf("#[DEBUG] CONFIGURATION step4: %s\n", configText4)
+	debugPrintf("#[DEBUG] CONFIGURATION step5: %s\n", configText5)
+	debugPrintf("#[DEBUG] CONFIGURATION step6: %s\n", configText6)
 
 	cachedNamespaceName := &testCachedFieldValue{}
 
@@ -106,6 +113,41 @@ func TestAccVcfaSupervisorNamespaceExternal(t *testing.T) {
 					resource.TestCheckResourceAttrSet("data.vcfa_kubeconfig.test-namespace", "kube_config_raw"),
 				),
 			},
+			{
+
+				Config: configText5,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.vcfa_kubeconfig.test-list", "contexts.#", "2"),
+					resource.TestCheckResourceAttr("data.vcfa_kubeconfig.test-list", "contexts.0.project_name", params["ProjectName"].(string)),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-list", "contexts.0.supervisor_namespace_name", "vcfa_supervisor_namespace.test", "name"),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-list", "contexts.1.supervisor_namespace_name", "vcfa_supervisor_namespace.test2", "name"),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-list", "current_context", "data.vcfa_kubeconfig.test-list", "contexts.1.name"),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-list", "host", "data.vcfa_kubeconfig.test-list", "contexts.1.host"),
+					resource.TestCheckResourceAttr("data.vcfa_kubeconfig.test-all", "contexts.#", "2"),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-all", "current_context", "data.vcfa_kubeconfig.test-all", "contexts.0.name"),
+					resource.TestCheckResourceAttrPair("data.vcfa_kubeconfig.test-all", "user", "data.vcfa_kubeconfig.test-list", "user"),
+				),
+			},
+			{
+
+				Config: configText6,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.vcfa_supervisor_namespace_usage.test", "id", "vcfa_supervisor_namespace.test", "id"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "zone.#", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "zone.0.name", params["SupervisorZoneName"].(string)),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "zone.0.cpu_limit_mhz", "100"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "zone.0.cpu_reservation_mhz", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "zone.0.memory_limit_mib", "200"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "zone.0.memory_reservation_mib", "2"),
+					resource.TestCheckResourceAttrSet("data.vcfa_supervisor_namespace_usage.test", "zone.0.memory_used_mib"),
+					resource.TestCheckResourceAttrSet("data.vcfa_supervisor_namespace_usage.test", "zone.0.memory_quota_mib"),
+					resource.TestCheckResourceAttrSet("data.vcfa_supervisor_namespace_usage.test", "zone.0.memory_used_percent"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "storage_class.#", "1"),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "storage_class.0.name", params["StorageClassName"].(string)),
+					resource.TestCheckResourceAttr("data.vcfa_supervisor_namespace_usage.test", "storage_class.0.limit_mib", "200"),
+					resource.TestCheckResourceAttrSet("data.vcfa_supervisor_namespace_usage.test", "storage_class.0.used_mib"),
+				),
+			},
 		},
 	})
 }
@@ -153,6 +195,55 @@ data "vcfa_kubeconfig" "test-namespace" {
 }
 `
 
+const testAccVcfaSupervisorNamespaceExternalStep5MultiKubeConfig = testAccVcfaSupervisorNamespaceExternalStep1 + `
+resource "vcfa_supervisor_namespace" "test2" {
+  name_prefix  = "terraform-test2"
+  project_name = "{{.ProjectName}}"
+  class_name   = "small"
+  description  = "Second Supervisor Namespace created by Terraform"
+  region_name  = "{{.RegionName}}"
+  vpc_name     = "{{.VpcName}}"
+
+  storage_classes_initial_class_config_overrides {
+    limit     = "200Mi"
+    name      = "{{.StorageClassName}}"
+  }
+
+  zones_initial_class_config_overrides {
+    cpu_limit          = "100M"
+    cpu_reservation    = "1M"
+    memory_limit       = "200Mi"
+    memory_reservation = "2Mi"
+    name               = "{{.SupervisorZoneName}}"
+  }
+}
+
+data "vcfa_kubeconfig" "test-list" {
+  supervisor_namespace {
+    project_name = vcfa_supervisor_namespace.test.project_name
+    name         = vcfa_supervisor_namespace.test.name
+  }
+  supervisor_namespace {
+    project_name = vcfa_supervisor_namespace.test2.project_name
+    name         = vcfa_supervisor_namespace.test2.name
+  }
+  current_context = "{{.Org}}:${vcfa_supervisor_namespace.test2.name}:{{.ProjectName}}"
+}
+
+data "vcfa_kubeconfig" "test-all" {
+  all_supervisor_namespaces_project_name = "{{.ProjectName}}"
+
+  depends_on = [vcfa_supervisor_namespace.test, vcfa_supervisor_namespace.test2]
+}
+`
+
+const testAccVcfaSupervisorNamespaceExternalStep6Usage = testAccVcfaSupervisorNamespaceExternalStep1 + `
+data "vcfa_supervisor_namespace_usage" "test" {
+  name         = vcfa_supervisor_namespace.test.name
+  project_name = vcfa_supervisor_namespace.test.project_name
+}
+`
+
 func setupProject(t *testing.T, projectName string) func() {
 	// setup project
 	tmClient := createTemporaryVCFAConnection(false)
@@ -0,0 +1,448 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	"sigs.k8s.io/yaml"
+)
+
+const labelSupervisorNamespaceManifest = "Supervisor Namespace Manifest"
+
+// defaultFieldManager is the field manager used for server-side apply operations when none is provided
+const defaultFieldManager = "terraform-provider-vcfa"
+
+func resourceVcfaSupervisorNamespaceManifest() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaSupervisorNamespaceManifestCreateUpdate,
+		ReadContext:   resourceVcfaSupervisorNamespaceManifestRead,
+		UpdateContext: resourceVcfaSupervisorNamespaceManifestCreateUpdate,
+		DeleteContext: resourceVcfaSupervisorNamespaceManifestDelete,
+		CustomizeDiff: resourceVcfaSupervisorNamespaceManifestCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the Project where the %s belongs to", labelSupervisorNamespace),
+			},
+			"supervisor_namespace_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the %s where the object is applied", labelSupervisorNamespace),
+			},
+			"manifest": {
+				Type:             schema.TypeString,
+				Required:         true,
+				Description:      "YAML or JSON definition of a single Kubernetes object. It must contain 'apiVersion', 'kind' and 'metadata.name'",
+				ValidateDiagFunc: validateKubernetesManifest,
+				DiffSuppressFunc: suppressEquivalentKubernetesManifest,
+			},
+			"field_manager": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     defaultFieldManager,
+				Description: "Name of the field manager that owns the fields of the manifest in server-side apply operations",
+			},
+			"force_conflicts": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to take ownership of the fields that are currently managed by other field managers",
+			},
+			"wait": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Conditions to wait for after the object is applied",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"condition": {
+							Type:        schema.TypeSet,
+							Optional:    true,
+							Description: "Status conditions that the object must report",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"type": {
+										Type:        schema.TypeString,
+										Required:    true,
+										Description: "Type of the condition, for example 'Ready'",
+									},
+									"status": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Default:     "True",
+										Description: "Expected status of the condition. Default 'True'",
+									},
+								},
+							},
+						},
+						"fields": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Description: "Map of dot separated field paths and the values they must have. Use '*' to wait for any non-empty value",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"api_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "API version of the object",
+			},
+			"kind": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Kind of the object",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the object",
+			},
+			"uid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Unique identifier of the object in the Supervisor Namespace",
+			},
+			"object": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "JSON representation of the whole object, as returned by the Supervisor Namespace. It is sensitive, as it can contain the data of Secrets",
+			},
+		},
+	}
+}
+
+func resourceVcfaSupervisorNamespaceManifestCreateUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName := d.Get("project_name").(string)
+	supervisorNamespaceName := d.Get("supervisor_namespace_name").(string)
+
+	manifest, err := parseKubernetesManifest(d.Get("manifest").(string))
+	if err != nil {
+		return diag.Errorf("error parsing %s: %s", labelSupervisorNamespaceManifest, err)
+	}
+	apiVersion, kind, name, err := getKubernetesManifestIdentity(manifest)
+	if err != nil {
+		return diag.Errorf("error parsing %s: %s", labelSupervisorNamespaceManifest, err)
+	}
+
+	// The object is always created in the Supervisor Namespace of this resource
+	metadata := manifest["metadata"].(map[string]interface{})
+	if namespace, ok := metadata["namespace"]; ok && namespace != supervisorNamespaceName {
+		return diag.Errorf("the namespace '%v' of the %s does not match the %s '%s'", namespace, labelSupervisorNamespaceManifest, labelSupervisorNamespace, supervisorNamespaceName)
+	}
+	metadata["namespace"] = supervisorNamespaceName
+
+	objectURL, err := getSupervisorNamespaceObjectURL(tmClient, projectName, supervisorNamespaceName, apiVersion, kind, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelSupervisorNamespaceManifest, err)
+	}
+
+	if _, err := applyKubernetesObject(tmClient, objectURL, d.Get("field_manager").(string), d.Get("force_conflicts").(bool), manifest); err != nil {
+		return diag.Errorf("error applying %s %s/%s in %s %s: %s", labelSupervisorNamespaceManifest, kind, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	d.SetId(buildSupervisorNamespaceManifestId(projectName, supervisorNamespaceName, apiVersion, kind, name))
+
+	conditions, fields := getKubernetesObjectWaitConfig(d)
+	if len(conditions) > 0 || len(fields) > 0 {
+		timeout := d.Timeout(schema.TimeoutCreate)
+		if !d.IsNewResource() {
+			timeout = d.Timeout(schema.TimeoutUpdate)
+		}
+		if _, err := waitForKubernetesObject(ctx, tmClient, objectURL, conditions, fields, timeout); err != nil {
+			return diag.Errorf("error waiting for %s %s/%s in %s %s: %s", labelSupervisorNamespaceManifest, kind, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+		}
+	}
+
+	return resourceVcfaSupervisorNamespaceManifestRead(ctx, d, meta)
+}
+
+// resourceVcfaSupervisorNamespaceManifestCustomizeDiff replaces the resource when the API version, kind or name of the
+// object change, as applying the new manifest would create another object and leave the previous one orphaned
+func resourceVcfaSupervisorNamespaceManifestCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" || !d.HasChange("manifest") || !d.NewValueKnown("manifest") {
+		return nil
+	}
+	oldValue, newValue := d.GetChange("manifest")
+	oldManifest, err := parseKubernetesManifest(oldValue.(string))
+	if err != nil {
+		return nil
+	}
+	newManifest, err := parseKubernetesManifest(newValue.(string))
+	if err != nil {
+		// The validation of the manifest reports the error
+		return nil
+	}
+	oldApiVersion, oldKind, oldName, err := getKubernetesManifestIdentity(oldManifest)
+	if err != nil {
+		return nil
+	}
+	newApiVersion, newKind, newName, err := getKubernetesManifestIdentity(newManifest)
+	if err != nil {
+		return nil
+	}
+	if oldApiVersion != newApiVersion || oldKind != newKind || oldName != newName {
+		return d.ForceNew("manifest")
+	}
+	return nil
+}
+
+func resourceVcfaSupervisorNamespaceManifestRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName := d.Get("project_name").(string)
+	supervisorNamespaceName := d.Get("supervisor_namespace_name").(string)
+
+	manifest, err := parseKubernetesManifest(d.Get("manifest").(string))
+	if err != nil {
+		return diag.Errorf("error parsing %s: %s", labelSupervisorNamespaceManifest, err)
+	}
+	apiVersion, kind, name, err := getKubernetesManifestIdentity(manifest)
+	if err != nil {
+		return diag.Errorf("error parsing %s: %s", labelSupervisorNamespaceManifest, err)
+	}
+
+	objectURL, err := getSupervisorNamespaceObjectURL(tmClient, projectName, supervisorNamespaceName, apiVersion, kind, name)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s/%s no longer exists. Removing from tfstate", labelSupervisorNamespaceManifest, kind, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error building %s URL: %s", labelSupervisorNamespaceManifest, err)
+	}
+
+	object, err := readKubernetesObject(tmClient, objectURL)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s/%s no longer exists. Removing from tfstate", labelSupervisorNamespaceManifest, kind, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading %s %s/%s: %s", labelSupervisorNamespaceManifest, kind, name, err)
+	}
+
+	if err := setSupervisorNamespaceManifestData(d, manifest, object); err != nil {
+		return diag.Errorf("error setting %s data: %s", labelSupervisorNamespaceManifest, err)
+	}
+
+	return nil
+}
+
+func resourceVcfaSupervisorNamespaceManifestDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName := d.Get("project_name").(string)
+	supervisorNamespaceName := d.Get("supervisor_namespace_name").(string)
+
+	manifest, err := parseKubernetesManifest(d.Get("manifest").(string))
+	if err != nil {
+		return diag.Errorf("error parsing %s: %s", labelSupervisorNamespaceManifest, err)
+	}
+	apiVersion, kind, name, err := getKubernetesManifestIdentity(manifest)
+	if err != nil {
+		return diag.Errorf("error parsing %s: %s", labelSupervisorNamespaceManifest, err)
+	}
+
+	objectURL, err := getSupervisorNamespaceObjectURL(tmClient, projectName, supervisorNamespaceName, apiVersion, kind, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelSupervisorNamespaceManifest, err)
+	}
+
+	if err := deleteKubernetesObject(tmClient, objectURL); err != nil {
+		if govcd.ContainsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error deleting %s %s/%s: %s", labelSupervisorNamespaceManifest, kind, name, err)
+	}
+
+	if err := waitForKubernetesObjectDeletion(ctx, tmClient, objectURL, d.Timeout(schema.TimeoutDelete)); err != nil {
+		return diag.Errorf("error waiting for %s %s/%s to be deleted: %s", labelSupervisorNamespaceManifest, kind, name, err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// getSupervisorNamespaceObjectURL returns the URL of a Kubernetes object that lives in the given Supervisor Namespace,
+// discovering the resource that serves its API version and kind
+func getSupervisorNamespaceObjectURL(tmClient *VCDClient, projectName, supervisorNamespaceName, apiVersion, kind, name string) (*url.URL, error) {
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return nil, err
+	}
+	apiResource, err := discoverKubernetesApiResource(tmClient, endpoint, apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	return buildKubernetesObjectURL(endpoint, apiVersion, apiResource, supervisorNamespaceName, name)
+}
+
+func buildSupervisorNamespaceManifestId(projectName, supervisorNamespaceName, apiVersion, kind, name string) string {
+	return strings.Join([]string{projectName, supervisorNamespaceName, apiVersion, kind, name}, ":")
+}
+
+// getKubernetesObjectWaitConfig retrieves the conditions and fields to wait for from the 'wait' block
+func getKubernetesObjectWaitConfig(d *schema.ResourceData) ([]kubernetesObjectCondition, map[string]string) {
+	var conditions []kubernetesObjectCondition
+	fields := map[string]string{}
+
+	waitList := d.Get("wait").([]interface{})
+	if len(waitList) == 0 || waitList[0] == nil {
+		return conditions, fields
+	}
+	wait := waitList[0].(map[string]interface{})
+	for _, c := range wait["condition"].(*schema.Set).List() {
+		condition := c.(map[string]interface{})
+		conditions = append(conditions, kubernetesObjectCondition{
+			conditionType: condition["type"].(string),
+			status:        condition["status"].(string),
+		})
+	}
+	for path, value := range wait["fields"].(map[string]interface{}) {
+		fields[path] = value.(string)
+	}
+	return conditions, fields
+}
+
+// parseKubernetesManifest converts a YAML or JSON manifest into a generic map
+func parseKubernetesManifest(manifest string) (map[string]interface{}, error) {
+	jsonManifest, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		return nil, fmt.Errorf("manifest is not valid YAML or JSON: %s", err)
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(jsonManifest, &result); err != nil {
+		return nil, fmt.Errorf("manifest must contain a single object: %s", err)
+	}
+	return result, nil
+}
+
+// getKubernetesManifestIdentity returns the API version, kind and name of the object defined in a manifest
+func getKubernetesManifestIdentity(manifest map[string]interface{}) (string, string, string, error) {
+	apiVersion, _ := manifest["apiVersion"].(string)
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if apiVersion == "" || kind == "" || name == "" {
+		return "", "", "", fmt.Errorf("manifest must contain 'apiVersion', 'kind' and 'metadata.name'")
+	}
+	return apiVersion, kind, name, nil
+}
+
+func validateKubernetesManifest(value interface{}, _ cty.Path) diag.Diagnostics {
+	manifest, err := parseKubernetesManifest(value.(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if _, _, _, err := getKubernetesManifestIdentity(manifest); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// suppressEquivalentKubernetesManifest suppresses the differences between manifests that define the same
+// object, even if they are written in different formats (YAML, JSON) or with different indentation
+func suppressEquivalentKubernetesManifest(_, oldValue, newValue string, _ *schema.ResourceData) bool {
+	oldManifest, err := parseKubernetesManifest(oldValue)
+	if err != nil {
+		return false
+	}
+	newManifest, err := parseKubernetesManifest(newValue)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(oldManifest, newManifest)
+}
+
+// projectKubernetesObject returns the subset of 'object' that has the same structure as 'manifest'. This
+// allows to compare only the fields that are managed by Terraform, ignoring the ones that the
+// Supervisor Namespace defaults or manages by itself
+func projectKubernetesObject(manifest, object map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, manifestValue := range manifest {
+		objectValue, ok := object[key]
+		if !ok {
+			continue
+		}
+		result[key] = projectKubernetesValue(manifestValue, objectValue)
+	}
+	return result
+}
+
+// projectKubernetesValue is the counterpart of projectKubernetesObject for any kind of value. Lists are
+// projected element by element only when they have the same length, otherwise the whole list is a drift
+func projectKubernetesValue(manifestValue, objectValue interface{}) interface{} {
+	switch manifestTyped := manifestValue.(type) {
+	case map[string]interface{}:
+		if objectMap, ok := objectValue.(map[string]interface{}); ok {
+			return projectKubernetesObject(manifestTyped, objectMap)
+		}
+	case []interface{}:
+		if objectList, ok := objectValue.([]interface{}); ok && len(objectList) == len(manifestTyped) {
+			result := make([]interface{}, len(objectList))
+			for i := range objectList {
+				result[i] = projectKubernetesValue(manifestTyped[i], objectList[i])
+			}
+			return result
+		}
+	}
+	return objectValue
+}
+
+func setSupervisorNamespaceManifestData(d *schema.ResourceData, manifest, object map[string]interface{}) error {
+	apiVersion, kind, name, err := getKubernetesManifestIdentity(manifest)
+	if err != nil {
+		return err
+	}
+	dSet(d, "api_version", apiVersion)
+	dSet(d, "kind", kind)
+	dSet(d, "name", name)
+
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		dSet(d, "uid", metadata["uid"])
+		// Managed fields are noise for the users and change on every apply
+		delete(metadata, "managedFields")
+	}
+
+	objectJson, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("error marshalling object: %s", err)
+	}
+	dSet(d, "object", string(objectJson))
+
+	// Only the fields present in the manifest are compared, so the drift on managed fields appears in plans
+	// while the fields defaulted by the Supervisor Namespace are ignored
+	drifted := projectKubernetesObject(manifest, object)
+	if !reflect.DeepEqual(drifted, manifest) {
+		driftedJson, err := json.Marshal(drifted)
+		if err != nil {
+			return fmt.Errorf("error marshalling managed fields: %s", err)
+		}
+		dSet(d, "manifest", string(driftedJson))
+	}
+
+	return nil
+}
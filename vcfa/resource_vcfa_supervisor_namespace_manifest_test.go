@@ -0,0 +1,203 @@
+//go:build cci || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+)
+
+func TestAccVcfaSupervisorNamespaceManifest(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfSysAdmin(t)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	var params = StringMap{
+		"Testname":           t.Name(),
+		"ProjectName":        "tf-project-manifest",
+		"RegionName":         testConfig.Cci.Region,
+		"VpcName":            testConfig.Cci.Vpc,
+		"StorageClassName":   testConfig.Cci.StoragePolicy,
+		"SupervisorZoneName": testConfig.Cci.SupervisorZone,
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	// Setup project and defer cleanup
+	cleanup := setupProject(t, params["ProjectName"].(string))
+	defer cleanup()
+
+	configText1 := templateFill(testAccVcfaSupervisorNamespaceManifestStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	configText2 := templateFill(testAccVcfaSupervisorNamespaceManifestStep2, params)
+	params["FuncName"] = t.Name() + "-step3"
+	configText3 := templateFill(testAccVcfaSupervisorNamespaceManifestStep3, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+	debugPrintf("#[DEBUG] CONFIGURATION step3: %s\n", configText3)
+
+	cachedId := &testCachedFieldValue{}
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("vcfa_supervisor_namespace_manifest.test", "id", regexp.MustCompile(fmt.Sprintf(`^%s:terraform-test.*:v1:ConfigMap:tf-config-map$`, params["ProjectName"].(string)))),
+					resource.TestCheckResourceAttr("vcfa_supervisor_namespace_manifest.test", "api_version", "v1"),
+					resource.TestCheckResourceAttr("vcfa_supervisor_namespace_manifest.test", "kind", "ConfigMap"),
+					resource.TestCheckResourceAttr("vcfa_supervisor_namespace_manifest.test", "name", "tf-config-map"),
+					resource.TestCheckResourceAttrSet("vcfa_supervisor_namespace_manifest.test", "uid"),
+					resource.TestMatchResourceAttr("vcfa_supervisor_namespace_manifest.test", "object", regexp.MustCompile(`"key1":"value1"`)),
+				),
+			},
+			{
+				Config: configText2,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcfa_supervisor_namespace_manifest.test", "field_manager", "tf-acceptance-test"),
+					resource.TestMatchResourceAttr("vcfa_supervisor_namespace_manifest.test", "object", regexp.MustCompile(`"key1":"value2"`)),
+					resource.TestMatchResourceAttr("vcfa_supervisor_namespace_manifest.test", "object", regexp.MustCompile(`"key2":"value3"`)),
+					cachedId.cacheTestResourceFieldValue("vcfa_supervisor_namespace_manifest.test", "id"),
+				),
+			},
+			{
+				// Renaming the object replaces the resource, so the previous object is removed
+				Config: configText3,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("vcfa_supervisor_namespace_manifest.test", "id", regexp.MustCompile(`:v1:ConfigMap:tf-config-map-renamed$`)),
+					resource.TestCheckResourceAttr("vcfa_supervisor_namespace_manifest.test", "name", "tf-config-map-renamed"),
+					testAccCheckSupervisorNamespaceManifestDeleted(cachedId),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckSupervisorNamespaceManifestDeleted checks that the object with the cached resource ID does not exist
+func testAccCheckSupervisorNamespaceManifestDeleted(cachedId *testCachedFieldValue) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		tmClient := testAccProvider.Meta().(ClientContainer).tmClient
+
+		// The ID is 'project:supervisor_namespace:api_version:kind:name', where the API version may contain ':'
+		idParts := strings.Split(cachedId.fieldValue, ":")
+		if len(idParts) < 5 {
+			return fmt.Errorf("unexpected %s ID '%s'", labelSupervisorNamespaceManifest, cachedId.fieldValue)
+		}
+		projectName, supervisorNamespaceName := idParts[0], idParts[1]
+		kind, name := idParts[len(idParts)-2], idParts[len(idParts)-1]
+		apiVersion := strings.Join(idParts[2:len(idParts)-2], ":")
+
+		objectURL, err := getSupervisorNamespaceObjectURL(tmClient, projectName, supervisorNamespaceName, apiVersion, kind, name)
+		if err != nil {
+			return err
+		}
+		_, err = readKubernetesObject(tmClient, objectURL)
+		if err == nil {
+			return fmt.Errorf("%s %s/%s still exists after being renamed", labelSupervisorNamespaceManifest, kind, name)
+		}
+		if !govcd.ContainsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+}
+
+const testAccVcfaSupervisorNamespaceManifestPrerequisites = `
+resource "vcfa_supervisor_namespace" "test" {
+  name_prefix  = "terraform-test"
+  project_name = "{{.ProjectName}}"
+  class_name   = "small"
+  description  = "Supervisor Namespace created by Terraform"
+  region_name  = "{{.RegionName}}"
+  vpc_name     = "{{.VpcName}}"
+
+  storage_classes_initial_class_config_overrides {
+    limit = "200Mi"
+    name  = "{{.StorageClassName}}"
+  }
+
+  zones_initial_class_config_overrides {
+    cpu_limit          = "100M"
+    cpu_reservation    = "1M"
+    memory_limit       = "200Mi"
+    memory_reservation = "2Mi"
+    name               = "{{.SupervisorZoneName}}"
+  }
+}
+`
+
+const testAccVcfaSupervisorNamespaceManifestStep1 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+resource "vcfa_supervisor_namespace_manifest" "test" {
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+
+  manifest = <<-EOT
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: tf-config-map
+    data:
+      key1: value1
+  EOT
+}
+`
+
+const testAccVcfaSupervisorNamespaceManifestStep2 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+resource "vcfa_supervisor_namespace_manifest" "test" {
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  field_manager             = "tf-acceptance-test"
+  force_conflicts           = true
+
+  manifest = jsonencode({
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    metadata = {
+      name = "tf-config-map"
+    }
+    data = {
+      key1 = "value2"
+      key2 = "value3"
+    }
+  })
+}
+`
+
+const testAccVcfaSupervisorNamespaceManifestStep3 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+resource "vcfa_supervisor_namespace_manifest" "test" {
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  field_manager             = "tf-acceptance-test"
+  force_conflicts           = true
+
+  manifest = jsonencode({
+    apiVersion = "v1"
+    kind       = "ConfigMap"
+    metadata = {
+      name = "tf-config-map-renamed"
+    }
+    data = {
+      key1 = "value2"
+      key2 = "value3"
+    }
+  })
+}
+`
@@ -0,0 +1,491 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const labelVirtualMachine = "Virtual Machine"
+
+// cloudInitSecretKey is the key of the Secret that holds the cloud-init user data of a Virtual Machine
+const cloudInitSecretKey = "user-data"
+
+func resourceVcfaVirtualMachine() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaVirtualMachineCreate,
+		ReadContext:   resourceVcfaVirtualMachineRead,
+		UpdateContext: resourceVcfaVirtualMachineUpdate,
+		DeleteContext: resourceVcfaVirtualMachineDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceVcfaVirtualMachineImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  fmt.Sprintf("Name of the %s", labelVirtualMachine),
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the Project where the %s belongs to", labelSupervisorNamespace),
+			},
+			"supervisor_namespace_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("The name of the %s where the %s is created", labelSupervisorNamespace, labelVirtualMachine),
+			},
+			"image_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("Name of the Virtual Machine Image used to create the %s, for example 'vmi-0123456789abcdef0'", labelVirtualMachine),
+			},
+			"class_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("Name of the VM Class of the %s. It must be one of the VM Classes of the %s", labelVirtualMachine, labelSupervisorNamespace),
+			},
+			"storage_class": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("Name of the Storage Class of the %s. It must be one of the Storage Classes of the %s", labelVirtualMachine, labelSupervisorNamespace),
+			},
+			"network_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: fmt.Sprintf("Name of the network the %s is connected to. If not set, the default network of the %s is used", labelVirtualMachine, labelSupervisorNamespace),
+			},
+			"cloud_init": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Sensitive:   true,
+				Description: fmt.Sprintf("Cloud-init user data used to bootstrap the %s", labelVirtualMachine),
+			},
+			"power_state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "PoweredOn",
+				Description:  fmt.Sprintf("Power state of the %s. One of 'PoweredOn', 'PoweredOff' or 'Suspended'", labelVirtualMachine),
+				ValidateFunc: validation.StringInSlice([]string{"PoweredOn", "PoweredOff", "Suspended"}, false),
+			},
+			"ip_address": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Primary IPv4 address of the %s", labelVirtualMachine),
+			},
+			"ipv6_address": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Primary IPv6 address of the %s", labelVirtualMachine),
+			},
+			"zone": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Zone where the %s is placed", labelVirtualMachine),
+			},
+			"unique_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Unique identifier of the %s in vCenter", labelVirtualMachine),
+			},
+		},
+	}
+}
+
+func resourceVcfaVirtualMachineCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	name := d.Get("name").(string)
+	projectName := d.Get("project_name").(string)
+	supervisorNamespaceName := d.Get("supervisor_namespace_name").(string)
+
+	endpoint, err := validateVirtualMachineClasses(tmClient, projectName, supervisorNamespaceName, d.Get("class_name").(string), d.Get("storage_class").(string))
+	if err != nil {
+		return diag.Errorf("error creating %s %s: %s", labelVirtualMachine, name, err)
+	}
+
+	vm := cciVirtualMachine{
+		TypeMeta: v1.TypeMeta{
+			Kind:       cciVirtualMachineKind,
+			APIVersion: cciVirtualMachineAPI + "/" + cciVirtualMachineVersion,
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: supervisorNamespaceName,
+		},
+		Spec: cciVirtualMachineSpec{
+			ImageName:    d.Get("image_name").(string),
+			ClassName:    d.Get("class_name").(string),
+			StorageClass: d.Get("storage_class").(string),
+			PowerState:   d.Get("power_state").(string),
+		},
+	}
+	if networkName := d.Get("network_name").(string); networkName != "" {
+		vm.Spec.Network = &cciVirtualMachineNetworkSpec{
+			Interfaces: []cciVirtualMachineNetworkInterfaceSpec{
+				{
+					Name:    "eth0",
+					Network: &cciVirtualMachineNetworkRef{Name: networkName},
+				},
+			},
+		}
+	}
+
+	// Cloud-init user data is consumed by the VM Operator from a Secret in the same Supervisor Namespace
+	if cloudInit := d.Get("cloud_init").(string); cloudInit != "" {
+		secretName := getVirtualMachineCloudInitSecretName(name)
+		if err := applyVirtualMachineCloudInitSecret(tmClient, endpoint, supervisorNamespaceName, secretName, cloudInit); err != nil {
+			return diag.Errorf("error creating cloud-init Secret for %s %s: %s", labelVirtualMachine, name, err)
+		}
+		vm.Spec.Bootstrap = &cciVirtualMachineBootstrapSpec{
+			CloudInit: &cciVirtualMachineCloudInitSpec{
+				RawCloudConfig: &cciSecretKeySelector{
+					Name: secretName,
+					Key:  cloudInitSecretKey,
+				},
+			},
+		}
+	}
+
+	vmURL, err := buildVirtualMachineURL(endpoint, supervisorNamespaceName, "")
+	if err == nil {
+		err = createCciEntity(tmClient, vmURL, &vm, &cciVirtualMachine{})
+	}
+	if err != nil {
+		// The cloud-init Secret would be leaked, as this resource is not saved in state
+		if vm.Spec.Bootstrap != nil {
+			if secretErr := deleteVirtualMachineCloudInitSecret(tmClient, endpoint, supervisorNamespaceName, name); secretErr != nil {
+				log.Printf("[ERROR] could not remove the cloud-init Secret of %s %s: %s", labelVirtualMachine, name, secretErr)
+			}
+		}
+		return diag.Errorf("error creating %s %s in %s %s: %s", labelVirtualMachine, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	// The ID is set before waiting, so a Virtual Machine that fails to power on is tainted instead of lost
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+
+	if err := waitForVirtualMachinePowerState(ctx, tmClient, endpoint, supervisorNamespaceName, name, d.Get("power_state").(string), d.Timeout(schema.TimeoutCreate)); err != nil {
+		return diag.Errorf("error waiting for %s %s in %s %s to be created: %s", labelVirtualMachine, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	return resourceVcfaVirtualMachineRead(ctx, d, meta)
+}
+
+func resourceVcfaVirtualMachineRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, supervisorNamespaceName, name, err := parseSupervisorNamespaceObjectId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelVirtualMachine, d.Id(), err)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists. Removing %s %s from tfstate", labelSupervisorNamespace, supervisorNamespaceName, labelVirtualMachine, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	vm, err := readVirtualMachine(tmClient, endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists. Removing from tfstate", labelVirtualMachine, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading %s: %s", labelVirtualMachine, err)
+	}
+
+	if err := setVirtualMachineData(d, projectName, supervisorNamespaceName, vm); err != nil {
+		return diag.Errorf("error setting %s data: %s", labelVirtualMachine, err)
+	}
+
+	return nil
+}
+
+func resourceVcfaVirtualMachineUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, supervisorNamespaceName, name, err := parseSupervisorNamespaceObjectId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelVirtualMachine, d.Id(), err)
+	}
+
+	// Power state is the only argument that can be updated in place
+	if !d.HasChange("power_state") {
+		return resourceVcfaVirtualMachineRead(ctx, d, meta)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	vmURL, err := buildVirtualMachineURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelVirtualMachine, err)
+	}
+	// Only the power state is sent, so the rest of the specification, which is not fully modelled by the
+	// provider (volumes, readiness probe, hardware version...), is kept untouched
+	powerState := d.Get("power_state").(string)
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"powerState": powerState,
+		},
+	}
+	if err := patchCciEntity(tmClient, vmURL, patch, nil); err != nil {
+		return diag.Errorf("error updating %s %s in %s %s: %s", labelVirtualMachine, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	if err := waitForVirtualMachinePowerState(ctx, tmClient, endpoint, supervisorNamespaceName, name, powerState, d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return diag.Errorf("error waiting for %s %s in %s %s to be updated: %s", labelVirtualMachine, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	return resourceVcfaVirtualMachineRead(ctx, d, meta)
+}
+
+func resourceVcfaVirtualMachineDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, supervisorNamespaceName, name, err := parseSupervisorNamespaceObjectId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelVirtualMachine, d.Id(), err)
+	}
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		// The Virtual Machine is removed together with its Supervisor Namespace
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists, so %s %s is already deleted", labelSupervisorNamespace, supervisorNamespaceName, labelVirtualMachine, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	vmURL, err := buildVirtualMachineURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelVirtualMachine, err)
+	}
+	if err := deleteKubernetesObject(tmClient, vmURL); err != nil && !govcd.ContainsNotFound(err) {
+		return diag.Errorf("error deleting %s %s in %s %s: %s", labelVirtualMachine, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+	if err := waitForKubernetesObjectDeletion(ctx, tmClient, vmURL, d.Timeout(schema.TimeoutDelete)); err != nil {
+		return diag.Errorf("error waiting for %s %s in %s %s to be deleted: %s", labelVirtualMachine, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+
+	if d.Get("cloud_init").(string) != "" {
+		if err := deleteVirtualMachineCloudInitSecret(tmClient, endpoint, supervisorNamespaceName, name); err != nil {
+			return diag.Errorf("error deleting cloud-init Secret of %s %s: %s", labelVirtualMachine, name, err)
+		}
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func resourceVcfaVirtualMachineImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	tmClient := meta.(ClientContainer).tmClient
+	idSlice := strings.Split(d.Id(), ImportSeparator)
+	if len(idSlice) != 3 {
+		return nil, fmt.Errorf("expected import ID to be <project_name>%s<supervisor_namespace_name>%s<virtual_machine_name>", ImportSeparator, ImportSeparator)
+	}
+	projectName := idSlice[0]
+	supervisorNamespaceName := idSlice[1]
+	name := idSlice[2]
+
+	endpoint, err := getSupervisorNamespaceEndpoint(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := readVirtualMachine(tmClient, endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", labelVirtualMachine, err)
+	}
+
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, name))
+	dSet(d, "project_name", projectName)
+	dSet(d, "supervisor_namespace_name", supervisorNamespaceName)
+	dSet(d, "name", name)
+	dSet(d, "image_name", vm.Spec.ImageName)
+	dSet(d, "class_name", vm.Spec.ClassName)
+	dSet(d, "storage_class", vm.Spec.StorageClass)
+	if vm.Spec.Network != nil && len(vm.Spec.Network.Interfaces) > 0 && vm.Spec.Network.Interfaces[0].Network != nil {
+		dSet(d, "network_name", vm.Spec.Network.Interfaces[0].Network.Name)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// validateVirtualMachineClasses checks that the given VM Class and Storage Class are available in the
+// Supervisor Namespace, and returns the Kubernetes endpoint of the latter
+func validateVirtualMachineClasses(tmClient *VCDClient, projectName, supervisorNamespaceName, className, storageClass string) (string, error) {
+	supervisorNamespace, err := readSupervisorNamespace(tmClient, projectName, supervisorNamespaceName)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %s", labelSupervisorNamespace, err)
+	}
+	if !isSupervisorNamespaceReady(supervisorNamespace) {
+		return "", fmt.Errorf("%s %s is not in a ready status", labelSupervisorNamespace, supervisorNamespaceName)
+	}
+
+	var vmClasses []string
+	for _, vmClass := range supervisorNamespace.Status.VMClasses {
+		vmClasses = append(vmClasses, vmClass.Name)
+	}
+	if !contains(vmClasses, className) {
+		return "", fmt.Errorf("VM Class '%s' is not available in %s %s. Available VM Classes: %v", className, labelSupervisorNamespace, supervisorNamespaceName, vmClasses)
+	}
+
+	var storageClasses []string
+	for _, sc := range supervisorNamespace.Status.StorageClasses {
+		storageClasses = append(storageClasses, sc.Name)
+	}
+	if !contains(storageClasses, storageClass) {
+		return "", fmt.Errorf("Storage Class '%s' is not available in %s %s. Available Storage Classes: %v", storageClass, labelSupervisorNamespace, supervisorNamespaceName, storageClasses)
+	}
+
+	if supervisorNamespace.Status.NamespaceEndpointURL == "" {
+		return "", fmt.Errorf("unable to retrieve the endpoint URL for %s %s", labelSupervisorNamespace, supervisorNamespaceName)
+	}
+	return supervisorNamespace.Status.NamespaceEndpointURL, nil
+}
+
+// waitForVirtualMachinePowerState waits until the Virtual Machine reaches the given power state. When the
+// expected power state is 'PoweredOn', it also waits for the Virtual Machine to get an IP address
+func waitForVirtualMachinePowerState(ctx context.Context, tmClient *VCDClient, endpoint, supervisorNamespaceName, name, powerState string, timeout time.Duration) error {
+	vmURL, err := buildVirtualMachineURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return fmt.Errorf("error building %s URL: %s", labelVirtualMachine, err)
+	}
+	fields := map[string]string{
+		"status.powerState": powerState,
+	}
+	if powerState == "PoweredOn" {
+		fields["status.network.primaryIP4"] = "*"
+	}
+	_, err = waitForKubernetesObject(ctx, tmClient, vmURL, nil, fields, timeout)
+	return err
+}
+
+// applyVirtualMachineCloudInitSecret creates or updates the Secret that holds the cloud-init user data
+func applyVirtualMachineCloudInitSecret(tmClient *VCDClient, endpoint, supervisorNamespaceName, secretName, cloudInit string) error {
+	secretURL, err := buildVirtualMachineCloudInitSecretURL(endpoint, supervisorNamespaceName, secretName)
+	if err != nil {
+		return err
+	}
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      secretName,
+			"namespace": supervisorNamespaceName,
+		},
+		"stringData": map[string]interface{}{
+			cloudInitSecretKey: cloudInit,
+		},
+	}
+	_, err = applyKubernetesObject(tmClient, secretURL, defaultFieldManager, true, secret)
+	return err
+}
+
+// deleteVirtualMachineCloudInitSecret removes the Secret that holds the cloud-init user data of the given Virtual
+// Machine, if it exists
+func deleteVirtualMachineCloudInitSecret(tmClient *VCDClient, endpoint, supervisorNamespaceName, name string) error {
+	secretURL, err := buildVirtualMachineCloudInitSecretURL(endpoint, supervisorNamespaceName, getVirtualMachineCloudInitSecretName(name))
+	if err != nil {
+		return fmt.Errorf("error building cloud-init Secret URL: %s", err)
+	}
+	if err := deleteKubernetesObject(tmClient, secretURL); err != nil && !govcd.ContainsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func getVirtualMachineCloudInitSecretName(name string) string {
+	return name + "-cloud-init"
+}
+
+func buildVirtualMachineCloudInitSecretURL(endpoint, supervisorNamespaceName, secretName string) (*url.URL, error) {
+	return buildKubernetesObjectURL(endpoint, "v1", &v1.APIResource{Name: "secrets", Namespaced: true}, supervisorNamespaceName, secretName)
+}
+
+func readVirtualMachine(tmClient *VCDClient, endpoint, supervisorNamespaceName, name string) (cciVirtualMachine, error) {
+	var vm cciVirtualMachine
+	vmURL, err := buildVirtualMachineURL(endpoint, supervisorNamespaceName, name)
+	if err != nil {
+		return vm, fmt.Errorf("error building %s URL: %s", labelVirtualMachine, err)
+	}
+	if err := performCciRequest(tmClient, http.MethodGet, vmURL, "application/json", nil, &vm); err != nil {
+		return vm, fmt.Errorf("error reading %s %s in %s %s: %s", labelVirtualMachine, name, labelSupervisorNamespace, supervisorNamespaceName, err)
+	}
+	return vm, nil
+}
+
+func buildVirtualMachineURL(endpoint, supervisorNamespaceName, name string) (*url.URL, error) {
+	vmRawURL := strings.TrimSuffix(endpoint, "/") + fmt.Sprintf(cciVirtualMachinesPath, url.PathEscape(supervisorNamespaceName))
+	if name != "" {
+		vmRawURL = vmRawURL + "/" + url.PathEscape(name)
+	}
+	return url.ParseRequestURI(vmRawURL)
+}
+
+func setVirtualMachineData(d *schema.ResourceData, projectName, supervisorNamespaceName string, vm cciVirtualMachine) error {
+	d.SetId(buildSupervisorNamespaceObjectId(projectName, supervisorNamespaceName, vm.Name))
+	dSet(d, "name", vm.Name)
+	dSet(d, "project_name", projectName)
+	dSet(d, "supervisor_namespace_name", supervisorNamespaceName)
+	dSet(d, "image_name", vm.Spec.ImageName)
+	dSet(d, "class_name", vm.Spec.ClassName)
+	dSet(d, "storage_class", vm.Spec.StorageClass)
+
+	ipAddress := ""
+	ipv6Address := ""
+	powerState := vm.Spec.PowerState
+	zone := ""
+	uniqueId := ""
+	// Status contains the effective power state, which may differ from the desired one if the
+	// Virtual Machine was modified outside Terraform
+	if vm.Status != nil {
+		if vm.Status.PowerState != "" {
+			powerState = vm.Status.PowerState
+		}
+		if vm.Status.Network != nil {
+			ipAddress = vm.Status.Network.PrimaryIP4
+			ipv6Address = vm.Status.Network.PrimaryIP6
+		}
+		zone = vm.Status.Zone
+		uniqueId = vm.Status.UniqueID
+	}
+	dSet(d, "power_state", powerState)
+	dSet(d, "ip_address", ipAddress)
+	dSet(d, "ipv6_address", ipv6Address)
+	dSet(d, "zone", zone)
+	dSet(d, "unique_id", uniqueId)
+
+	return nil
+}
@@ -0,0 +1,124 @@
+//go:build cci || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccVcfaVirtualMachine(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfSysAdmin(t)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	var params = StringMap{
+		"Testname":           t.Name(),
+		"ProjectName":        "tf-project-vm",
+		"VmName":             "tf-vm",
+		"RegionName":         testConfig.Cci.Region,
+		"VpcName":            testConfig.Cci.Vpc,
+		"StorageClassName":   testConfig.Cci.StoragePolicy,
+		"SupervisorZoneName": testConfig.Cci.SupervisorZone,
+		"VmImage":            testConfig.Cci.VmImage,
+		"VmClass":            testConfig.Cci.VmClass,
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	// Setup project and defer cleanup
+	cleanup := setupProject(t, params["ProjectName"].(string))
+	defer cleanup()
+
+	configText1 := templateFill(testAccVcfaVirtualMachineStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	configText2 := templateFill(testAccVcfaVirtualMachineStep2, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("vcfa_virtual_machine.test", "id", regexp.MustCompile(fmt.Sprintf(`^%s:terraform-test.*:%s$`, params["ProjectName"].(string), params["VmName"].(string)))),
+					resource.TestCheckResourceAttr("vcfa_virtual_machine.test", "name", params["VmName"].(string)),
+					resource.TestCheckResourceAttr("vcfa_virtual_machine.test", "image_name", params["VmImage"].(string)),
+					resource.TestCheckResourceAttr("vcfa_virtual_machine.test", "class_name", params["VmClass"].(string)),
+					resource.TestCheckResourceAttr("vcfa_virtual_machine.test", "storage_class", params["StorageClassName"].(string)),
+					resource.TestCheckResourceAttr("vcfa_virtual_machine.test", "power_state", "PoweredOn"),
+					resource.TestCheckResourceAttrSet("vcfa_virtual_machine.test", "ip_address"),
+					resource.TestCheckResourceAttrSet("vcfa_virtual_machine.test", "unique_id"),
+				),
+			},
+			{
+				Config: configText2,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcfa_virtual_machine.test", "power_state", "PoweredOff"),
+				),
+			},
+			{
+				ResourceName:            "vcfa_virtual_machine.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"cloud_init"},
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					rs, ok := s.RootModule().Resources["vcfa_virtual_machine.test"]
+					if !ok {
+						return "", fmt.Errorf("resource vcfa_virtual_machine.test not found")
+					}
+					return params["ProjectName"].(string) + ImportSeparator + rs.Primary.Attributes["supervisor_namespace_name"] + ImportSeparator + params["VmName"].(string), nil
+				},
+			},
+		},
+	})
+}
+
+const testAccVcfaVirtualMachineStep1 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+resource "vcfa_virtual_machine" "test" {
+  name                      = "{{.VmName}}"
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  image_name                = "{{.VmImage}}"
+  class_name                = "{{.VmClass}}"
+  storage_class             = "{{.StorageClassName}}"
+
+  cloud_init = <<-EOT
+    #cloud-config
+    hostname: {{.VmName}}
+  EOT
+}
+`
+
+const testAccVcfaVirtualMachineStep2 = testAccVcfaSupervisorNamespaceManifestPrerequisites + `
+resource "vcfa_virtual_machine" "test" {
+  name                      = "{{.VmName}}"
+  project_name              = vcfa_supervisor_namespace.test.project_name
+  supervisor_namespace_name = vcfa_supervisor_namespace.test.name
+  image_name                = "{{.VmImage}}"
+  class_name                = "{{.VmClass}}"
+  storage_class             = "{{.StorageClassName}}"
+  power_state               = "PoweredOff"
+
+  cloud_init = <<-EOT
+    #cloud-config
+    hostname: {{.VmName}}
+  EOT
+}
+`
@@ -0,0 +1,452 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vmware/go-vcloud-director/v3/govcd"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const labelVcfaVpc = "VPC"
+
+func resourceVcfaVpc() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVcfaVpcCreate,
+		ReadContext:   resourceVcfaVpcRead,
+		UpdateContext: resourceVcfaVpcUpdate,
+		DeleteContext: resourceVcfaVpcDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceVcfaVpcImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true, // VPC names cannot be changed
+				Description: fmt.Sprintf("Name of the %s", labelVcfaVpc),
+			},
+			"project_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true, // Update not supported
+				Description: fmt.Sprintf("The name of the Project the %s belongs to", labelVcfaVpc),
+			},
+			"region_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true, // Update not supported
+				Description: fmt.Sprintf("Name of the %s", labelVcfaRegion),
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description",
+			},
+			"private_ips": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true, // The backend assigns a private CIDR if none is provided
+				Description: fmt.Sprintf("A set of private IP CIDRs used by the %s", labelVcfaVpc),
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IsCIDR),
+				},
+			},
+			"connectivity_profile_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true, // The default VPC Connectivity Profile of the Region is used if not provided
+				ForceNew:    true, // Update not supported
+				Description: fmt.Sprintf("Name of the VPC Connectivity Profile used by the %s", labelVcfaVpc),
+			},
+			"is_default": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: fmt.Sprintf("Whether this %s is the default one of the Project in the %s", labelVcfaVpc, labelVcfaRegion),
+			},
+			"ingress_committed_bandwidth_mbps": {
+				Type:        schema.TypeString, // string + validation due to usual problem of differentiation between 0 and empty value for TypeInt
+				Optional:    true,
+				Computed:    true,
+				Description: fmt.Sprintf("Ingress committed bandwidth in Mbps for the %s. Inherited from %s if not set", labelVcfaVpc, labelVcfaOrgRegionalNetworkingVpcQos),
+				ValidateDiagFunc: validation.AnyDiag(
+					validation.ToDiagFunc(validation.StringIsEmpty),
+					IsIntAndAtLeast(-1), // -1 is unlimited
+				),
+				RequiredWith: []string{"ingress_burst_size_bytes"},
+			},
+			"ingress_burst_size_bytes": {
+				Type:        schema.TypeString, // string + validation due to usual problem of differentiation between 0 and empty value for TypeInt
+				Optional:    true,
+				Computed:    true,
+				Description: fmt.Sprintf("Ingress burst size bytes for the %s. Inherited from %s if not set", labelVcfaVpc, labelVcfaOrgRegionalNetworkingVpcQos),
+				ValidateDiagFunc: validation.AnyDiag(
+					validation.ToDiagFunc(validation.StringIsEmpty),
+					IsIntAndAtLeast(-1), // -1 is unlimited
+				),
+				RequiredWith: []string{"ingress_committed_bandwidth_mbps"},
+			},
+			"egress_committed_bandwidth_mbps": {
+				Type:        schema.TypeString, // string + validation due to usual problem of differentiation between 0 and empty value for TypeInt
+				Optional:    true,
+				Computed:    true,
+				Description: fmt.Sprintf("Egress committed bandwidth in Mbps for the %s. Inherited from %s if not set", labelVcfaVpc, labelVcfaOrgRegionalNetworkingVpcQos),
+				ValidateDiagFunc: validation.AnyDiag(
+					validation.ToDiagFunc(validation.StringIsEmpty),
+					IsIntAndAtLeast(-1), // -1 is unlimited
+				),
+				RequiredWith: []string{"egress_burst_size_bytes"},
+			},
+			"egress_burst_size_bytes": {
+				Type:        schema.TypeString, // string + validation due to usual problem of differentiation between 0 and empty value for TypeInt
+				Optional:    true,
+				Computed:    true,
+				Description: fmt.Sprintf("Egress burst size bytes for the %s. Inherited from %s if not set", labelVcfaVpc, labelVcfaOrgRegionalNetworkingVpcQos),
+				ValidateDiagFunc: validation.AnyDiag(
+					validation.ToDiagFunc(validation.StringIsEmpty),
+					IsIntAndAtLeast(-1), // -1 is unlimited
+				),
+				RequiredWith: []string{"egress_committed_bandwidth_mbps"},
+			},
+			"phase": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: fmt.Sprintf("Phase of the %s", labelVcfaVpc),
+			},
+			"ready": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: fmt.Sprintf("Whether the %s is in a ready status or not", labelVcfaVpc),
+			},
+		},
+	}
+}
+
+func resourceVcfaVpcCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName := d.Get("project_name").(string)
+	name := d.Get("name").(string)
+
+	vpc := cciVpc{
+		TypeMeta: v1.TypeMeta{
+			Kind:       cciVpcKind,
+			APIVersion: cciVpcAPI + "/" + cciVpcVersion,
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: projectName,
+		},
+		Spec: getVpcSpec(d),
+	}
+
+	if _, err := createVpc(tmClient, projectName, vpc); err != nil {
+		return diag.Errorf("error creating %s: %s", labelVcfaVpc, err)
+	}
+
+	// The ID is set before waiting, so a VPC that fails to become ready is tainted instead of lost
+	d.SetId(buildResourceId(projectName, name))
+
+	if err := waitForVpcReady(ctx, tmClient, projectName, name, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return diag.Errorf("error waiting for %s %s in Project %s to be created: %s", labelVcfaVpc, name, projectName, err)
+	}
+
+	return resourceVcfaVpcRead(ctx, d, meta)
+}
+
+func resourceVcfaVpcRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, name, err := parseResourceId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelVcfaVpc, d.Id(), err)
+	}
+
+	vpc, err := readVpc(tmClient, projectName, name)
+	if err != nil {
+		if govcd.ContainsNotFound(err) {
+			log.Printf("[DEBUG] %s %s no longer exists. Removing from tfstate", labelVcfaVpc, name)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error reading %s: %s", labelVcfaVpc, err)
+	}
+
+	if err := setVpcData(tmClient, d, projectName, name, vpc); err != nil {
+		return diag.Errorf("error setting %s data: %s", labelVcfaVpc, err)
+	}
+
+	return nil
+}
+
+func resourceVcfaVpcUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, name, err := parseResourceId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelVcfaVpc, d.Id(), err)
+	}
+
+	// The whole object must be sent back, including the resource version, to avoid overriding
+	// changes done by other clients in the meantime
+	vpc, err := readVpc(tmClient, projectName, name)
+	if err != nil {
+		return diag.Errorf("error reading %s: %s", labelVcfaVpc, err)
+	}
+	vpc.Spec = getVpcSpec(d)
+	vpc.Status = nil
+
+	vpcURL, err := buildVpcURL(tmClient, projectName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelVcfaVpc, err)
+	}
+	if err := updateCciEntity(tmClient, vpcURL, &vpc, &cciVpc{}); err != nil {
+		return diag.Errorf("error updating %s %s in Project %s: %s", labelVcfaVpc, name, projectName, err)
+	}
+
+	if err := waitForVpcReady(ctx, tmClient, projectName, name, d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return diag.Errorf("error waiting for %s %s in Project %s to be updated: %s", labelVcfaVpc, name, projectName, err)
+	}
+
+	return resourceVcfaVpcRead(ctx, d, meta)
+}
+
+func resourceVcfaVpcDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	tmClient := meta.(ClientContainer).tmClient
+	projectName, name, err := parseResourceId(d.Id())
+	if err != nil {
+		return diag.Errorf("error parsing %s resource id %s: %s", labelVcfaVpc, d.Id(), err)
+	}
+
+	vpcURL, err := buildVpcURL(tmClient, projectName, name)
+	if err != nil {
+		return diag.Errorf("error building %s URL: %s", labelVcfaVpc, err)
+	}
+	if err := tmClient.Client.DeleteEntity(vpcURL, nil, nil); err != nil {
+		return diag.Errorf("error deleting %s %s in Project %s: %s", labelVcfaVpc, name, projectName, err)
+	}
+
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"DELETING"},
+		Target:  []string{"DELETED"},
+		Refresh: func() (any, string, error) {
+			vpc, err := readVpc(tmClient, projectName, name)
+			if err != nil {
+				if govcd.ContainsNotFound(err) {
+					return "", "DELETED", nil
+				}
+				return nil, "", err
+			}
+
+			log.Printf("[DEBUG] %s %s is still being deleted", labelVcfaVpc, name)
+			return vpc, "DELETING", nil
+		},
+		Timeout:    d.Timeout(schema.TimeoutDelete),
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	if _, err = stateChangeFunc.WaitForStateContext(ctx); err != nil {
+		return diag.Errorf("error waiting for %s %s in Project %s to be deleted: %s", labelVcfaVpc, name, projectName, err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func resourceVcfaVpcImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	tmClient := meta.(ClientContainer).tmClient
+	idSlice := strings.Split(d.Id(), ImportSeparator)
+	if len(idSlice) != 2 {
+		return nil, fmt.Errorf("expected import ID to be <project_name>%s<vpc_name>", ImportSeparator)
+	}
+	projectName := idSlice[0]
+	name := idSlice[1]
+	if _, err := readVpc(tmClient, projectName, name); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", labelVcfaVpc, err)
+	}
+
+	d.SetId(buildResourceId(projectName, name))
+	dSet(d, "project_name", projectName)
+	dSet(d, "name", name)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// waitForVpcReady polls the given VPC until its Ready condition is true, or it reaches an ERROR phase
+func waitForVpcReady(ctx context.Context, tmClient *VCDClient, projectName, name string, timeout time.Duration) error {
+	stateChangeFunc := retry.StateChangeConf{
+		Pending: []string{"NOT_READY"},
+		Target:  []string{"READY"},
+		Refresh: func() (any, string, error) {
+			vpc, err := readVpc(tmClient, projectName, name)
+			if err != nil {
+				return nil, "", err
+			}
+			if vpc.Status == nil {
+				return vpc, "NOT_READY", nil
+			}
+
+			log.Printf("[DEBUG] %s %s current phase is %s", labelVcfaVpc, name, vpc.Status.Phase)
+			if strings.ToUpper(vpc.Status.Phase) == "ERROR" {
+				return nil, "", fmt.Errorf("%s %s is in an ERROR state: %s", labelVcfaVpc, name, getCciConditionMessage(vpc.Status.Conditions, "ready"))
+			}
+			if isCciConditionTrue(vpc.Status.Conditions, "ready") {
+				return vpc, "READY", nil
+			}
+			return vpc, "NOT_READY", nil
+		},
+		Timeout:    timeout,
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	_, err := stateChangeFunc.WaitForStateContext(ctx)
+	return err
+}
+
+func createVpc(tmClient *VCDClient, projectName string, vpc cciVpc) (cciVpc, error) {
+	var vpcOut cciVpc
+	vpcURL, err := buildVpcURL(tmClient, projectName, "")
+	if err != nil {
+		return vpc, fmt.Errorf("error building %s URL: %s", labelVcfaVpc, err)
+	}
+	if err := tmClient.Client.PostEntity(vpcURL, nil, &vpc, &vpcOut, nil); err != nil {
+		return vpc, fmt.Errorf("error creating %s in Project %s: %s", labelVcfaVpc, projectName, err)
+	}
+	return vpcOut, nil
+}
+
+func readVpc(tmClient *VCDClient, projectName, name string) (cciVpc, error) {
+	var vpc cciVpc
+	vpcURL, err := buildVpcURL(tmClient, projectName, name)
+	if err != nil {
+		return vpc, fmt.Errorf("error building %s URL: %s", labelVcfaVpc, err)
+	}
+	if err := tmClient.Client.GetEntity(vpcURL, nil, &vpc, nil); err != nil {
+		return vpc, fmt.Errorf("error reading %s %s in Project %s: %s", labelVcfaVpc, name, projectName, err)
+	}
+	return vpc, nil
+}
+
+func buildVpcURL(tmClient *VCDClient, projectName, name string) (*url.URL, error) {
+	vpcRawURL := fmt.Sprintf(cciVpcsURL, projectName)
+	if name != "" {
+		vpcRawURL = vpcRawURL + "/" + name
+	}
+	return tmClient.Client.GetEntityUrl(vpcRawURL)
+}
+
+func getVpcSpec(d *schema.ResourceData) cciVpcSpec {
+	spec := cciVpcSpec{
+		Description:                d.Get("description").(string),
+		RegionName:                 d.Get("region_name").(string),
+		PrivateIPs:                 convertSchemaSetToSliceOfStrings(d.Get("private_ips").(*schema.Set)),
+		VpcConnectivityProfileName: d.Get("connectivity_profile_name").(string),
+		IsDefault:                  d.Get("is_default").(bool),
+	}
+
+	// Only the QoS values that are explicitly configured are sent, the rest are inherited
+	// from the Org Regional Networking VPC Connectivity Profile
+	qos := &cciVpcQosConfig{}
+	ingressCommittedBandwidthMbps := getRawConfigString(d, "ingress_committed_bandwidth_mbps")
+	ingressBurstSizeBytes := getRawConfigString(d, "ingress_burst_size_bytes")
+	if ingressCommittedBandwidthMbps != "" && ingressBurstSizeBytes != "" { // schema requires both to be set if one is set
+		qos.IngressProfile = &cciVpcQosProfile{
+			CommittedBandwidthMbps: mustStrToInt(ingressCommittedBandwidthMbps), // schema validates that fields are ints
+			BurstSizeBytes:         mustStrToInt(ingressBurstSizeBytes),
+		}
+	}
+	egressCommittedBandwidthMbps := getRawConfigString(d, "egress_committed_bandwidth_mbps")
+	egressBurstSizeBytes := getRawConfigString(d, "egress_burst_size_bytes")
+	if egressCommittedBandwidthMbps != "" && egressBurstSizeBytes != "" { // schema requires both to be set if one is set
+		qos.EgressProfile = &cciVpcQosProfile{
+			CommittedBandwidthMbps: mustStrToInt(egressCommittedBandwidthMbps), // schema validates that fields are ints
+			BurstSizeBytes:         mustStrToInt(egressBurstSizeBytes),
+		}
+	}
+	if qos.IngressProfile != nil || qos.EgressProfile != nil {
+		spec.QosConfig = qos
+	}
+
+	return spec
+}
+
+// getRawConfigString returns the value of a string attribute only if it is present in the configuration.
+// This allows to differentiate between values explicitly set by the user and those that are
+// Computed and stored in state
+func getRawConfigString(d *schema.ResourceData, key string) string {
+	rawValue := d.GetRawConfig().GetAttr(key)
+	if rawValue.IsNull() || !rawValue.IsKnown() {
+		return ""
+	}
+	return rawValue.AsString()
+}
+
+func setVpcData(_ *VCDClient, d *schema.ResourceData, projectName, name string, vpc cciVpc) error {
+	d.SetId(buildResourceId(projectName, name))
+	dSet(d, "name", name)
+	dSet(d, "project_name", projectName)
+	dSet(d, "region_name", vpc.Spec.RegionName)
+	dSet(d, "description", vpc.Spec.Description)
+
+	privateIps := vpc.Spec.PrivateIPs
+	connectivityProfileName := vpc.Spec.VpcConnectivityProfileName
+	isDefault := vpc.Spec.IsDefault
+	qosConfig := vpc.Spec.QosConfig
+	phase := ""
+	ready := false
+	// Status contains the effective values, which may be assigned by the backend or
+	// inherited from the Org Regional Networking settings
+	if vpc.Status != nil {
+		phase = vpc.Status.Phase
+		ready = isCciConditionTrue(vpc.Status.Conditions, "ready")
+		if len(vpc.Status.PrivateIPs) > 0 {
+			privateIps = vpc.Status.PrivateIPs
+		}
+		if vpc.Status.VpcConnectivityProfileName != "" {
+			connectivityProfileName = vpc.Status.VpcConnectivityProfileName
+		}
+		if vpc.Status.IsDefault != nil {
+			isDefault = *vpc.Status.IsDefault
+		}
+		if vpc.Status.QosConfig != nil {
+			qosConfig = vpc.Status.QosConfig
+		}
+	}
+	dSet(d, "phase", phase)
+	dSet(d, "ready", ready)
+	dSet(d, "connectivity_profile_name", connectivityProfileName)
+	dSet(d, "is_default", isDefault)
+	if err := d.Set("private_ips", privateIps); err != nil {
+		return err
+	}
+
+	dSet(d, "ingress_committed_bandwidth_mbps", nil)
+	dSet(d, "ingress_burst_size_bytes", nil)
+	if qosConfig != nil && qosConfig.IngressProfile != nil {
+		dSet(d, "ingress_committed_bandwidth_mbps", strconv.Itoa(qosConfig.IngressProfile.CommittedBandwidthMbps))
+		dSet(d, "ingress_burst_size_bytes", strconv.Itoa(qosConfig.IngressProfile.BurstSizeBytes))
+	}
+
+	dSet(d, "egress_committed_bandwidth_mbps", nil)
+	dSet(d, "egress_burst_size_bytes", nil)
+	if qosConfig != nil && qosConfig.EgressProfile != nil {
+		dSet(d, "egress_committed_bandwidth_mbps", strconv.Itoa(qosConfig.EgressProfile.CommittedBandwidthMbps))
+		dSet(d, "egress_burst_size_bytes", strconv.Itoa(qosConfig.EgressProfile.BurstSizeBytes))
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+//go:build cci || ALL || functional
+
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: MPL-2.0
+
+package vcfa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccVcfaVpc(t *testing.T) {
+	preTestChecks(t)
+	defer postTestChecks(t)
+	skipIfSysAdmin(t)
+
+	if vcfaShortTest {
+		t.Skip(acceptanceTestsSkipped)
+		return
+	}
+
+	var params = StringMap{
+		"Testname":    t.Name(),
+		"ProjectName": "tf-project-vpc",
+		"VpcName":     "tf-vpc",
+		"RegionName":  testConfig.Cci.Region,
+
+		"Tags": "cci",
+	}
+	testParamsNotEmpty(t, params)
+
+	// Setup project and defer cleanup
+	cleanup := setupProject(t, params["ProjectName"].(string))
+	defer cleanup()
+
+	configText1 := templateFill(testAccVcfaVpcStep1, params)
+	params["FuncName"] = t.Name() + "-step2"
+	configText2 := templateFill(testAccVcfaVpcStep2, params)
+	params["FuncName"] = t.Name() + "-step3"
+	configText3 := templateFill(testAccVcfaVpcStep3DS, params)
+
+	debugPrintf("#[DEBUG] CONFIGURATION step1: %s\n", configText1)
+	debugPrintf("#[DEBUG] CONFIGURATION step2: %s\n", configText2)
+	debugPrintf("#[DEBUG] CONFIGURATION step3: %s\n", configText3)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: configText1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "id", fmt.Sprintf("%s:%s", params["ProjectName"], params["VpcName"])),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "name", params["VpcName"].(string)),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "region_name", params["RegionName"].(string)),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "description", "VPC created by Terraform"),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "private_ips.#", "1"),
+					resource.TestCheckTypeSetElemAttr("vcfa_vpc.test", "private_ips.*", "172.16.0.0/24"),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "ready", "true"),
+					resource.TestCheckResourceAttrSet("vcfa_vpc.test", "connectivity_profile_name"),
+					resource.TestCheckResourceAttrSet("vcfa_vpc.test", "ingress_committed_bandwidth_mbps"),
+					resource.TestCheckResourceAttrSet("vcfa_vpc.test", "egress_committed_bandwidth_mbps"),
+				),
+			},
+			{
+				Config: configText2,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "description", "VPC updated by Terraform"),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "private_ips.#", "2"),
+					resource.TestCheckTypeSetElemAttr("vcfa_vpc.test", "private_ips.*", "172.16.0.0/24"),
+					resource.TestCheckTypeSetElemAttr("vcfa_vpc.test", "private_ips.*", "172.16.1.0/24"),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "ingress_committed_bandwidth_mbps", "100"),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "ingress_burst_size_bytes", "1000"),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "egress_committed_bandwidth_mbps", "200"),
+					resource.TestCheckResourceAttr("vcfa_vpc.test", "egress_burst_size_bytes", "2000"),
+				),
+			},
+			{
+				Config: configText3,
+				Check: resource.ComposeTestCheckFunc(
+					resourceFieldsEqual("data.vcfa_vpc.test", "vcfa_vpc.test", nil),
+				),
+			},
+			{
+				ResourceName:      "vcfa_vpc.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateId:     params["ProjectName"].(string) + ImportSeparator + params["VpcName"].(string),
+			},
+		},
+	})
+}
+
+const testAccVcfaVpcStep1 = `
+resource "vcfa_vpc" "test" {
+  name         = "{{.VpcName}}"
+  project_name = "{{.ProjectName}}"
+  region_name  = "{{.RegionName}}"
+  description  = "VPC created by Terraform"
+  private_ips  = ["172.16.0.0/24"]
+}
+`
+
+const testAccVcfaVpcStep2 = `
+resource "vcfa_vpc" "test" {
+  name         = "{{.VpcName}}"
+  project_name = "{{.ProjectName}}"
+  region_name  = "{{.RegionName}}"
+  description  = "VPC updated by Terraform"
+  private_ips  = ["172.16.0.0/24", "172.16.1.0/24"]
+
+  ingress_committed_bandwidth_mbps = "100"
+  ingress_burst_size_bytes         = "1000"
+  egress_committed_bandwidth_mbps  = "200"
+  egress_burst_size_bytes          = "2000"
+}
+`
+
+const testAccVcfaVpcStep3DS = testAccVcfaVpcStep2 + `
+data "vcfa_vpc" "test" {
+  name         = vcfa_vpc.test.name
+  project_name = vcfa_vpc.test.project_name
+}
+`
@@ -7,6 +7,7 @@ package vcfa
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
@@ -31,3 +32,22 @@ func IsIntAndAtLeast(min int) schema.SchemaValidateDiagFunc {
 		return warnings, errors
 	})
 }
+
+// IsPositiveDuration returns a SchemaValidateFunc which tests if the provided value string is a valid
+// duration, like '30m' or '2h', and is greater than zero
+func IsPositiveDuration() schema.SchemaValidateDiagFunc {
+	return validation.ToDiagFunc(func(i interface{}, k string) (warnings []string, errors []error) {
+		value, err := time.ParseDuration(i.(string))
+		if err != nil {
+			errors = append(errors, fmt.Errorf("expected %s to be a duration like '30m' or '2h', got %s", k, i.(string)))
+			return warnings, errors
+		}
+
+		if value <= 0 {
+			errors = append(errors, fmt.Errorf("expected %s to be greater than zero, got %s", k, i.(string)))
+			return warnings, errors
+		}
+
+		return warnings, errors
+	})
+}